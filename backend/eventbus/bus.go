@@ -0,0 +1,78 @@
+package eventbus
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+const (
+	BackendKafka  = "kafka"
+	BackendMemory = "memory"
+)
+
+var ErrClosed = errors.New("event bus is closed")
+
+type Message struct {
+	Topic     string
+	Key       []byte
+	Value     []byte
+	Headers   map[string]string
+	Partition int
+	Offset    int64
+	Time      time.Time
+}
+
+// Handler processes one message. Returning an error leaves the message
+// uncommitted so it is redelivered to the group, giving at-least-once
+// delivery on both backends.
+type Handler func(ctx context.Context, msg Message) error
+
+type EventBus interface {
+	Publish(ctx context.Context, msgs ...Message) error
+	// Subscribe joins the consumer group and blocks, delivering messages in
+	// partition order until ctx is cancelled or the bus is closed.
+	Subscribe(ctx context.Context, topic, group string, handler Handler) error
+	Close() error
+}
+
+const (
+	retryBackoffMin = 100 * time.Millisecond
+	retryBackoffMax = 10 * time.Second
+)
+
+func nextBackoff(d time.Duration) time.Duration {
+	if d == 0 {
+		return retryBackoffMin
+	}
+	d *= 2
+	if d > retryBackoffMax {
+		return retryBackoffMax
+	}
+	return d
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// Position selects where a replay starts in every partition. A non-zero Time
+// wins over Offset; a negative Offset means the earliest retained message.
+type Position struct {
+	Offset int64
+	Time   time.Time
+}
+
+// Replayer reads a topic outside of any consumer group, from a position up
+// to the end offsets observed when the replay started, without committing.
+type Replayer interface {
+	Replay(ctx context.Context, topic string, from Position, handler Handler) error
+}
@@ -0,0 +1,219 @@
+package eventbus
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+type KafkaBus struct {
+	brokers []string
+	writer  *kafka.Writer
+
+	mu      sync.Mutex
+	readers []*kafka.Reader
+	closed  bool
+}
+
+func NewKafkaBus(brokers ...string) *KafkaBus {
+	return &KafkaBus{
+		brokers: brokers,
+		writer: &kafka.Writer{
+			Addr:         kafka.TCP(brokers...),
+			Balancer:     &kafka.Hash{},
+			BatchTimeout: 10 * time.Millisecond,
+		},
+	}
+}
+
+func (b *KafkaBus) Publish(ctx context.Context, msgs ...Message) error {
+	kmsgs := make([]kafka.Message, 0, len(msgs))
+	for _, m := range msgs {
+		km := kafka.Message{
+			Topic: m.Topic,
+			Key:   m.Key,
+			Value: m.Value,
+		}
+		for k, v := range m.Headers {
+			km.Headers = append(km.Headers, kafka.Header{Key: k, Value: []byte(v)})
+		}
+		kmsgs = append(kmsgs, km)
+	}
+
+	if err := b.writer.WriteMessages(ctx, kmsgs...); err != nil {
+		return fmt.Errorf("failed to write message to Kafka: %w", err)
+	}
+	return nil
+}
+
+func (b *KafkaBus) Subscribe(ctx context.Context, topic, group string, handler Handler) error {
+	reader := kafka.NewReader(kafka.ReaderConfig{
+		Brokers:     b.brokers,
+		Topic:       topic,
+		GroupID:     group,
+		MinBytes:    10e3,
+		MaxBytes:    10e6,
+		StartOffset: kafka.FirstOffset,
+	})
+	if err := b.track(reader); err != nil {
+		reader.Close()
+		return err
+	}
+	defer reader.Close()
+
+	log.Printf("✅ Kafka consumer initialized for topic: %s (group: %s)", topic, group)
+
+	for {
+		km, err := reader.FetchMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if b.isClosed() {
+				return ErrClosed
+			}
+			log.Printf("⚠️ Error reading Kafka message: %v", err)
+			if err := sleepContext(ctx, time.Second); err != nil {
+				return err
+			}
+			continue
+		}
+
+		msg := fromKafkaMessage(km)
+		var backoff time.Duration
+		for {
+			err := handler(ctx, msg)
+			if err == nil {
+				break
+			}
+			backoff = nextBackoff(backoff)
+			log.Printf("⚠️ Handler failed for %s[%d]@%d, retrying in %s: %v", topic, km.Partition, km.Offset, backoff, err)
+			if err := sleepContext(ctx, backoff); err != nil {
+				return err
+			}
+		}
+
+		if err := reader.CommitMessages(ctx, km); err != nil && ctx.Err() == nil {
+			log.Printf("⚠️ Failed to commit Kafka offset: %v", err)
+		}
+	}
+}
+
+func (b *KafkaBus) Close() error {
+	b.mu.Lock()
+	b.closed = true
+	readers := b.readers
+	b.readers = nil
+	b.mu.Unlock()
+
+	for _, r := range readers {
+		r.Close()
+	}
+	return b.writer.Close()
+}
+
+func (b *KafkaBus) track(r *kafka.Reader) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return ErrClosed
+	}
+	b.readers = append(b.readers, r)
+	return nil
+}
+
+func (b *KafkaBus) isClosed() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.closed
+}
+
+func fromKafkaMessage(km kafka.Message) Message {
+	headers := make(map[string]string, len(km.Headers))
+	for _, h := range km.Headers {
+		headers[h.Key] = string(h.Value)
+	}
+
+	return Message{
+		Topic:     km.Topic,
+		Key:       km.Key,
+		Value:     km.Value,
+		Headers:   headers,
+		Partition: km.Partition,
+		Offset:    km.Offset,
+		Time:      km.Time,
+	}
+}
+
+func (b *KafkaBus) Replay(ctx context.Context, topic string, from Position, handler Handler) error {
+	conn, err := kafka.DialContext(ctx, "tcp", b.brokers[0])
+	if err != nil {
+		return fmt.Errorf("failed to connect to Kafka: %w", err)
+	}
+	partitions, err := conn.ReadPartitions(topic)
+	conn.Close()
+	if err != nil {
+		return fmt.Errorf("failed to read partitions of %s: %w", topic, err)
+	}
+
+	for _, p := range partitions {
+		if err := b.replayPartition(ctx, topic, p.ID, from, handler); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *KafkaBus) replayPartition(ctx context.Context, topic string, partition int, from Position, handler Handler) error {
+	leader, err := kafka.DialLeader(ctx, "tcp", b.brokers[0], topic, partition)
+	if err != nil {
+		return fmt.Errorf("failed to dial leader for %s[%d]: %w", topic, partition, err)
+	}
+	first, last, err := leader.ReadOffsets()
+	if err == nil && !from.Time.IsZero() {
+		first, err = leader.ReadOffset(from.Time)
+	}
+	leader.Close()
+	if err != nil {
+		return fmt.Errorf("failed to read offsets for %s[%d]: %w", topic, partition, err)
+	}
+
+	start := first
+	if from.Time.IsZero() && from.Offset > first {
+		start = from.Offset
+	}
+	if start >= last {
+		return nil
+	}
+
+	reader := kafka.NewReader(kafka.ReaderConfig{
+		Brokers:   b.brokers,
+		Topic:     topic,
+		Partition: partition,
+		MinBytes:  1,
+		MaxBytes:  10e6,
+	})
+	defer reader.Close()
+
+	if err := reader.SetOffset(start); err != nil {
+		return fmt.Errorf("failed to seek %s[%d] to %d: %w", topic, partition, start, err)
+	}
+
+	for {
+		km, err := reader.FetchMessage(ctx)
+		if err != nil {
+			return err
+		}
+		if err := handler(ctx, fromKafkaMessage(km)); err != nil {
+			return err
+		}
+		if km.Offset+1 >= last {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,281 @@
+package eventbus
+
+import (
+	"context"
+	"hash/fnv"
+	"log"
+	"sync"
+	"time"
+)
+
+const DefaultMemoryPartitions = 4
+
+// MemoryBus is an in-process EventBus for local development and tests. Like
+// Kafka it keeps an append-only log per partition, routes messages by key
+// hash, tracks committed offsets per consumer group and splits partitions
+// between the members of a group. Nothing survives a restart.
+type MemoryBus struct {
+	partitions int
+
+	mu     sync.Mutex
+	topics map[string]*memTopic
+	closed bool
+	done   chan struct{}
+}
+
+type memTopic struct {
+	partitions [][]Message
+	groups     map[string]*memGroup
+	notify     chan struct{}
+}
+
+type memGroup struct {
+	offsets []int64
+	members []*memMember
+}
+
+type memMember struct {
+	group string
+}
+
+func NewMemoryBus(partitions int) *MemoryBus {
+	if partitions < 1 {
+		partitions = DefaultMemoryPartitions
+	}
+	return &MemoryBus{
+		partitions: partitions,
+		topics:     make(map[string]*memTopic),
+		done:       make(chan struct{}),
+	}
+}
+
+func (b *MemoryBus) Publish(ctx context.Context, msgs ...Message) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return ErrClosed
+	}
+
+	for _, m := range msgs {
+		t := b.topic(m.Topic)
+		p := b.partitionFor(m.Key)
+
+		m.Partition = p
+		m.Offset = int64(len(t.partitions[p]))
+		if m.Time.IsZero() {
+			m.Time = time.Now().UTC()
+		}
+		t.partitions[p] = append(t.partitions[p], m)
+
+		close(t.notify)
+		t.notify = make(chan struct{})
+	}
+	return nil
+}
+
+func (b *MemoryBus) Subscribe(ctx context.Context, topic, group string, handler Handler) error {
+	me := &memMember{group: group}
+	if err := b.join(topic, group, me); err != nil {
+		return err
+	}
+	defer b.leave(topic, group, me)
+
+	backoff := make(map[int]time.Duration)
+
+	for {
+		msgs := b.pending(topic, group, me)
+
+		for _, msg := range msgs {
+			if err := handler(ctx, msg); err != nil {
+				backoff[msg.Partition] = nextBackoff(backoff[msg.Partition])
+				log.Printf("⚠️ Handler failed for %s[%d]@%d, retrying in %s: %v", topic, msg.Partition, msg.Offset, backoff[msg.Partition], err)
+				if err := sleepContext(ctx, backoff[msg.Partition]); err != nil {
+					return err
+				}
+				continue
+			}
+			delete(backoff, msg.Partition)
+			b.commit(topic, group, msg)
+		}
+
+		if len(msgs) > 0 {
+			continue
+		}
+
+		b.mu.Lock()
+		if b.closed {
+			b.mu.Unlock()
+			return ErrClosed
+		}
+		notify := b.topic(topic).notify
+		b.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-b.done:
+			return ErrClosed
+		case <-notify:
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+func (b *MemoryBus) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !b.closed {
+		b.closed = true
+		close(b.done)
+	}
+	return nil
+}
+
+// Len reports how many messages have been published to a topic.
+func (b *MemoryBus) Len(topic string) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	n := 0
+	if t, ok := b.topics[topic]; ok {
+		for _, p := range t.partitions {
+			n += len(p)
+		}
+	}
+	return n
+}
+
+// Lag reports how many messages of a topic a group has not committed yet.
+func (b *MemoryBus) Lag(topic, group string) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	t, ok := b.topics[topic]
+	if !ok {
+		return 0
+	}
+
+	lag := 0
+	g := t.groups[group]
+	for p, msgs := range t.partitions {
+		committed := int64(0)
+		if g != nil {
+			committed = g.offsets[p]
+		}
+		lag += len(msgs) - int(committed)
+	}
+	return lag
+}
+
+func (b *MemoryBus) topic(name string) *memTopic {
+	t, ok := b.topics[name]
+	if !ok {
+		t = &memTopic{
+			partitions: make([][]Message, b.partitions),
+			groups:     make(map[string]*memGroup),
+			notify:     make(chan struct{}),
+		}
+		b.topics[name] = t
+	}
+	return t
+}
+
+func (b *MemoryBus) partitionFor(key []byte) int {
+	if len(key) == 0 {
+		return 0
+	}
+	h := fnv.New32a()
+	h.Write(key)
+	return int(h.Sum32() % uint32(b.partitions))
+}
+
+func (b *MemoryBus) join(topic, group string, me *memMember) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return ErrClosed
+	}
+
+	t := b.topic(topic)
+	g, ok := t.groups[group]
+	if !ok {
+		g = &memGroup{offsets: make([]int64, b.partitions)}
+		t.groups[group] = g
+	}
+	g.members = append(g.members, me)
+	return nil
+}
+
+func (b *MemoryBus) leave(topic, group string, me *memMember) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	g := b.topic(topic).groups[group]
+	for i, m := range g.members {
+		if m == me {
+			g.members = append(g.members[:i], g.members[i+1:]...)
+			break
+		}
+	}
+}
+
+// pending returns the next uncommitted message of every partition currently
+// assigned to me. Partitions are assigned round-robin over the members in
+// join order, so a rebalance happens whenever a member joins or leaves.
+func (b *MemoryBus) pending(topic, group string, me *memMember) []Message {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	t := b.topic(topic)
+	g := t.groups[group]
+
+	var msgs []Message
+	for p := range t.partitions {
+		if g.members[p%len(g.members)] != me {
+			continue
+		}
+		if off := g.offsets[p]; off < int64(len(t.partitions[p])) {
+			msgs = append(msgs, t.partitions[p][off])
+		}
+	}
+	return msgs
+}
+
+func (b *MemoryBus) commit(topic, group string, msg Message) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	g := b.topic(topic).groups[group]
+	if g.offsets[msg.Partition] == msg.Offset {
+		g.offsets[msg.Partition] = msg.Offset + 1
+	}
+}
+
+func (b *MemoryBus) Replay(ctx context.Context, topic string, from Position, handler Handler) error {
+	b.mu.Lock()
+	var snapshot [][]Message
+	if t, ok := b.topics[topic]; ok {
+		for _, p := range t.partitions {
+			snapshot = append(snapshot, append([]Message(nil), p...))
+		}
+	}
+	b.mu.Unlock()
+
+	for _, msgs := range snapshot {
+		for _, msg := range msgs {
+			if !from.Time.IsZero() && msg.Time.Before(from.Time) {
+				continue
+			}
+			if from.Time.IsZero() && msg.Offset < from.Offset {
+				continue
+			}
+			if err := handler(ctx, msg); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
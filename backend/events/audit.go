@@ -0,0 +1,42 @@
+package events
+
+import (
+	"encoding/json"
+	"time"
+
+	"cinema-booking-system/models"
+)
+
+func ToAuditLog(env Envelope, p Payload) models.AuditLog {
+	subject := p.Subject()
+
+	var payload map[string]interface{}
+	json.Unmarshal(env.Payload, &payload)
+
+	return models.AuditLog{
+		EventID:       env.EventID,
+		EventType:     env.Type,
+		SchemaVersion: env.SchemaVersion,
+		SessionID:     subject.SessionID,
+		UserID:        subject.UserID,
+		SeatIDs:       subject.SeatIDs,
+		ActorType:     env.Actor.Type,
+		ActorID:       env.Actor.ID,
+		CorrelationID: env.CorrelationID,
+		Timestamp:     env.OccurredAt,
+		Description:   p.Describe(),
+		Payload:       payload,
+	}
+}
+
+func DecodeLegacy(data []byte) (models.AuditLog, error) {
+	var auditLog models.AuditLog
+	if err := json.Unmarshal(data, &auditLog); err != nil {
+		return models.AuditLog{}, err
+	}
+
+	if auditLog.Timestamp.IsZero() {
+		auditLog.Timestamp = time.Now().UTC()
+	}
+	return auditLog, nil
+}
@@ -0,0 +1,22 @@
+// Package events defines the versioned envelope and typed payloads that the
+// backend publishes to the audit topic.
+//
+// Every message on the topic is an Envelope whose Payload is the JSON encoding
+// of one registered payload type at the envelope's SchemaVersion. Producers
+// build envelopes through a Registry, which refuses unregistered types and
+// payloads that fail Validate. Consumers decode through the same Registry,
+// which rejects unknown types and versions newer than the ones it knows.
+//
+// Compatibility rules for evolving a payload:
+//
+//   - Adding an optional field is backward compatible and does not bump the
+//     version. Consumers ignore fields they do not know.
+//   - Renaming, removing or changing the meaning of a field bumps the version.
+//     The previous version stays registered with an Upcast function that
+//     rewrites its JSON into the next version, so consumers only ever see the
+//     current shape while old messages remain readable from the topic.
+//   - Consumers must be deployed before producers when a version is bumped.
+//
+// Messages written before the envelope existed (a bare models.AuditLog with no
+// schemaVersion) are decoded as legacy records by DecodeLegacy.
+package events
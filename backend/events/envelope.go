@@ -0,0 +1,68 @@
+package events
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+)
+
+const (
+	ActorUser   = "user"
+	ActorSystem = "system"
+)
+
+type Actor struct {
+	Type string `json:"type"`
+	ID   string `json:"id,omitempty"`
+}
+
+type Envelope struct {
+	EventID       string          `json:"eventId"`
+	Type          string          `json:"type"`
+	SchemaVersion int             `json:"schemaVersion"`
+	OccurredAt    time.Time       `json:"occurredAt"`
+	Actor         Actor           `json:"actor"`
+	CorrelationID string          `json:"correlationId,omitempty"`
+	Payload       json.RawMessage `json:"payload"`
+}
+
+type Subject struct {
+	SessionID string
+	UserID    string
+	SeatIDs   []string
+}
+
+type Payload interface {
+	EventType() string
+	Subject() Subject
+	Describe() string
+	Validate() error
+}
+
+type correlationKey struct{}
+
+func WithCorrelationID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, correlationKey{}, id)
+}
+
+func CorrelationID(ctx context.Context) string {
+	if id, ok := ctx.Value(correlationKey{}).(string); ok {
+		return id
+	}
+	return ""
+}
+
+func NewID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func actorFor(subject Subject) Actor {
+	if subject.UserID == "" || subject.UserID == ActorSystem {
+		return Actor{Type: ActorSystem}
+	}
+	return Actor{Type: ActorUser, ID: subject.UserID}
+}
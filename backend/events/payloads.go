@@ -0,0 +1,485 @@
+package events
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	TypeSeatsLocked      = "SEAT_LOCKED"
+	TypeSeatsUnlocked    = "SEAT_UNLOCKED"
+	TypeBookingConfirmed = "BOOKING_SUCCESS"
+	TypeBookingTimeout   = "BOOKING_TIMEOUT"
+	TypeBookingCancelled = "BOOKING_CANCELLED"
+	TypeLockExpired      = "LOCK_EXPIRED"
+	TypeSystemError      = "SYSTEM_ERROR"
+	TypeReminderSent     = "REMINDER_SENT"
+	TypeTicketAdmitted   = "TICKET_ADMITTED"
+	TypeSeatRepaired     = "SEAT_STATE_REPAIRED"
+	TypeSessionStatus    = "SESSION_STATUS_CHANGED"
+	TypeSessionCancelled = "SESSION_CANCELLED"
+	TypeBookingExchanged = "BOOKING_EXCHANGED"
+	TypeWaitlistJoined   = "WAITLIST_JOINED"
+	TypeWaitlistOffered  = "WAITLIST_OFFERED"
+)
+
+var (
+	errMissingSession = errors.New("sessionId is required")
+	errMissingUser    = errors.New("userId is required")
+	errMissingSeats   = errors.New("seatIds must not be empty")
+	errMissingBooking = errors.New("bookingId is required")
+)
+
+type SeatsLocked struct {
+	SessionID string    `json:"sessionId"`
+	UserID    string    `json:"userId"`
+	SeatIDs   []string  `json:"seatIds"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+func (p SeatsLocked) EventType() string { return TypeSeatsLocked }
+
+func (p SeatsLocked) Subject() Subject {
+	return Subject{SessionID: p.SessionID, UserID: p.UserID, SeatIDs: p.SeatIDs}
+}
+
+func (p SeatsLocked) Describe() string {
+	return fmt.Sprintf("User %s locked seats: %v", p.UserID, p.SeatIDs)
+}
+
+func (p SeatsLocked) Validate() error {
+	switch {
+	case p.SessionID == "":
+		return errMissingSession
+	case p.UserID == "":
+		return errMissingUser
+	case len(p.SeatIDs) == 0:
+		return errMissingSeats
+	}
+	return nil
+}
+
+type SeatsUnlocked struct {
+	SessionID string   `json:"sessionId"`
+	UserID    string   `json:"userId"`
+	SeatIDs   []string `json:"seatIds"`
+	Reason    string   `json:"reason"`
+}
+
+func (p SeatsUnlocked) EventType() string { return TypeSeatsUnlocked }
+
+func (p SeatsUnlocked) Subject() Subject {
+	return Subject{SessionID: p.SessionID, UserID: p.UserID, SeatIDs: p.SeatIDs}
+}
+
+func (p SeatsUnlocked) Describe() string {
+	return fmt.Sprintf("Seats unlocked (%s): %v", p.Reason, p.SeatIDs)
+}
+
+func (p SeatsUnlocked) Validate() error {
+	switch {
+	case p.SessionID == "":
+		return errMissingSession
+	case len(p.SeatIDs) == 0:
+		return errMissingSeats
+	}
+	return nil
+}
+
+type BookingConfirmed struct {
+	BookingID   string   `json:"bookingId"`
+	SessionID   string   `json:"sessionId"`
+	UserID      string   `json:"userId"`
+	SeatIDs     []string `json:"seatIds"`
+	TotalAmount float64  `json:"totalAmount"`
+}
+
+func (p BookingConfirmed) EventType() string { return TypeBookingConfirmed }
+
+func (p BookingConfirmed) Subject() Subject {
+	return Subject{SessionID: p.SessionID, UserID: p.UserID, SeatIDs: p.SeatIDs}
+}
+
+func (p BookingConfirmed) Describe() string {
+	return fmt.Sprintf("Booking %s confirmed for user %s, seats: %v", p.BookingID, p.UserID, p.SeatIDs)
+}
+
+func (p BookingConfirmed) Validate() error {
+	switch {
+	case p.BookingID == "":
+		return errMissingBooking
+	case p.SessionID == "":
+		return errMissingSession
+	case p.UserID == "":
+		return errMissingUser
+	case len(p.SeatIDs) == 0:
+		return errMissingSeats
+	case p.TotalAmount < 0:
+		return errors.New("totalAmount must not be negative")
+	}
+	return nil
+}
+
+type BookingTimeout struct {
+	SessionID string   `json:"sessionId"`
+	UserID    string   `json:"userId"`
+	SeatIDs   []string `json:"seatIds"`
+}
+
+func (p BookingTimeout) EventType() string { return TypeBookingTimeout }
+
+func (p BookingTimeout) Subject() Subject {
+	return Subject{SessionID: p.SessionID, UserID: p.UserID, SeatIDs: p.SeatIDs}
+}
+
+func (p BookingTimeout) Describe() string {
+	return fmt.Sprintf("Booking timed out for user %s, seats released: %v", p.UserID, p.SeatIDs)
+}
+
+func (p BookingTimeout) Validate() error {
+	switch {
+	case p.SessionID == "":
+		return errMissingSession
+	case len(p.SeatIDs) == 0:
+		return errMissingSeats
+	}
+	return nil
+}
+
+type BookingCancelled struct {
+	BookingID       string   `json:"bookingId,omitempty"`
+	SessionID       string   `json:"sessionId"`
+	UserID          string   `json:"userId"`
+	SeatIDs         []string `json:"seatIds"`
+	Reason          string   `json:"reason"`
+	RefundAmount    float64  `json:"refundAmount,omitempty"`
+	RefundReference string   `json:"refundReference,omitempty"`
+}
+
+func (p BookingCancelled) EventType() string { return TypeBookingCancelled }
+
+func (p BookingCancelled) Subject() Subject {
+	return Subject{SessionID: p.SessionID, UserID: p.UserID, SeatIDs: p.SeatIDs}
+}
+
+func (p BookingCancelled) Describe() string {
+	return fmt.Sprintf("Booking cancelled for user %s (%s), seats: %v", p.UserID, p.Reason, p.SeatIDs)
+}
+
+func (p BookingCancelled) Validate() error {
+	switch {
+	case p.SessionID == "":
+		return errMissingSession
+	case p.UserID == "":
+		return errMissingUser
+	case len(p.SeatIDs) == 0:
+		return errMissingSeats
+	}
+	return nil
+}
+
+type LockExpired struct {
+	SessionID      string   `json:"sessionId"`
+	SeatIDs        []string `json:"seatIds"`
+	LockTTLSeconds int      `json:"lockTtlSeconds"`
+}
+
+func (p LockExpired) EventType() string { return TypeLockExpired }
+
+func (p LockExpired) Subject() Subject {
+	return Subject{SessionID: p.SessionID, SeatIDs: p.SeatIDs}
+}
+
+func (p LockExpired) Describe() string {
+	return fmt.Sprintf("Seat lock expired (%s timeout)", time.Duration(p.LockTTLSeconds)*time.Second)
+}
+
+func (p LockExpired) Validate() error {
+	switch {
+	case p.SessionID == "":
+		return errMissingSession
+	case len(p.SeatIDs) == 0:
+		return errMissingSeats
+	}
+	return nil
+}
+
+type SystemError struct {
+	ErrorType string                 `json:"errorType"`
+	Message   string                 `json:"message"`
+	SessionID string                 `json:"sessionId,omitempty"`
+	Details   map[string]interface{} `json:"details,omitempty"`
+}
+
+func (p SystemError) EventType() string { return TypeSystemError }
+
+func (p SystemError) Subject() Subject {
+	return Subject{SessionID: p.SessionID, UserID: ActorSystem}
+}
+
+func (p SystemError) Describe() string {
+	return p.ErrorType + ": " + p.Message
+}
+
+func (p SystemError) Validate() error {
+	if p.ErrorType == "" {
+		return errors.New("errorType is required")
+	}
+	return nil
+}
+
+type ReminderSent struct {
+	BookingID string   `json:"bookingId"`
+	SessionID string   `json:"sessionId"`
+	UserID    string   `json:"userId"`
+	SeatIDs   []string `json:"seatIds"`
+	Offset    string   `json:"offset"`
+	EmailID   string   `json:"emailId"`
+}
+
+func (p ReminderSent) EventType() string { return TypeReminderSent }
+
+func (p ReminderSent) Subject() Subject {
+	return Subject{SessionID: p.SessionID, UserID: p.UserID, SeatIDs: p.SeatIDs}
+}
+
+func (p ReminderSent) Describe() string {
+	return fmt.Sprintf("Reminder (%s before showtime) sent for booking %s", p.Offset, p.BookingID)
+}
+
+func (p ReminderSent) Validate() error {
+	switch {
+	case p.BookingID == "":
+		return errMissingBooking
+	case p.SessionID == "":
+		return errMissingSession
+	case p.Offset == "":
+		return errors.New("offset is required")
+	}
+	return nil
+}
+
+type TicketAdmitted struct {
+	BookingID  string `json:"bookingId"`
+	SessionID  string `json:"sessionId"`
+	UserID     string `json:"userId"`
+	SeatID     string `json:"seatId"`
+	AdmittedBy string `json:"admittedBy"`
+}
+
+func (p TicketAdmitted) EventType() string { return TypeTicketAdmitted }
+
+func (p TicketAdmitted) Subject() Subject {
+	return Subject{SessionID: p.SessionID, UserID: p.UserID, SeatIDs: []string{p.SeatID}}
+}
+
+func (p TicketAdmitted) Describe() string {
+	return fmt.Sprintf("Seat %s of booking %s admitted by %s", p.SeatID, p.BookingID, p.AdmittedBy)
+}
+
+func (p TicketAdmitted) Validate() error {
+	switch {
+	case p.BookingID == "":
+		return errMissingBooking
+	case p.SessionID == "":
+		return errMissingSession
+	case p.SeatID == "":
+		return errMissingSeats
+	}
+	return nil
+}
+
+// SeatRepaired records a fix applied by the reconciler. ApprovedBy is empty
+// for automatic repairs.
+type SeatRepaired struct {
+	ReportID      string   `json:"reportId"`
+	DiscrepancyID string   `json:"discrepancyId"`
+	Kind          string   `json:"kind"`
+	SessionID     string   `json:"sessionId"`
+	SeatID        string   `json:"seatId"`
+	BookingIDs    []string `json:"bookingIds,omitempty"`
+	Action        string   `json:"action"`
+	ApprovedBy    string   `json:"approvedBy,omitempty"`
+}
+
+func (p SeatRepaired) EventType() string { return TypeSeatRepaired }
+
+func (p SeatRepaired) Subject() Subject {
+	return Subject{SessionID: p.SessionID, UserID: p.ApprovedBy, SeatIDs: []string{p.SeatID}}
+}
+
+func (p SeatRepaired) Describe() string {
+	if p.ApprovedBy == "" {
+		return fmt.Sprintf("Reconciler repaired %s on seat %s: %s", p.Kind, p.SeatID, p.Action)
+	}
+	return fmt.Sprintf("Reconciler repaired %s on seat %s (approved by %s): %s", p.Kind, p.SeatID, p.ApprovedBy, p.Action)
+}
+
+func (p SeatRepaired) Validate() error {
+	switch {
+	case p.SessionID == "":
+		return errMissingSession
+	case p.SeatID == "":
+		return errMissingSeats
+	case p.Kind == "" || p.Action == "":
+		return errors.New("kind and action are required")
+	}
+	return nil
+}
+
+type SessionStatusChanged struct {
+	SessionID string `json:"sessionId"`
+	From      string `json:"from,omitempty"`
+	To        string `json:"to"`
+}
+
+func (p SessionStatusChanged) EventType() string { return TypeSessionStatus }
+
+func (p SessionStatusChanged) Subject() Subject {
+	return Subject{SessionID: p.SessionID}
+}
+
+func (p SessionStatusChanged) Describe() string {
+	if p.From == "" {
+		return fmt.Sprintf("Session is %s", p.To)
+	}
+	return fmt.Sprintf("Session moved from %s to %s", p.From, p.To)
+}
+
+func (p SessionStatusChanged) Validate() error {
+	switch {
+	case p.SessionID == "":
+		return errMissingSession
+	case p.To == "":
+		return errors.New("to is required")
+	}
+	return nil
+}
+
+type SessionCancelled struct {
+	SessionID      string `json:"sessionId"`
+	CancellationID string `json:"cancellationId"`
+	Reason         string `json:"reason"`
+	RequestedBy    string `json:"requestedBy"`
+	PreviousStatus string `json:"previousStatus,omitempty"`
+}
+
+func (p SessionCancelled) EventType() string { return TypeSessionCancelled }
+
+func (p SessionCancelled) Subject() Subject {
+	return Subject{SessionID: p.SessionID, UserID: p.RequestedBy}
+}
+
+func (p SessionCancelled) Describe() string {
+	return fmt.Sprintf("Session cancelled by %s: %s", p.RequestedBy, p.Reason)
+}
+
+func (p SessionCancelled) Validate() error {
+	switch {
+	case p.SessionID == "":
+		return errMissingSession
+	case p.CancellationID == "" || p.Reason == "":
+		return errors.New("cancellationId and reason are required")
+	}
+	return nil
+}
+
+type BookingExchanged struct {
+	BookingID        string   `json:"bookingId"`
+	UserID           string   `json:"userId"`
+	Revision         int      `json:"revision"`
+	FromSessionID    string   `json:"fromSessionId"`
+	FromSeatIDs      []string `json:"fromSeatIds"`
+	SessionID        string   `json:"sessionId"`
+	SeatIDs          []string `json:"seatIds"`
+	TotalAmount      float64  `json:"totalAmount"`
+	PriceDifference  float64  `json:"priceDifference"`
+	PaymentReference string   `json:"paymentReference,omitempty"`
+}
+
+func (p BookingExchanged) EventType() string { return TypeBookingExchanged }
+
+func (p BookingExchanged) Subject() Subject {
+	return Subject{SessionID: p.SessionID, UserID: p.UserID, SeatIDs: p.SeatIDs}
+}
+
+func (p BookingExchanged) Describe() string {
+	if p.FromSessionID != p.SessionID {
+		return fmt.Sprintf("Booking %s moved from session %s seats %v to seats %v", p.BookingID, p.FromSessionID, p.FromSeatIDs, p.SeatIDs)
+	}
+	return fmt.Sprintf("Booking %s moved from seats %v to seats %v", p.BookingID, p.FromSeatIDs, p.SeatIDs)
+}
+
+func (p BookingExchanged) Validate() error {
+	switch {
+	case p.BookingID == "":
+		return errMissingBooking
+	case p.SessionID == "" || p.FromSessionID == "":
+		return errMissingSession
+	case p.UserID == "":
+		return errMissingUser
+	case len(p.SeatIDs) == 0:
+		return errMissingSeats
+	}
+	return nil
+}
+
+type WaitlistJoined struct {
+	SessionID string `json:"sessionId"`
+	UserID    string `json:"userId"`
+	EntryID   string `json:"entryId"`
+	PartySize int    `json:"partySize"`
+}
+
+func (p WaitlistJoined) EventType() string { return TypeWaitlistJoined }
+
+func (p WaitlistJoined) Subject() Subject {
+	return Subject{SessionID: p.SessionID, UserID: p.UserID}
+}
+
+func (p WaitlistJoined) Describe() string {
+	return fmt.Sprintf("User %s joined the waitlist for %d seat(s)", p.UserID, p.PartySize)
+}
+
+func (p WaitlistJoined) Validate() error {
+	switch {
+	case p.SessionID == "":
+		return errMissingSession
+	case p.UserID == "":
+		return errMissingUser
+	case p.PartySize <= 0:
+		return errors.New("partySize must be positive")
+	}
+	return nil
+}
+
+type WaitlistOffered struct {
+	SessionID string    `json:"sessionId"`
+	UserID    string    `json:"userId"`
+	EntryID   string    `json:"entryId"`
+	SeatIDs   []string  `json:"seatIds"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+func (p WaitlistOffered) EventType() string { return TypeWaitlistOffered }
+
+func (p WaitlistOffered) Subject() Subject {
+	return Subject{SessionID: p.SessionID, UserID: p.UserID, SeatIDs: p.SeatIDs}
+}
+
+func (p WaitlistOffered) Describe() string {
+	return fmt.Sprintf("Seats %v held for waitlisted user %s until %s", p.SeatIDs, p.UserID, p.ExpiresAt.Format(time.RFC3339))
+}
+
+func (p WaitlistOffered) Validate() error {
+	switch {
+	case p.SessionID == "":
+		return errMissingSession
+	case p.UserID == "":
+		return errMissingUser
+	case len(p.SeatIDs) == 0:
+		return errMissingSeats
+	}
+	return nil
+}
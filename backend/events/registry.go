@@ -0,0 +1,195 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+var (
+	ErrUnknownType    = errors.New("unknown event type")
+	ErrUnknownVersion = errors.New("unsupported schema version")
+	ErrLegacyMessage  = errors.New("message has no schema version")
+)
+
+type Schema struct {
+	Type    string
+	Version int
+	New     func() Payload
+	// Upcast rewrites a payload of this version into the next version. It is
+	// required for every version except the current one.
+	Upcast func(json.RawMessage) (json.RawMessage, error)
+}
+
+type Registry struct {
+	mu      sync.RWMutex
+	schemas map[string]map[int]Schema
+	current map[string]int
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		schemas: make(map[string]map[int]Schema),
+		current: make(map[string]int),
+	}
+}
+
+var Default = newDefaultRegistry()
+
+func newDefaultRegistry() *Registry {
+	r := NewRegistry()
+	r.MustRegister(Schema{Type: TypeSeatsLocked, Version: 1, New: func() Payload { return &SeatsLocked{} }})
+	r.MustRegister(Schema{Type: TypeSeatsUnlocked, Version: 1, New: func() Payload { return &SeatsUnlocked{} }})
+	r.MustRegister(Schema{Type: TypeBookingConfirmed, Version: 1, New: func() Payload { return &BookingConfirmed{} }})
+	r.MustRegister(Schema{Type: TypeBookingTimeout, Version: 1, New: func() Payload { return &BookingTimeout{} }})
+	r.MustRegister(Schema{Type: TypeBookingCancelled, Version: 1, New: func() Payload { return &BookingCancelled{} }})
+	r.MustRegister(Schema{Type: TypeLockExpired, Version: 1, New: func() Payload { return &LockExpired{} }})
+	r.MustRegister(Schema{Type: TypeSystemError, Version: 1, New: func() Payload { return &SystemError{} }})
+	r.MustRegister(Schema{Type: TypeReminderSent, Version: 1, New: func() Payload { return &ReminderSent{} }})
+	r.MustRegister(Schema{Type: TypeTicketAdmitted, Version: 1, New: func() Payload { return &TicketAdmitted{} }})
+	r.MustRegister(Schema{Type: TypeSeatRepaired, Version: 1, New: func() Payload { return &SeatRepaired{} }})
+	r.MustRegister(Schema{Type: TypeSessionStatus, Version: 1, New: func() Payload { return &SessionStatusChanged{} }})
+	r.MustRegister(Schema{Type: TypeSessionCancelled, Version: 1, New: func() Payload { return &SessionCancelled{} }})
+	r.MustRegister(Schema{Type: TypeBookingExchanged, Version: 1, New: func() Payload { return &BookingExchanged{} }})
+	r.MustRegister(Schema{Type: TypeWaitlistJoined, Version: 1, New: func() Payload { return &WaitlistJoined{} }})
+	r.MustRegister(Schema{Type: TypeWaitlistOffered, Version: 1, New: func() Payload { return &WaitlistOffered{} }})
+	return r
+}
+
+func (r *Registry) Register(s Schema) error {
+	if s.Type == "" || s.Version < 1 || s.New == nil {
+		return fmt.Errorf("invalid schema registration for %q v%d", s.Type, s.Version)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	versions, ok := r.schemas[s.Type]
+	if !ok {
+		versions = make(map[int]Schema)
+		r.schemas[s.Type] = versions
+	}
+	if _, exists := versions[s.Version]; exists {
+		return fmt.Errorf("schema %q v%d already registered", s.Type, s.Version)
+	}
+	versions[s.Version] = s
+
+	if s.Version > r.current[s.Type] {
+		r.current[s.Type] = s.Version
+	}
+	return nil
+}
+
+func (r *Registry) MustRegister(s Schema) {
+	if err := r.Register(s); err != nil {
+		panic(err)
+	}
+}
+
+func (r *Registry) CurrentVersion(eventType string) (int, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	v, ok := r.current[eventType]
+	return v, ok
+}
+
+func (r *Registry) Types() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	types := make([]string, 0, len(r.current))
+	for t := range r.current {
+		types = append(types, t)
+	}
+	return types
+}
+
+// NewEnvelope validates p against the registry and wraps it at the current
+// schema version. Producers must go through here rather than building
+// envelopes by hand.
+func (r *Registry) NewEnvelope(ctx context.Context, p Payload) (Envelope, error) {
+	version, ok := r.CurrentVersion(p.EventType())
+	if !ok {
+		return Envelope{}, fmt.Errorf("%w: %s", ErrUnknownType, p.EventType())
+	}
+
+	if err := p.Validate(); err != nil {
+		return Envelope{}, fmt.Errorf("invalid %s payload: %w", p.EventType(), err)
+	}
+
+	data, err := json.Marshal(p)
+	if err != nil {
+		return Envelope{}, fmt.Errorf("failed to marshal %s payload: %w", p.EventType(), err)
+	}
+
+	return Envelope{
+		EventID:       NewID(),
+		Type:          p.EventType(),
+		SchemaVersion: version,
+		OccurredAt:    time.Now().UTC(),
+		Actor:         actorFor(p.Subject()),
+		CorrelationID: CorrelationID(ctx),
+		Payload:       data,
+	}, nil
+}
+
+// Decode parses a message, upcasts its payload to the current version and
+// validates it. The returned envelope carries the upcast payload and version.
+func (r *Registry) Decode(data []byte) (Envelope, Payload, error) {
+	var env Envelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		return Envelope{}, nil, fmt.Errorf("failed to unmarshal envelope: %w", err)
+	}
+	if env.SchemaVersion == 0 {
+		return Envelope{}, nil, ErrLegacyMessage
+	}
+
+	payload, err := r.DecodePayload(&env)
+	if err != nil {
+		return Envelope{}, nil, err
+	}
+	return env, payload, nil
+}
+
+func (r *Registry) DecodePayload(env *Envelope) (Payload, error) {
+	r.mu.RLock()
+	versions, ok := r.schemas[env.Type]
+	current := r.current[env.Type]
+	r.mu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownType, env.Type)
+	}
+	if env.SchemaVersion < 1 || env.SchemaVersion > current {
+		return nil, fmt.Errorf("%w: %s v%d", ErrUnknownVersion, env.Type, env.SchemaVersion)
+	}
+
+	raw := env.Payload
+	for v := env.SchemaVersion; v < current; v++ {
+		schema, ok := versions[v]
+		if !ok || schema.Upcast == nil {
+			return nil, fmt.Errorf("%w: %s v%d has no upcast", ErrUnknownVersion, env.Type, v)
+		}
+		upcast, err := schema.Upcast(raw)
+		if err != nil {
+			return nil, fmt.Errorf("failed to upcast %s v%d: %w", env.Type, v, err)
+		}
+		raw = upcast
+	}
+
+	payload := versions[current].New()
+	if err := json.Unmarshal(raw, payload); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal %s payload: %w", env.Type, err)
+	}
+	if err := payload.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid %s payload: %w", env.Type, err)
+	}
+
+	env.Payload = raw
+	env.SchemaVersion = current
+	return payload, nil
+}
@@ -0,0 +1,216 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+const testTypeSeatHeld = "TEST_SEAT_HELD"
+
+// seatHeld is a test payload at version 2. Version 1 named a single seat,
+// which version 2 replaced with a list.
+type seatHeld struct {
+	SessionID string   `json:"sessionId"`
+	UserID    string   `json:"userId"`
+	SeatIDs   []string `json:"seatIds"`
+}
+
+func (p seatHeld) EventType() string { return testTypeSeatHeld }
+
+func (p seatHeld) Subject() Subject {
+	return Subject{SessionID: p.SessionID, UserID: p.UserID, SeatIDs: p.SeatIDs}
+}
+
+func (p seatHeld) Describe() string { return "seat held" }
+
+func (p seatHeld) Validate() error {
+	switch {
+	case p.SessionID == "":
+		return errMissingSession
+	case len(p.SeatIDs) == 0:
+		return errMissingSeats
+	}
+	return nil
+}
+
+// upcastSeatHeldV1 turns {"seat": "A1"} into {"seatIds": ["A1"]}.
+func upcastSeatHeldV1(raw json.RawMessage) (json.RawMessage, error) {
+	var v1 map[string]interface{}
+	if err := json.Unmarshal(raw, &v1); err != nil {
+		return nil, err
+	}
+	if seat, ok := v1["seat"].(string); ok {
+		v1["seatIds"] = []string{seat}
+	}
+	delete(v1, "seat")
+	return json.Marshal(v1)
+}
+
+func newTestRegistry(t *testing.T, upcast bool) *Registry {
+	t.Helper()
+	r := NewRegistry()
+	v1 := Schema{Type: testTypeSeatHeld, Version: 1, New: func() Payload { return &seatHeld{} }}
+	if upcast {
+		v1.Upcast = upcastSeatHeldV1
+	}
+	r.MustRegister(v1)
+	r.MustRegister(Schema{Type: testTypeSeatHeld, Version: 2, New: func() Payload { return &seatHeld{} }})
+	return r
+}
+
+func message(t *testing.T, env Envelope) []byte {
+	t.Helper()
+	data, err := json.Marshal(env)
+	if err != nil {
+		t.Fatalf("marshal envelope: %v", err)
+	}
+	return data
+}
+
+func TestNewEnvelope(t *testing.T) {
+	ctx := WithCorrelationID(context.Background(), "corr-1")
+	env, err := Default.NewEnvelope(ctx, SeatsLocked{SessionID: "s1", UserID: "u1", SeatIDs: []string{"A1"}})
+	if err != nil {
+		t.Fatalf("NewEnvelope: %v", err)
+	}
+	if env.EventID == "" || env.Type != TypeSeatsLocked || env.SchemaVersion != 1 || env.CorrelationID != "corr-1" {
+		t.Errorf("envelope = %+v", env)
+	}
+	if env.Actor != (Actor{Type: ActorUser, ID: "u1"}) {
+		t.Errorf("actor = %+v, want the user", env.Actor)
+	}
+}
+
+func TestNewEnvelopeRejectsInvalidPayloads(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload Payload
+		wantErr error
+	}{
+		{name: "missing session", payload: SeatsLocked{UserID: "u1", SeatIDs: []string{"A1"}}, wantErr: errMissingSession},
+		{name: "missing seats", payload: SeatsLocked{SessionID: "s1", UserID: "u1"}, wantErr: errMissingSeats},
+		{name: "unregistered type", payload: seatHeld{SessionID: "s1", SeatIDs: []string{"A1"}}, wantErr: ErrUnknownType},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env, err := Default.NewEnvelope(context.Background(), tt.payload)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("err = %v, want %v", err, tt.wantErr)
+			}
+			if env.EventID != "" {
+				t.Errorf("got an envelope for an invalid payload: %+v", env)
+			}
+		})
+	}
+}
+
+func TestDecodeUpcastsOldVersions(t *testing.T) {
+	r := newTestRegistry(t, true)
+	data := message(t, Envelope{
+		EventID:       "evt-1",
+		Type:          testTypeSeatHeld,
+		SchemaVersion: 1,
+		OccurredAt:    time.Now().UTC(),
+		Payload:       json.RawMessage(`{"sessionId":"s1","userId":"u1","seat":"A1"}`),
+	})
+
+	env, payload, err := r.Decode(data)
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	held, ok := payload.(*seatHeld)
+	if !ok {
+		t.Fatalf("payload is %T, want *seatHeld", payload)
+	}
+	if !reflect.DeepEqual(held.SeatIDs, []string{"A1"}) || held.SessionID != "s1" {
+		t.Errorf("payload = %+v, want seat A1 upcast into seatIds", held)
+	}
+	if env.SchemaVersion != 2 {
+		t.Errorf("schema version = %d, want 2 after upcasting", env.SchemaVersion)
+	}
+	var stored map[string]interface{}
+	if err := json.Unmarshal(env.Payload, &stored); err != nil {
+		t.Fatalf("decode upcast payload: %v", err)
+	}
+	if _, old := stored["seat"]; old {
+		t.Errorf("envelope still carries the v1 payload: %s", env.Payload)
+	}
+}
+
+func TestDecodeRejects(t *testing.T) {
+	v2 := json.RawMessage(`{"sessionId":"s1","userId":"u1","seatIds":["A1"]}`)
+	tests := []struct {
+		name    string
+		upcast  bool
+		env     Envelope
+		wantErr error
+	}{
+		{
+			name:    "version newer than known",
+			upcast:  true,
+			env:     Envelope{Type: testTypeSeatHeld, SchemaVersion: 3, Payload: v2},
+			wantErr: ErrUnknownVersion,
+		},
+		{
+			name:    "negative version",
+			upcast:  true,
+			env:     Envelope{Type: testTypeSeatHeld, SchemaVersion: -1, Payload: v2},
+			wantErr: ErrUnknownVersion,
+		},
+		{
+			name:    "old version without an upcast",
+			upcast:  false,
+			env:     Envelope{Type: testTypeSeatHeld, SchemaVersion: 1, Payload: json.RawMessage(`{"sessionId":"s1","seat":"A1"}`)},
+			wantErr: ErrUnknownVersion,
+		},
+		{
+			name:    "unknown type",
+			upcast:  true,
+			env:     Envelope{Type: "NOT_A_TYPE", SchemaVersion: 1, Payload: v2},
+			wantErr: ErrUnknownType,
+		},
+		{
+			name:    "payload failing validation",
+			upcast:  true,
+			env:     Envelope{Type: testTypeSeatHeld, SchemaVersion: 2, Payload: json.RawMessage(`{"sessionId":"s1"}`)},
+			wantErr: errMissingSeats,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, payload, err := newTestRegistry(t, tt.upcast).Decode(message(t, tt.env))
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("err = %v, want %v", err, tt.wantErr)
+			}
+			if payload != nil {
+				t.Errorf("got payload %+v with an error", payload)
+			}
+		})
+	}
+}
+
+func TestDecodeLegacyMessages(t *testing.T) {
+	// Written before the envelope existed: a bare audit log.
+	data := []byte(`{"eventType":"SEAT_LOCKED","sessionId":"s1","userId":"u1","seatIds":["A1"]}`)
+
+	if _, _, err := Default.Decode(data); !errors.Is(err, ErrLegacyMessage) {
+		t.Fatalf("Decode err = %v, want ErrLegacyMessage", err)
+	}
+
+	auditLog, err := DecodeLegacy(data)
+	if err != nil {
+		t.Fatalf("DecodeLegacy: %v", err)
+	}
+	if auditLog.EventType != TypeSeatsLocked || auditLog.SessionID != "s1" || auditLog.UserID != "u1" {
+		t.Errorf("legacy audit log = %+v", auditLog)
+	}
+	if auditLog.Timestamp.IsZero() {
+		t.Error("legacy audit log without a timestamp was not given one")
+	}
+}
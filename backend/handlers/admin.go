@@ -2,11 +2,15 @@ package handlers
 
 import (
 	"context"
+	"errors"
+	"log"
 	"net/http"
+	"strings"
 	"time"
 
 	"cinema-booking-system/config"
 	"cinema-booking-system/models"
+	"cinema-booking-system/services"
 
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson"
@@ -14,10 +18,25 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-type AdminHandler struct{}
+type AdminHandler struct {
+	auditStore  *services.MongoAuditLogStore
+	archiver    *services.AuditArchiver
+	emailOutbox *services.EmailOutboxService
+	reconciler  *services.Reconciler
 
-func NewAdminHandler() *AdminHandler {
-	return &AdminHandler{}
+	sessionCancellations *services.SessionCancellationService
+	waitlist             *services.WaitlistService
+}
+
+func NewAdminHandler(archiver *services.AuditArchiver, emailOutbox *services.EmailOutboxService, reconciler *services.Reconciler, sessionCancellations *services.SessionCancellationService, waitlist *services.WaitlistService) *AdminHandler {
+	return &AdminHandler{
+		auditStore:           services.NewDefaultAuditLogStore(),
+		archiver:             archiver,
+		emailOutbox:          emailOutbox,
+		reconciler:           reconciler,
+		sessionCancellations: sessionCancellations,
+		waitlist:             waitlist,
+	}
 }
 
 func (h *AdminHandler) GetBookings(c *gin.Context) {
@@ -161,33 +180,51 @@ func (h *AdminHandler) GetAuditLogs(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	filter := bson.M{}
+	filter, err := auditLogFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
 
-	if eventType := c.Query("eventType"); eventType != "" {
-		filter["eventType"] = eventType
+	limit := 50
+	if l := c.Query("limit"); l != "" {
+		if parsed, err := parseInt(l); err == nil && parsed > 0 && parsed <= 200 {
+			limit = parsed
+		}
 	}
 
-	if sessionID := c.Query("sessionId"); sessionID != "" {
-		filter["sessionId"] = sessionID
+	collection := config.MongoDB.Collection("audit_logs")
+
+	total, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Error:   "Failed to count audit logs",
+		})
+		return
 	}
 
-	page := 1
-	limit := 50
-	if p := c.Query("page"); p != "" {
-		if parsed, err := parseInt(p); err == nil && parsed > 0 {
-			page = parsed
+	pageFilter := filter
+	if cursorParam := c.Query("cursor"); cursorParam != "" {
+		after, err := decodeAuditCursor(cursorParam)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Error:   "Invalid cursor",
+			})
+			return
 		}
+		pageFilter = bson.M{"$and": []bson.M{filter, after.filter()}}
 	}
-	skip := (page - 1) * limit
 
 	opts := options.Find().
-		SetSort(bson.D{{Key: "timestamp", Value: -1}}).
-		SetSkip(int64(skip)).
-		SetLimit(int64(limit))
+		SetSort(bson.D{{Key: "timestamp", Value: -1}, {Key: "_id", Value: -1}}).
+		SetLimit(int64(limit + 1))
 
-	collection := config.MongoDB.Collection("audit_logs")
-
-	cursor, err := collection.Find(ctx, filter, opts)
+	cursor, err := collection.Find(ctx, pageFilter, opts)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.APIResponse{
 			Success: false,
@@ -197,7 +234,7 @@ func (h *AdminHandler) GetAuditLogs(c *gin.Context) {
 	}
 	defer cursor.Close(ctx)
 
-	var logs []models.AuditLog
+	logs := []models.AuditLog{}
 	if err := cursor.All(ctx, &logs); err != nil {
 		c.JSON(http.StatusInternalServerError, models.APIResponse{
 			Success: false,
@@ -206,12 +243,221 @@ func (h *AdminHandler) GetAuditLogs(c *gin.Context) {
 		return
 	}
 
+	var nextCursor string
+	if len(logs) > limit {
+		logs = logs[:limit]
+		last := logs[len(logs)-1]
+		nextCursor = auditCursor{Timestamp: last.Timestamp, ID: last.ID}.encode()
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data: gin.H{
+			"logs":       logs,
+			"total":      total,
+			"limit":      limit,
+			"nextCursor": nextCursor,
+		},
+	})
+}
+
+func (h *AdminHandler) VerifyAuditChain(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	opts := services.ChainVerifyOptions{
+		ChainID: c.Query("sessionId"),
+	}
+
+	var err error
+	if opts.From, err = parseTimeParam(c.Query("from")); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "Invalid from: " + err.Error(),
+		})
+		return
+	}
+	if opts.To, err = parseTimeParam(c.Query("to")); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "Invalid to: " + err.Error(),
+		})
+		return
+	}
+
+	report, err := h.auditStore.VerifyChains(ctx, opts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Error:   "Failed to verify audit chain",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    report,
+	})
+}
+
+func (h *AdminHandler) GetRetentionStatus(c *gin.Context) {
+	if h.archiver == nil {
+		c.JSON(http.StatusServiceUnavailable, models.APIResponse{
+			Success: false,
+			Error:   "Audit archiver is not configured",
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	statuses, err := h.archiver.Status(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Error:   "Failed to compute retention status",
+		})
+		return
+	}
+
+	archives, err := h.archiver.RecentArchives(ctx, 20)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Error:   "Failed to fetch archives",
+		})
+		return
+	}
+
+	lastRun, running := h.archiver.LastRun()
+
 	c.JSON(http.StatusOK, models.APIResponse{
 		Success: true,
-		Data:    logs,
+		Data: gin.H{
+			"policies":       statuses,
+			"running":        running,
+			"lastRun":        lastRun,
+			"recentArchives": archives,
+		},
 	})
 }
 
+func (h *AdminHandler) TriggerArchive(c *gin.Context) {
+	if h.archiver == nil {
+		c.JSON(http.StatusServiceUnavailable, models.APIResponse{
+			Success: false,
+			Error:   "Audit archiver is not configured",
+		})
+		return
+	}
+
+	if _, running := h.archiver.LastRun(); running {
+		c.JSON(http.StatusConflict, models.APIResponse{
+			Success: false,
+			Error:   services.ErrArchiveRunning.Error(),
+		})
+		return
+	}
+
+	go func() {
+		if _, err := h.archiver.Run(context.Background()); err != nil {
+			log.Printf("⚠️ Manual audit archive run failed: %v", err)
+		}
+	}()
+
+	c.JSON(http.StatusAccepted, models.APIResponse{
+		Success: true,
+		Message: "Archive run started",
+	})
+}
+
+func (h *AdminHandler) GetEmails(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var statuses []models.EmailStatus
+	for _, v := range strings.Split(c.Query("status"), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			statuses = append(statuses, models.EmailStatus(strings.ToUpper(v)))
+		}
+	}
+
+	page := 1
+	limit := 20
+	if p := c.Query("page"); p != "" {
+		if parsed, err := parseInt(p); err == nil && parsed > 0 {
+			page = parsed
+		}
+	}
+	if l := c.Query("limit"); l != "" {
+		if parsed, err := parseInt(l); err == nil && parsed > 0 && parsed <= 100 {
+			limit = parsed
+		}
+	}
+
+	emails, total, err := h.emailOutbox.List(ctx, statuses, page, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Error:   "Failed to fetch emails",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data: gin.H{
+			"emails":     emails,
+			"total":      total,
+			"page":       page,
+			"limit":      limit,
+			"totalPages": (total + int64(limit) - 1) / int64(limit),
+		},
+	})
+}
+
+func (h *AdminHandler) ResendEmail(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "Invalid email ID",
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := h.emailOutbox.Resend(ctx, id); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, services.ErrEmailNotResendable) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, models.APIResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Email queued for resend",
+	})
+}
+
+func parseTimeParam(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", s)
+}
+
 func parseInt(s string) (int, error) {
 	var result int
 	_, err := parseIntHelper(s, &result)
@@ -0,0 +1,262 @@
+package handlers
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"cinema-booking-system/config"
+	"cinema-booking-system/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	exportFlushEvery = 500
+
+	// ExportStatusTrailer is sent after an export's body: "complete", or
+	// "failed" when it stopped early.
+	ExportStatusTrailer = "X-Export-Status"
+
+	// exportErrorMarker starts the last CSV row of a failed export.
+	exportErrorMarker = "#ERROR"
+)
+
+func auditLogFilter(c *gin.Context) (bson.M, error) {
+	filter := bson.M{}
+
+	var eventTypes []string
+	for _, v := range c.QueryArray("eventType") {
+		for _, t := range strings.Split(v, ",") {
+			if t = strings.TrimSpace(t); t != "" {
+				eventTypes = append(eventTypes, t)
+			}
+		}
+	}
+	switch len(eventTypes) {
+	case 0:
+	case 1:
+		filter["eventType"] = eventTypes[0]
+	default:
+		filter["eventType"] = bson.M{"$in": eventTypes}
+	}
+
+	if sessionID := c.Query("sessionId"); sessionID != "" {
+		filter["sessionId"] = sessionID
+	}
+
+	if userID := c.Query("userId"); userID != "" {
+		filter["userId"] = userID
+	}
+
+	if seatID := c.Query("seatId"); seatID != "" {
+		filter["seatIds"] = seatID
+	}
+
+	from, err := parseTimeParam(c.Query("from"))
+	if err != nil {
+		return nil, errors.New("invalid from: " + err.Error())
+	}
+	to, err := parseTimeParam(c.Query("to"))
+	if err != nil {
+		return nil, errors.New("invalid to: " + err.Error())
+	}
+	if !from.IsZero() || !to.IsZero() {
+		ts := bson.M{}
+		if !from.IsZero() {
+			ts["$gte"] = from
+		}
+		if !to.IsZero() {
+			ts["$lte"] = to
+		}
+		filter["timestamp"] = ts
+	}
+
+	if q := strings.TrimSpace(c.Query("q")); q != "" {
+		filter["description"] = bson.M{"$regex": regexp.QuoteMeta(q), "$options": "i"}
+	}
+
+	return filter, nil
+}
+
+type auditCursor struct {
+	Timestamp time.Time          `json:"t"`
+	ID        primitive.ObjectID `json:"id"`
+}
+
+func (a auditCursor) encode() string {
+	data, _ := json.Marshal(a)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeAuditCursor(s string) (auditCursor, error) {
+	var a auditCursor
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return a, err
+	}
+	if err := json.Unmarshal(data, &a); err != nil {
+		return a, err
+	}
+	return a, nil
+}
+
+func (a auditCursor) filter() bson.M {
+	return bson.M{"$or": []bson.M{
+		{"timestamp": bson.M{"$lt": a.Timestamp}},
+		{"timestamp": a.Timestamp, "_id": bson.M{"$lt": a.ID}},
+	}}
+}
+
+var auditCSVHeader = []string{
+	"timestamp", "eventId", "eventType", "schemaVersion", "sessionId", "userId", "seatIds",
+	"actorType", "actorId", "correlationId", "description", "chainId", "chainSeq", "hash",
+}
+
+func auditCSVRow(l models.AuditLog) []string {
+	row := []string{
+		l.Timestamp.UTC().Format(time.RFC3339Nano),
+		l.EventID,
+		l.EventType,
+		strconv.Itoa(l.SchemaVersion),
+		l.SessionID,
+		l.UserID,
+		strings.Join(l.SeatIDs, ";"),
+		l.ActorType,
+		l.ActorID,
+		l.CorrelationID,
+		l.Description,
+		l.ChainID,
+		strconv.FormatInt(l.ChainSeq, 10),
+		l.Hash,
+	}
+	for i, cell := range row {
+		row[i] = csvSafe(cell)
+	}
+	return row
+}
+
+// csvSafe stops spreadsheet tools from running a cell as a formula.
+func csvSafe(cell string) string {
+	if cell != "" && strings.ContainsRune("=+-@\t\r", rune(cell[0])) {
+		return "'" + cell
+	}
+	return cell
+}
+
+func (h *AdminHandler) ExportAuditLogs(c *gin.Context) {
+	format := c.DefaultQuery("format", "csv")
+	if format != "csv" && format != "jsonl" {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "Invalid format. Must be 'csv' or 'jsonl'",
+		})
+		return
+	}
+
+	filter, err := auditLogFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Minute)
+	defer cancel()
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "timestamp", Value: 1}, {Key: "_id", Value: 1}}).
+		SetBatchSize(exportFlushEvery)
+
+	cursor, err := config.MongoDB.Collection("audit_logs").Find(ctx, filter, opts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Error:   "Failed to fetch audit logs",
+		})
+		return
+	}
+	defer cursor.Close(ctx)
+
+	filename := "audit-logs-" + time.Now().UTC().Format("20060102-150405") + "." + format
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	if format == "csv" {
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+	} else {
+		c.Header("Content-Type", "application/x-ndjson")
+	}
+	c.Header("Trailer", ExportStatusTrailer)
+	c.Status(http.StatusOK)
+
+	w := c.Writer
+	csvWriter := csv.NewWriter(w)
+	jsonEncoder := json.NewEncoder(w)
+
+	write := func(l models.AuditLog) error {
+		if format == "csv" {
+			return csvWriter.Write(auditCSVRow(l))
+		}
+		return jsonEncoder.Encode(l)
+	}
+
+	if format == "csv" {
+		if err := csvWriter.Write(auditCSVHeader); err != nil {
+			return
+		}
+	}
+
+	n := 0
+	var exportErr error
+	for cursor.Next(ctx) {
+		var l models.AuditLog
+		if err := cursor.Decode(&l); err != nil {
+			exportErr = err
+			break
+		}
+		if err := write(l); err != nil {
+			// The client went away; there is nobody left to tell.
+			return
+		}
+
+		n++
+		if n%exportFlushEvery == 0 {
+			csvWriter.Flush()
+			if csvWriter.Error() != nil {
+				return
+			}
+			w.Flush()
+		}
+	}
+	if exportErr == nil {
+		exportErr = cursor.Err()
+	}
+
+	// The 200 is long gone, so a failed export ends with a marker line and
+	// trailer instead, and never looks like a complete one.
+	if exportErr != nil {
+		log.Printf("❌ Audit log export failed after %d records: %v", n, exportErr)
+		if format == "csv" {
+			csvWriter.Write([]string{exportErrorMarker, "export failed after " + strconv.Itoa(n) + " records"})
+		} else {
+			jsonEncoder.Encode(gin.H{"error": "export failed after " + strconv.Itoa(n) + " records"})
+		}
+		w.Header().Set(ExportStatusTrailer, "failed")
+	} else {
+		w.Header().Set(ExportStatusTrailer, "complete")
+	}
+	csvWriter.Flush()
+	w.Flush()
+}
@@ -173,10 +173,10 @@ func (h *AuthHandler) SetUserRole(c *gin.Context) {
 		return
 	}
 
-	if req.Role != models.RoleUser && req.Role != models.RoleAdmin {
+	if req.Role != models.RoleUser && req.Role != models.RoleStaff && req.Role != models.RoleAdmin {
 		c.JSON(http.StatusBadRequest, models.APIResponse{
 			Success: false,
-			Error:   "Invalid role. Must be 'user' or 'admin'",
+			Error:   "Invalid role. Must be 'user', 'staff' or 'admin'",
 		})
 		return
 	}
@@ -0,0 +1,483 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"time"
+
+	"cinema-booking-system/config"
+	"cinema-booking-system/models"
+	"cinema-booking-system/services"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	UserIDHeader = "X-User-ID"
+
+	bookingTabUpcoming = "upcoming"
+	bookingTabPast     = "past"
+	maxHistoryBookings = 500
+)
+
+type bookingSessionView struct {
+	ID          string    `json:"id"`
+	MovieTitle  string    `json:"movieTitle"`
+	MoviePoster string    `json:"moviePoster,omitempty"`
+	Theater     string    `json:"theater"`
+	StartTime   time.Time `json:"startTime"`
+	EndTime     time.Time `json:"endTime"`
+}
+
+type bookingLinks struct {
+	Tickets   string `json:"tickets,omitempty"`
+	TicketPDF string `json:"ticketPdf,omitempty"`
+	Calendar  string `json:"calendar"`
+}
+
+// bookingView is a booking as its owner sees it: joined with its session and
+// with what they can still do with it.
+type bookingView struct {
+	models.Booking
+	Session      bookingSessionView               `json:"session"`
+	Cancellation services.CancellationEligibility `json:"cancellation"`
+	Links        bookingLinks                     `json:"links"`
+}
+
+func newBookingView(b models.Booking, s models.MovieSession, now time.Time) bookingView {
+	id := b.ID.Hex()
+	v := bookingView{
+		Booking: b,
+		Session: bookingSessionView{
+			ID:          b.SessionID.Hex(),
+			MovieTitle:  s.MovieTitle,
+			MoviePoster: s.MoviePoster,
+			Theater:     s.Theater,
+			StartTime:   s.StartTime,
+			EndTime:     s.EndTime,
+		},
+		Cancellation: services.BookingCancellationEligibility(b, s, now),
+		Links:        bookingLinks{Calendar: services.BookingCalendarURL(id)},
+	}
+	if b.Status == models.BookingStatusConfirmed {
+		v.Links.Tickets = services.BookingTicketsURL(id)
+		v.Links.TicketPDF = services.BookingTicketPDFURL(id)
+	}
+	return v
+}
+
+// requestUserID identifies the customer making a request. The app has no
+// sessions yet, so this is the user ID the frontend got at login, sent as
+// X-User-ID or ?userId=.
+func requestUserID(c *gin.Context) string {
+	if id := c.GetHeader(UserIDHeader); id != "" {
+		return id
+	}
+	return c.Query("userId")
+}
+
+// GetMyBookings lists the caller's bookings. ?tab=upcoming (the default)
+// returns shows that have not started yet, soonest first; ?tab=past returns
+// the rest, most recent first.
+func (h *Handler) GetMyBookings(c *gin.Context) {
+	userID := requestUserID(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Error:   "User ID is required",
+		})
+		return
+	}
+
+	tab := c.DefaultQuery("tab", bookingTabUpcoming)
+	if tab != bookingTabUpcoming && tab != bookingTabPast {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "Invalid tab. Must be 'upcoming' or 'past'",
+		})
+		return
+	}
+
+	page := 1
+	limit := 20
+	if p := c.Query("page"); p != "" {
+		if parsed, err := parseInt(p); err == nil && parsed > 0 {
+			page = parsed
+		}
+	}
+	if l := c.Query("limit"); l != "" {
+		if parsed, err := parseInt(l); err == nil && parsed > 0 && parsed <= 100 {
+			limit = parsed
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	cursor, err := config.MongoDB.Collection("bookings").Find(ctx,
+		bson.M{"userId": userID},
+		options.Find().SetSort(bson.D{{Key: "createdAt", Value: -1}}).SetLimit(maxHistoryBookings),
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Error:   "Failed to fetch bookings",
+		})
+		return
+	}
+
+	var bookings []models.Booking
+	if err := cursor.All(ctx, &bookings); err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Error:   "Failed to decode bookings",
+		})
+		return
+	}
+
+	sessionIDs := make([]primitive.ObjectID, 0, len(bookings))
+	for _, b := range bookings {
+		sessionIDs = append(sessionIDs, b.SessionID)
+	}
+
+	sessions := make(map[primitive.ObjectID]models.MovieSession)
+	sessCursor, err := config.MongoDB.Collection("sessions").Find(ctx,
+		bson.M{"_id": bson.M{"$in": sessionIDs}},
+		options.Find().SetProjection(bson.M{"seats": 0}),
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Error:   "Failed to fetch sessions",
+		})
+		return
+	}
+	var list []models.MovieSession
+	if err := sessCursor.All(ctx, &list); err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Error:   "Failed to decode sessions",
+		})
+		return
+	}
+	for _, s := range list {
+		sessions[s.ID] = s
+	}
+
+	now := time.Now().UTC()
+	var upcoming, past []bookingView
+	for _, b := range bookings {
+		v := newBookingView(b, sessions[b.SessionID], now)
+		if v.Session.StartTime.After(now) {
+			upcoming = append(upcoming, v)
+		} else {
+			past = append(past, v)
+		}
+	}
+	sort.SliceStable(upcoming, func(i, j int) bool {
+		return upcoming[i].Session.StartTime.Before(upcoming[j].Session.StartTime)
+	})
+	sort.SliceStable(past, func(i, j int) bool {
+		return past[i].Session.StartTime.After(past[j].Session.StartTime)
+	})
+
+	selected := upcoming
+	if tab == bookingTabPast {
+		selected = past
+	}
+	total := len(selected)
+	start := (page - 1) * limit
+	if start > total {
+		start = total
+	}
+	end := start + limit
+	if end > total {
+		end = total
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data: gin.H{
+			"tab":           tab,
+			"bookings":      append([]bookingView{}, selected[start:end]...),
+			"total":         total,
+			"page":          page,
+			"limit":         limit,
+			"upcomingCount": len(upcoming),
+			"pastCount":     len(past),
+		},
+	})
+}
+
+// GetBooking returns one of the caller's bookings. Someone else's booking is
+// reported as not found.
+func (h *Handler) GetBooking(c *gin.Context) {
+	userID := requestUserID(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Error:   "User ID is required",
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	booking, session, err := h.bookings.GetForUser(ctx, c.Param("id"), userID)
+	if err != nil {
+		if errors.Is(err, services.ErrBookingNotFound) {
+			c.JSON(http.StatusNotFound, models.APIResponse{
+				Success: false,
+				Error:   "Booking not found",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Error:   "Failed to fetch booking",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    newBookingView(*booking, *session, time.Now().UTC()),
+	})
+}
+
+// CancelBooking lets a customer cancel their own booking while the
+// cancellation policy allows it. The seats go back on sale straight away.
+func (h *Handler) CancelBooking(c *gin.Context) {
+	var req struct {
+		UserID string `json:"userId"`
+		Reason string `json:"reason"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil && c.Request.ContentLength > 0 {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "Invalid request: " + err.Error(),
+		})
+		return
+	}
+	userID := requestUserID(c)
+	if userID == "" {
+		userID = req.UserID
+	}
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Error:   "User ID is required",
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(eventContext(c), 10*time.Second)
+	defer cancel()
+
+	booking, session, err := h.bookings.Cancel(ctx, c.Param("id"), userID, req.Reason)
+	if err != nil {
+		var notCancellable *services.NotCancellableError
+		switch {
+		case errors.Is(err, services.ErrBookingNotFound):
+			c.JSON(http.StatusNotFound, models.APIResponse{
+				Success: false,
+				Error:   "Booking not found",
+			})
+		case errors.As(err, &notCancellable):
+			c.JSON(http.StatusConflict, models.APIResponse{
+				Success: false,
+				Error:   notCancellable.Error(),
+				Data:    gin.H{"reason": notCancellable.Reason},
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, models.APIResponse{
+				Success: false,
+				Error:   "Failed to cancel booking",
+			})
+		}
+		return
+	}
+
+	bookingID := booking.ID.Hex()
+
+	h.releaseSeats(ctx, booking.SessionID.Hex(), booking.Seats, "")
+
+	invite := models.EmailAttachment{
+		Filename:    "booking-" + bookingID + ".ics",
+		ContentType: services.CalendarContentType,
+		Data: services.BuildBookingCalendar(session.MovieTitle, []services.BookingCalendarEntry{
+			{Booking: *booking, Session: *session},
+		}),
+	}
+	_, err = h.emailOutbox.EnqueueBookingEmailOnce(ctx, "cancellation:"+bookingID, models.EmailKindBookingCancellation, booking.UserEmail, services.BookingEmailData{
+		Locale:      booking.Locale,
+		UserName:    booking.UserEmail,
+		BookingID:   bookingID,
+		MovieTitle:  session.MovieTitle,
+		Theater:     session.Theater,
+		Seats:       booking.Seats,
+		TotalAmount: booking.TotalAmount,
+		ShowTime:    session.StartTime,
+		Reason:      booking.CancelReason,
+	}, invite)
+	if err != nil {
+		log.Printf("❌ Failed to queue cancellation email for %s: %v", booking.UserEmail, err)
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Booking cancelled",
+		Data:    newBookingView(*booking, *session, time.Now().UTC()),
+	})
+}
+
+// ExchangeBooking moves a customer's booking to other seats, in the same
+// session or another one, under the cancellation policy. The booking keeps
+// its ID; the price difference is charged or refunded and an updated
+// confirmation with the new tickets is emailed.
+func (h *Handler) ExchangeBooking(c *gin.Context) {
+	var req models.ExchangeBookingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "Invalid request: " + err.Error(),
+		})
+		return
+	}
+	userID := requestUserID(c)
+	if userID == "" {
+		userID = req.UserID
+	}
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Error:   "User ID is required",
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(eventContext(c), 15*time.Second)
+	defer cancel()
+
+	result, err := h.bookings.Exchange(ctx, c.Param("id"), userID, req.SessionID, req.SeatIDs)
+	if err != nil {
+		var notExchangeable *services.NotExchangeableError
+		var rejected *services.LockRejectedError
+		var notOnSale *services.SessionNotOnSaleError
+		var unavailable *services.SeatUnavailableError
+		var conflict *services.SessionConflictError
+		switch {
+		case errors.Is(err, services.ErrBookingNotFound):
+			c.JSON(http.StatusNotFound, models.APIResponse{
+				Success: false,
+				Error:   "Booking not found",
+			})
+		case errors.As(err, &notExchangeable):
+			c.JSON(http.StatusConflict, models.APIResponse{
+				Success: false,
+				Error:   notExchangeable.Error(),
+				Data:    gin.H{"reason": notExchangeable.Reason},
+			})
+		case errors.As(err, &rejected):
+			respondLockRejected(c, rejected)
+		case errors.As(err, &notOnSale):
+			respondNotOnSale(c, notOnSale)
+		case errors.As(err, &unavailable), errors.As(err, &conflict), errors.Is(err, services.ErrBookingChanged):
+			c.JSON(http.StatusConflict, models.APIResponse{
+				Success: false,
+				Error:   "Seats or booking changed while exchanging, please try again",
+			})
+		default:
+			log.Printf("❌ Failed to exchange booking %s: %v", c.Param("id"), err)
+			c.JSON(http.StatusInternalServerError, models.APIResponse{
+				Success: false,
+				Error:   "Failed to exchange booking",
+			})
+		}
+		return
+	}
+
+	booking, session, previous := result.Booking, result.Session, result.Previous
+	bookingID := booking.ID.Hex()
+
+	var booked []models.SeatUpdate
+	for _, seatID := range result.Booked {
+		booked = append(booked, models.SeatUpdate{SeatID: seatID, Status: models.SeatBooked})
+	}
+	if len(result.Released) > 0 {
+		h.releaseSeats(ctx, previous.SessionID.Hex(), result.Released, "")
+	}
+	if len(booked) > 0 {
+		h.wsHub.BroadcastMultipleSeatUpdates(booking.SessionID.Hex(), booked)
+	}
+
+	invite := models.EmailAttachment{
+		Filename:    "booking-" + bookingID + ".ics",
+		ContentType: services.CalendarContentType,
+		Data: services.BuildBookingCalendar(session.MovieTitle, []services.BookingCalendarEntry{
+			{Booking: *booking, Session: *session},
+		}),
+	}
+	attachments, ticketImages, err := services.TicketAttachments(services.BookingTickets(*booking))
+	if err != nil {
+		log.Printf("⚠️ Failed to render tickets for booking %s: %v", bookingID, err)
+	}
+	attachments = append(attachments, invite)
+	if config.AppConfig.AttachTicketPDF {
+		if pdf, err := services.RenderTicketPDF(*booking, *session); err != nil {
+			log.Printf("⚠️ Failed to render ticket PDF for booking %s: %v", bookingID, err)
+		} else {
+			attachments = append(attachments, models.EmailAttachment{
+				Filename:    "ticket-" + bookingID + ".pdf",
+				ContentType: services.TicketPDFContentType,
+				Data:        pdf,
+			})
+		}
+	}
+
+	data := services.BookingEmailData{
+		Locale:          booking.Locale,
+		UserName:        booking.UserEmail,
+		BookingID:       bookingID,
+		MovieTitle:      session.MovieTitle,
+		Theater:         session.Theater,
+		Seats:           booking.Seats,
+		TotalAmount:     booking.TotalAmount,
+		ShowTime:        session.StartTime,
+		Updated:         true,
+		CalendarURL:     services.BookingCalendarURL(bookingID),
+		CalendarFeedURL: services.UserCalendarFeedURL(booking.UserID),
+		TicketsURL:      services.BookingTicketsURL(bookingID),
+		TicketPDFURL:    services.BookingTicketPDFURL(bookingID),
+		Tickets:         ticketImages,
+	}
+	if previous.PriceDifference > 0 {
+		data.ChargeAmount = previous.PriceDifference
+	} else if previous.PriceDifference < 0 {
+		data.RefundAmount = -previous.PriceDifference
+		data.RefundReference = previous.PaymentReference
+	}
+	dedupeKey := fmt.Sprintf("exchange:%s:%d", bookingID, booking.Revision)
+	_, err = h.emailOutbox.EnqueueBookingEmailOnce(ctx, dedupeKey, models.EmailKindBookingConfirmation, booking.UserEmail, data, attachments...)
+	if err != nil {
+		log.Printf("❌ Failed to queue updated confirmation email for %s: %v", booking.UserEmail, err)
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Booking exchanged",
+		Data: gin.H{
+			"booking":          newBookingView(*booking, *session, time.Now().UTC()),
+			"priceDifference":  previous.PriceDifference,
+			"paymentReference": previous.PaymentReference,
+		},
+	})
+}
@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"cinema-booking-system/config"
+	"cinema-booking-system/models"
+	"cinema-booking-system/services"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const calendarFeedLookback = 24 * time.Hour
+
+func (h *Handler) GetBookingCalendar(c *gin.Context) {
+	bookingID := c.Param("id")
+	if !services.VerifyToken(services.TokenPurposeBookingCalendar, bookingID, c.Query("token")) {
+		c.JSON(http.StatusForbidden, models.APIResponse{
+			Success: false,
+			Error:   "Invalid or missing token",
+		})
+		return
+	}
+
+	oid, err := primitive.ObjectIDFromHex(bookingID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "Invalid booking ID",
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var booking models.Booking
+	if err := config.MongoDB.Collection("bookings").FindOne(ctx, bson.M{"_id": oid}).Decode(&booking); err != nil {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Error:   "Booking not found",
+		})
+		return
+	}
+
+	var session models.MovieSession
+	if err := config.MongoDB.Collection("sessions").FindOne(ctx, bson.M{"_id": booking.SessionID}).Decode(&session); err != nil {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Error:   "Session not found",
+		})
+		return
+	}
+
+	body := services.BuildBookingCalendar(session.MovieTitle, []services.BookingCalendarEntry{
+		{Booking: booking, Session: session},
+	})
+	c.Header("Content-Disposition", `attachment; filename="booking-`+bookingID+`.ics"`)
+	c.Data(http.StatusOK, services.CalendarContentType, body)
+}
+
+// GetUserCalendarFeed serves a subscribable calendar of a user's upcoming
+// bookings. Cancelled bookings stay in the feed so subscribed clients drop
+// the event they already have.
+func (h *Handler) GetUserCalendarFeed(c *gin.Context) {
+	userID := c.Param("userId")
+	if !services.VerifyToken(services.TokenPurposeUserCalendar, userID, c.Query("token")) {
+		c.JSON(http.StatusForbidden, models.APIResponse{
+			Success: false,
+			Error:   "Invalid or missing token",
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	cursor, err := config.MongoDB.Collection("bookings").Find(ctx,
+		bson.M{"userId": userID},
+		options.Find().SetSort(bson.D{{Key: "createdAt", Value: -1}}).SetLimit(500),
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Error:   "Failed to fetch bookings",
+		})
+		return
+	}
+
+	var bookings []models.Booking
+	if err := cursor.All(ctx, &bookings); err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Error:   "Failed to decode bookings",
+		})
+		return
+	}
+
+	sessionIDs := make([]primitive.ObjectID, 0, len(bookings))
+	for _, b := range bookings {
+		sessionIDs = append(sessionIDs, b.SessionID)
+	}
+
+	sessions := make(map[primitive.ObjectID]models.MovieSession)
+	sessCursor, err := config.MongoDB.Collection("sessions").Find(ctx,
+		bson.M{
+			"_id":       bson.M{"$in": sessionIDs},
+			"startTime": bson.M{"$gte": time.Now().Add(-calendarFeedLookback)},
+		},
+		options.Find().SetProjection(bson.M{"seats": 0}),
+	)
+	if err == nil {
+		var list []models.MovieSession
+		if err := sessCursor.All(ctx, &list); err == nil {
+			for _, s := range list {
+				sessions[s.ID] = s
+			}
+		}
+	}
+
+	var entries []services.BookingCalendarEntry
+	for _, b := range bookings {
+		if s, ok := sessions[b.SessionID]; ok {
+			entries = append(entries, services.BookingCalendarEntry{Booking: b, Session: s})
+		}
+	}
+
+	c.Header("Cache-Control", "private, max-age=900")
+	c.Data(http.StatusOK, services.CalendarContentType, services.BuildBookingCalendar("Cinema Bookings", entries))
+}
@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"net/http"
+
+	"cinema-booking-system/models"
+	"cinema-booking-system/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DevHandler exposes the in-memory mailbox used when MAIL_TRANSPORT=memory.
+type DevHandler struct {
+	mailbox *services.MemoryMailer
+}
+
+func NewDevHandler(mailbox *services.MemoryMailer) *DevHandler {
+	return &DevHandler{mailbox: mailbox}
+}
+
+func (h *DevHandler) GetEmails(c *gin.Context) {
+	messages := h.mailbox.Messages()
+	if to := c.Query("to"); to != "" {
+		filtered := []services.CapturedMail{}
+		for _, m := range messages {
+			if m.To == to {
+				filtered = append(filtered, m)
+			}
+		}
+		messages = filtered
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data: gin.H{
+			"emails": messages,
+			"total":  len(messages),
+		},
+	})
+}
+
+func (h *DevHandler) GetEmail(c *gin.Context) {
+	m, ok := h.mailbox.Get(c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Error:   "Email not found",
+		})
+		return
+	}
+
+	switch c.Query("format") {
+	case "html":
+		c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(m.HTMLBody))
+	case "text":
+		c.Data(http.StatusOK, "text/plain; charset=utf-8", []byte(m.TextBody))
+	case "raw":
+		c.Data(http.StatusOK, "message/rfc822", []byte(m.Raw))
+	default:
+		c.JSON(http.StatusOK, models.APIResponse{
+			Success: true,
+			Data:    m,
+		})
+	}
+}
+
+func (h *DevHandler) ClearEmails(c *gin.Context) {
+	h.mailbox.Clear()
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Mailbox cleared",
+	})
+}
@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
@@ -19,18 +20,29 @@ import (
 
 type Handler struct {
 	lockService  *services.RedisLockService
-	kafkaService *services.KafkaProducerService
-	emailService *services.EmailService
+	seatMaps     *services.SeatMapCache
+	eventService *services.EventProducerService
+	emailOutbox  *services.EmailOutboxService
+	tickets      *services.TicketService
+	bookings     *services.BookingService
+	waitlist     *services.WaitlistService
 	wsHub        *websocket.Hub
 }
 
-func NewHandler(wsHub *websocket.Hub) *Handler {
-	return &Handler{
+func NewHandler(wsHub *websocket.Hub, emailOutbox *services.EmailOutboxService, payments services.PaymentGateway, waitlist *services.WaitlistService) *Handler {
+	h := &Handler{
+		waitlist:     waitlist,
 		lockService:  services.NewRedisLockService(),
-		kafkaService: services.NewKafkaProducerService(),
-		emailService: services.NewEmailService(),
+		eventService: services.NewEventProducerService(),
+		emailOutbox:  emailOutbox,
 		wsHub:        wsHub,
 	}
+	if config.MongoDB != nil {
+		h.seatMaps = services.NewSeatMapCache()
+		h.tickets = services.NewTicketService(h.eventService)
+		h.bookings = services.NewBookingService(h.eventService, payments)
+	}
+	return h
 }
 
 func (h *Handler) HealthCheck(c *gin.Context) {
@@ -72,6 +84,11 @@ func (h *Handler) GetSessions(c *gin.Context) {
 		return
 	}
 
+	now := time.Now().UTC()
+	for i := range sessions {
+		services.ApplySessionStatus(&sessions[i], now, config.AppConfig.SalesCutoff)
+	}
+
 	c.JSON(http.StatusOK, models.APIResponse{
 		Success: true,
 		Data:    sessions,
@@ -111,6 +128,7 @@ func (h *Handler) GetSession(c *gin.Context) {
 			session.Seats[i].LockedBy = lockedBy
 		}
 	}
+	services.ApplySessionStatus(&session, time.Now().UTC(), config.AppConfig.SalesCutoff)
 
 	c.JSON(http.StatusOK, models.APIResponse{
 		Success: true,
@@ -118,6 +136,177 @@ func (h *Handler) GetSession(c *gin.Context) {
 	})
 }
 
+// requireSessionOnSale loads a session and writes the error response when
+// its seats cannot be locked or booked right now.
+func (h *Handler) requireSessionOnSale(ctx context.Context, c *gin.Context, sessionID string) (models.MovieSession, bool) {
+	var session models.MovieSession
+
+	objectID, err := primitive.ObjectIDFromHex(sessionID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "Invalid session ID",
+		})
+		return session, false
+	}
+
+	err = config.MongoDB.Collection("sessions").FindOne(ctx, bson.M{"_id": objectID}).Decode(&session)
+	if err != nil {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Error:   "Session not found",
+		})
+		return session, false
+	}
+
+	if err := services.CheckSessionOnSale(session, time.Now().UTC(), config.AppConfig.SalesCutoff); err != nil {
+		respondNotOnSale(c, err.(*services.SessionNotOnSaleError))
+		return session, false
+	}
+	return session, true
+}
+
+func respondNotOnSale(c *gin.Context, err *services.SessionNotOnSaleError) {
+	c.JSON(http.StatusConflict, models.APIResponse{
+		Success: false,
+		Error:   err.Error(),
+		Data: gin.H{
+			"code":          err.Code(),
+			"sessionStatus": err.Status,
+		},
+	})
+}
+
+// respondLockRejected explains a refused lock with a code the UI can act
+// on. Unknown sessions are 404, malformed requests 400 and everything else
+// - closed sales, taken or blocked seats - 409.
+func respondLockRejected(c *gin.Context, err *services.LockRejectedError) {
+	status := http.StatusConflict
+	switch err.Code {
+	case services.LockRejectSessionNotFound:
+		status = http.StatusNotFound
+	case services.LockRejectInvalidSession, services.LockRejectNoSeats, services.LockRejectDuplicateSeat, services.LockRejectSeatNotFound:
+		status = http.StatusBadRequest
+	}
+
+	data := gin.H{"code": err.Code}
+	if err.SessionStatus != "" {
+		data["sessionStatus"] = err.SessionStatus
+	}
+	if len(err.Seats) > 0 {
+		data["seats"] = err.Seats
+		failed := make([]string, 0, len(err.Seats))
+		for _, seat := range err.Seats {
+			failed = append(failed, seat.SeatID)
+		}
+		data["failedSeats"] = failed
+	}
+	if len(err.Suggestion) > 0 {
+		data["suggestedSeats"] = err.Suggestion
+	}
+	c.JSON(status, models.APIResponse{
+		Success: false,
+		Error:   err.Error(),
+		Data:    data,
+	})
+}
+
+// respondConfirmFailed explains a booking that could not be confirmed.
+// Losing a race for a seat is a 409, so the client can pick other seats.
+func respondConfirmFailed(c *gin.Context, sessionID string, err error) {
+	var notOnSale *services.SessionNotOnSaleError
+	if errors.As(err, &notOnSale) {
+		respondNotOnSale(c, notOnSale)
+		return
+	}
+	var unavailable *services.SeatUnavailableError
+	if errors.As(err, &unavailable) {
+		c.JSON(http.StatusConflict, models.APIResponse{
+			Success: false,
+			Error:   "Seat " + unavailable.SeatID + " is already booked",
+		})
+		return
+	}
+	var conflict *services.SessionConflictError
+	if errors.As(err, &conflict) {
+		c.JSON(http.StatusConflict, models.APIResponse{
+			Success: false,
+			Error:   "Seats changed while booking, please try again",
+		})
+		return
+	}
+	log.Printf("❌ Booking confirmation for session %s failed: %v", sessionID, err)
+	c.JSON(http.StatusInternalServerError, models.APIResponse{
+		Success: false,
+		Error:   "Failed to create booking",
+	})
+}
+
+// enforceSingleSeatGap refuses a selection that would leave a lone empty seat
+// in sessions with that rule, suggesting one that would not. It reports
+// whether the request may go on.
+func (h *Handler) enforceSingleSeatGap(ctx context.Context, c *gin.Context, session *models.MovieSession, seatIDs []string, userID string) bool {
+	err := services.EnforceSingleSeatGap(ctx, h.lockService, session, seatIDs, userID)
+	if err == nil {
+		return true
+	}
+	var rejected *services.LockRejectedError
+	if errors.As(err, &rejected) {
+		respondLockRejected(c, rejected)
+		return false
+	}
+	log.Printf("❌ Failed to check seat gaps for session %s: %v", session.ID.Hex(), err)
+	c.JSON(http.StatusInternalServerError, models.APIResponse{
+		Success: false,
+		Error:   "Failed to check seats",
+	})
+	return false
+}
+
+// releaseSeats frees seats of a session that owner had locked, or that were
+// booked when owner is "", and tells clients they are available. With a
+// waitlist, seats wanted by waiting customers stay locked and go to the
+// waitlist worker instead.
+func (h *Handler) releaseSeats(ctx context.Context, sessionID string, seatIDs []string, owner string) {
+	if h.waitlist != nil {
+		h.waitlist.SeatsReleased(ctx, sessionID, seatIDs, owner)
+		return
+	}
+	if owner != "" {
+		h.lockService.UnlockMultipleSeats(ctx, sessionID, seatIDs, owner)
+	}
+	var seatUpdates []models.SeatUpdate
+	for _, seatID := range seatIDs {
+		seatUpdates = append(seatUpdates, models.SeatUpdate{
+			SeatID: seatID,
+			Status: models.SeatAvailable,
+		})
+	}
+	h.wsHub.BroadcastMultipleSeatUpdates(sessionID, seatUpdates)
+}
+
+// loadSeatMap reads a session's seat map for locking, answering the request
+// itself if the session cannot be found or read.
+func (h *Handler) loadSeatMap(ctx context.Context, c *gin.Context, sessionID string) (*models.MovieSession, bool) {
+	session, err := h.seatMaps.Get(ctx, sessionID)
+	if err != nil {
+		if errors.Is(err, services.ErrSessionNotFound) {
+			code := services.LockRejectSessionNotFound
+			if _, err := primitive.ObjectIDFromHex(sessionID); err != nil {
+				code = services.LockRejectInvalidSession
+			}
+			respondLockRejected(c, &services.LockRejectedError{Code: code})
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Error:   "Failed to load session",
+		})
+		return nil, false
+	}
+	return session, true
+}
+
 func (h *Handler) LockSeats(c *gin.Context) {
 	var req models.LockSeatRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -131,15 +320,35 @@ func (h *Handler) LockSeats(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
+	session, ok := h.loadSeatMap(ctx, c, req.SessionID)
+	if !ok {
+		return
+	}
+	if err := services.ValidateSeatLock(session, req.SeatIDs, time.Now().UTC(), config.AppConfig.SalesCutoff); err != nil {
+		respondLockRejected(c, err.(*services.LockRejectedError))
+		return
+	}
+	if !h.enforceSingleSeatGap(ctx, c, session, req.SeatIDs, req.UserID) {
+		return
+	}
+
 	lockedSeats, failedSeats, err := h.lockService.LockMultipleSeats(ctx, req.SessionID, req.SeatIDs, req.UserID)
+	if err == nil && len(failedSeats) > 0 {
+		err = errors.New("seats are already locked")
+	}
 	if err != nil {
-		c.JSON(http.StatusConflict, models.APIResponse{
-			Success: false,
-			Error:   err.Error(),
-			Data: gin.H{
-				"failedSeats": failedSeats,
-			},
-		})
+		rejected := &services.LockRejectedError{Code: services.LockRejectSeatLocked}
+		for _, seatID := range failedSeats {
+			rejected.Seats = append(rejected.Seats, services.SeatRejection{SeatID: seatID, Code: services.LockRejectSeatLocked})
+		}
+		if len(rejected.Seats) == 0 {
+			c.JSON(http.StatusConflict, models.APIResponse{
+				Success: false,
+				Error:   err.Error(),
+			})
+			return
+		}
+		respondLockRejected(c, rejected)
 		return
 	}
 
@@ -153,7 +362,7 @@ func (h *Handler) LockSeats(c *gin.Context) {
 	}
 	h.wsHub.BroadcastMultipleSeatUpdates(req.SessionID, seatUpdates)
 
-	go h.kafkaService.LogSeatLocked(context.Background(), req.SessionID, req.UserID, lockedSeats)
+	go h.eventService.LogSeatLocked(eventContext(c), req.SessionID, req.UserID, lockedSeats)
 
 	c.JSON(http.StatusOK, models.APIResponse{
 		Success: true,
@@ -165,8 +374,10 @@ func (h *Handler) LockSeats(c *gin.Context) {
 	})
 }
 
-func (h *Handler) UnlockSeats(c *gin.Context) {
-	var req models.UnlockSeatRequest
+// BestAvailable picks the best block of adjacent free seats for a party and
+// locks it in one call, so large groups need not click seats one by one.
+func (h *Handler) BestAvailable(c *gin.Context) {
+	var req models.BestAvailableRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, models.APIResponse{
 			Success: false,
@@ -174,28 +385,94 @@ func (h *Handler) UnlockSeats(c *gin.Context) {
 		})
 		return
 	}
+	userID := requestUserID(c)
+	if userID == "" {
+		userID = req.UserID
+	}
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Error:   "User ID is required",
+		})
+		return
+	}
+	prefs, err := services.NewSeatPreferences(req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	if err := h.lockService.UnlockMultipleSeats(ctx, req.SessionID, req.SeatIDs, req.UserID); err != nil {
+	sessionID := c.Param("id")
+	session, ok := h.loadSeatMap(ctx, c, sessionID)
+	if !ok {
+		return
+	}
+	if err := services.CheckSessionOnSale(*session, time.Now().UTC(), config.AppConfig.SalesCutoff); err != nil {
+		notOnSale := err.(*services.SessionNotOnSaleError)
+		respondLockRejected(c, &services.LockRejectedError{Code: notOnSale.Code(), SessionStatus: notOnSale.Status})
+		return
+	}
+
+	block, err := services.LockBestAvailable(ctx, h.lockService, session, userID, prefs)
+	if err != nil {
+		var rejected *services.LockRejectedError
+		if errors.As(err, &rejected) {
+			respondLockRejected(c, rejected)
+			return
+		}
 		c.JSON(http.StatusInternalServerError, models.APIResponse{
 			Success: false,
-			Error:   err.Error(),
+			Error:   "Failed to find seats",
 		})
 		return
 	}
 
 	var seatUpdates []models.SeatUpdate
-	for _, seatID := range req.SeatIDs {
+	for _, seatID := range block.SeatIDs {
 		seatUpdates = append(seatUpdates, models.SeatUpdate{
-			SeatID: seatID,
-			Status: models.SeatAvailable,
+			SeatID:   seatID,
+			Status:   models.SeatLocked,
+			LockedBy: userID,
 		})
 	}
-	h.wsHub.BroadcastMultipleSeatUpdates(req.SessionID, seatUpdates)
+	h.wsHub.BroadcastMultipleSeatUpdates(sessionID, seatUpdates)
+
+	go h.eventService.LogSeatLocked(eventContext(c), sessionID, userID, block.SeatIDs)
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Seats locked successfully",
+		Data: gin.H{
+			"lockedSeats": block.SeatIDs,
+			"row":         block.Row,
+			"totalAmount": services.SeatsTotal(*session, block.SeatIDs),
+			"expiresIn":   services.LockDuration.Seconds(),
+		},
+	})
+}
+
+func (h *Handler) UnlockSeats(c *gin.Context) {
+	var req models.UnlockSeatRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "Invalid request: " + err.Error(),
+		})
+		return
+	}
 
-	go h.kafkaService.LogSeatUnlocked(context.Background(), req.SessionID, req.UserID, req.SeatIDs, "manual")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	h.releaseSeats(ctx, req.SessionID, req.SeatIDs, req.UserID)
+
+	go h.eventService.LogSeatUnlocked(eventContext(c), req.SessionID, req.UserID, req.SeatIDs, "manual")
 
 	c.JSON(http.StatusOK, models.APIResponse{
 		Success: true,
@@ -227,49 +504,37 @@ func (h *Handler) CreateBooking(c *gin.Context) {
 		}
 	}
 
-	sessionObjectID, _ := primitive.ObjectIDFromHex(req.SessionID)
-
-	sessCollection := config.MongoDB.Collection("sessions")
-	var session models.MovieSession
-	if err := sessCollection.FindOne(ctx, bson.M{"_id": sessionObjectID}).Decode(&session); err != nil {
-		session.MovieTitle = "Cinema Booking"
-		session.Theater = "Theater 1"
-		session.StartTime = time.Now()
+	session, ok := h.requireSessionOnSale(ctx, c, req.SessionID)
+	if !ok {
+		return
+	}
+	if !h.enforceSingleSeatGap(ctx, c, &session, req.SeatIDs, req.UserID) {
+		return
 	}
 
 	booking := models.Booking{
-		SessionID:   sessionObjectID,
+		SessionID:   session.ID,
 		UserID:      req.UserID,
 		UserEmail:   req.UserEmail,
 		Seats:       req.SeatIDs,
-		TotalAmount: float64(len(req.SeatIDs)) * 150.0,
-		Status:      "CONFIRMED",
+		TotalAmount: services.SeatsTotal(session, req.SeatIDs),
+		Status:      models.BookingStatusConfirmed,
+		Locale:      services.ResolveLocale(req.Locale + "," + c.GetHeader("Accept-Language")).Tag,
 		CreatedAt:   time.Now().UTC(),
 	}
 	confirmedAt := time.Now().UTC()
 	booking.ConfirmedAt = &confirmedAt
 
-	collection := config.MongoDB.Collection("bookings")
-	result, err := collection.InsertOne(ctx, booking)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.APIResponse{
-			Success: false,
-			Error:   "Failed to create booking",
-		})
+	if err := h.bookings.Confirm(ctx, &booking); err != nil {
+		respondConfirmFailed(c, req.SessionID, err)
 		return
 	}
+	bookingID := booking.ID.Hex()
 
-	bookingID := result.InsertedID.(primitive.ObjectID).Hex()
-
-	for _, seatID := range req.SeatIDs {
-		sessCollection.UpdateOne(ctx,
-			bson.M{"_id": sessionObjectID, "seats.id": seatID},
-			bson.M{"$set": bson.M{"seats.$.status": models.SeatBooked}},
-		)
+	if h.waitlist != nil {
+		h.waitlist.MarkBooked(ctx, session.ID, req.UserID)
 	}
 
-	h.lockService.UnlockMultipleSeats(ctx, req.SessionID, req.SeatIDs, req.UserID)
-
 	var seatUpdates []models.SeatUpdate
 	for _, seatID := range req.SeatIDs {
 		seatUpdates = append(seatUpdates, models.SeatUpdate{
@@ -279,31 +544,62 @@ func (h *Handler) CreateBooking(c *gin.Context) {
 	}
 	h.wsHub.BroadcastMultipleSeatUpdates(req.SessionID, seatUpdates)
 
-	go h.kafkaService.LogBookingSuccess(context.Background(), req.SessionID, req.UserID, req.SeatIDs, bookingID)
-
-	go func() {
-		log.Printf("📧 Attempting to send email to: %s", req.UserEmail)
-		err := h.emailService.SendBookingConfirmation(req.UserEmail, services.BookingConfirmationData{
-			UserName:    req.UserEmail,
-			BookingID:   bookingID,
-			MovieTitle:  session.MovieTitle,
-			Theater:     session.Theater,
-			Seats:       req.SeatIDs,
-			TotalAmount: booking.TotalAmount,
-			BookingDate: session.StartTime.Format("January 2, 2006 at 3:04 PM"),
-		})
-		if err != nil {
-			log.Printf("❌ Failed to send email to %s: %v", req.UserEmail, err)
+	go h.eventService.LogBookingSuccess(eventContext(c), req.SessionID, req.UserID, req.SeatIDs, bookingID, booking.TotalAmount)
+
+	invite := models.EmailAttachment{
+		Filename:    "booking-" + bookingID + ".ics",
+		ContentType: services.CalendarContentType,
+		Data: services.BuildBookingCalendar(session.MovieTitle, []services.BookingCalendarEntry{
+			{Booking: booking, Session: session},
+		}),
+	}
+	attachments, ticketImages, err := services.TicketAttachments(services.BookingTickets(booking))
+	if err != nil {
+		log.Printf("⚠️ Failed to render tickets for booking %s: %v", bookingID, err)
+	}
+	attachments = append(attachments, invite)
+	if config.AppConfig.AttachTicketPDF {
+		if pdf, err := services.RenderTicketPDF(booking, session); err != nil {
+			log.Printf("⚠️ Failed to render ticket PDF for booking %s: %v", bookingID, err)
+		} else {
+			attachments = append(attachments, models.EmailAttachment{
+				Filename:    "ticket-" + bookingID + ".pdf",
+				ContentType: services.TicketPDFContentType,
+				Data:        pdf,
+			})
 		}
-	}()
+	}
+
+	_, err = h.emailOutbox.EnqueueBookingEmail(ctx, models.EmailKindBookingConfirmation, req.UserEmail, services.BookingEmailData{
+		Locale:          booking.Locale,
+		UserName:        req.UserEmail,
+		BookingID:       bookingID,
+		MovieTitle:      session.MovieTitle,
+		Theater:         session.Theater,
+		Seats:           req.SeatIDs,
+		TotalAmount:     booking.TotalAmount,
+		ShowTime:        session.StartTime,
+		CalendarURL:     services.BookingCalendarURL(bookingID),
+		CalendarFeedURL: services.UserCalendarFeedURL(req.UserID),
+		TicketsURL:      services.BookingTicketsURL(bookingID),
+		TicketPDFURL:    services.BookingTicketPDFURL(bookingID),
+		Tickets:         ticketImages,
+	}, attachments...)
+	if err != nil {
+		log.Printf("❌ Failed to queue confirmation email for %s: %v", req.UserEmail, err)
+	}
 
 	c.JSON(http.StatusCreated, models.APIResponse{
 		Success: true,
 		Message: "Booking confirmed",
 		Data: gin.H{
-			"bookingId":   bookingID,
-			"seats":       req.SeatIDs,
-			"totalAmount": booking.TotalAmount,
+			"bookingId":       bookingID,
+			"seats":           req.SeatIDs,
+			"totalAmount":     booking.TotalAmount,
+			"calendarUrl":     services.BookingCalendarURL(bookingID),
+			"calendarFeedUrl": services.UserCalendarFeedURL(req.UserID),
+			"ticketsUrl":      services.BookingTicketsURL(bookingID),
+			"ticketPdfUrl":    services.BookingTicketPDFURL(bookingID),
 		},
 	})
 }
@@ -314,10 +610,14 @@ func (h *Handler) CreateDemoSession(c *gin.Context) {
 
 	collection := config.MongoDB.Collection("sessions")
 
+	// Only reuse a demo session that is still on sale; once its sales close
+	// a fresh one is created.
 	var existingSession models.MovieSession
 	err := collection.FindOne(ctx, bson.M{
 		"movieTitle": "Inception",
 		"theater":    "Theater 1",
+		"startTime":  bson.M{"$gt": time.Now().UTC().Add(config.AppConfig.SalesCutoff)},
+		"status":     bson.M{"$ne": models.SessionCancelled},
 	}).Decode(&existingSession)
 
 	if err == nil {
@@ -329,6 +629,7 @@ func (h *Handler) CreateDemoSession(c *gin.Context) {
 				existingSession.Seats[i].LockedBy = lockedBy
 			}
 		}
+		services.ApplySessionStatus(&existingSession, time.Now().UTC(), config.AppConfig.SalesCutoff)
 
 		c.JSON(http.StatusOK, models.APIResponse{
 			Success: true,
@@ -341,14 +642,20 @@ func (h *Handler) CreateDemoSession(c *gin.Context) {
 	var seats []models.Seat
 	rows := []string{"A", "B", "C", "D", "E", "F", "G", "H"}
 	for _, row := range rows {
+		// The back two rows are premium.
+		category, price := models.SeatCategoryStandard, 150.0
+		if row >= "G" {
+			category, price = models.SeatCategoryPremium, 220.0
+		}
 		for num := 1; num <= 10; num++ {
 			seatID := fmt.Sprintf("%s%d", row, num)
 			seats = append(seats, models.Seat{
-				ID:     seatID,
-				Row:    row,
-				Number: num,
-				Status: models.SeatAvailable,
-				Price:  150.0,
+				ID:       seatID,
+				Row:      row,
+				Number:   num,
+				Status:   models.SeatAvailable,
+				Price:    price,
+				Category: category,
 			})
 		}
 	}
@@ -361,6 +668,8 @@ func (h *Handler) CreateDemoSession(c *gin.Context) {
 		EndTime:     time.Now().Add(4 * time.Hour),
 		Seats:       seats,
 		TotalSeats:  len(seats),
+		Version:     1,
+		Status:      models.SessionOnSale,
 		CreatedAt:   time.Now().UTC(),
 		UpdatedAt:   time.Now().UTC(),
 	}
@@ -375,6 +684,7 @@ func (h *Handler) CreateDemoSession(c *gin.Context) {
 	}
 
 	session.ID = result.InsertedID.(primitive.ObjectID)
+	services.ApplySessionStatus(&session, time.Now().UTC(), config.AppConfig.SalesCutoff)
 
 	c.JSON(http.StatusCreated, models.APIResponse{
 		Success: true,
@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"cinema-booking-system/models"
+	"cinema-booking-system/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRespondConfirmFailed(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantError  string
+	}{
+		{
+			name:       "seat taken",
+			err:        fmt.Errorf("confirm: %w", &services.SeatUnavailableError{SeatID: "A1"}),
+			wantStatus: http.StatusConflict,
+			wantError:  "Seat A1 is already booked",
+		},
+		{
+			name:       "session changed",
+			err:        &services.SessionConflictError{SessionID: "s1", Version: 3},
+			wantStatus: http.StatusConflict,
+			wantError:  "Seats changed while booking, please try again",
+		},
+		{
+			name:       "sales closed",
+			err:        &services.SessionNotOnSaleError{Status: models.SessionSalesClosed},
+			wantStatus: http.StatusConflict,
+		},
+		{
+			name:       "store failure",
+			err:        errors.New("mongo unavailable"),
+			wantStatus: http.StatusInternalServerError,
+			wantError:  "Failed to create booking",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+
+			respondConfirmFailed(c, "s1", tt.err)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			var resp models.APIResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("decode response: %v", err)
+			}
+			if resp.Success {
+				t.Error("response reports success")
+			}
+			if tt.wantError != "" && resp.Error != tt.wantError {
+				t.Errorf("error = %q, want %q", resp.Error, tt.wantError)
+			}
+		})
+	}
+}
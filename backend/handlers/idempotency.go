@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"cinema-booking-system/models"
+	"cinema-booking-system/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	IdempotencyKeyHeader      = "Idempotency-Key"
+	IdempotentReplayedHeader  = "Idempotent-Replayed"
+	maxIdempotencyKeyLength   = 255
+	maxIdempotentRequestBytes = 1 << 20
+)
+
+// recordingWriter keeps a copy of the response body so it can be stored for
+// replay.
+type recordingWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *recordingWriter) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *recordingWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// idempotencyUser is who a request is for: the X-User-ID header or
+// ?userId, falling back to the userId field of the JSON body.
+func idempotencyUser(c *gin.Context, body []byte) string {
+	if userID := requestUserID(c); userID != "" {
+		return userID
+	}
+	var req struct {
+		UserID string `json:"userId"`
+	}
+	json.Unmarshal(body, &req)
+	return req.UserID
+}
+
+// Idempotency honours the Idempotency-Key header. The first response to a
+// key is stored and replayed for identical retries; reusing the key for a
+// different request is rejected with 422. Keys are scoped to the user,
+// method and path, and bodies over 1 MiB are rejected with 413. Requests without the header, and
+// all requests while Redis is down, run normally. 5xx responses are not
+// stored, so they can be retried with the same key.
+func Idempotency(store *services.IdempotencyStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			c.AbortWithStatusJSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Error:   IdempotencyKeyHeader + " must be at most 255 characters",
+			})
+			return
+		}
+
+		// One byte over the limit is enough to tell the body is too large.
+		body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxIdempotentRequestBytes+1))
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Error:   "Failed to read request body",
+			})
+			return
+		}
+		if len(body) > maxIdempotentRequestBytes {
+			c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, models.APIResponse{
+				Success: false,
+				Error:   "Request body must be at most 1 MiB",
+			})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		fingerprint := services.RequestFingerprint(c.Request.Method, c.Request.URL.Path, body)
+		key = services.ScopedIdempotencyKey(idempotencyUser(c, body), c.Request.Method, c.Request.URL.Path, key)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		stored, err := store.Begin(ctx, key, fingerprint)
+		switch {
+		case errors.Is(err, services.ErrIdempotencyKeyReused):
+			c.AbortWithStatusJSON(http.StatusUnprocessableEntity, models.APIResponse{
+				Success: false,
+				Error:   "Idempotency-Key was already used with a different request",
+			})
+			return
+		case errors.Is(err, services.ErrIdempotencyInProgress):
+			c.Header("Retry-After", "1")
+			c.AbortWithStatusJSON(http.StatusConflict, models.APIResponse{
+				Success: false,
+				Error:   "A request with this Idempotency-Key is still being processed",
+			})
+			return
+		case err != nil:
+			log.Printf("⚠️ Idempotency store unavailable, processing %s without it: %v", c.Request.URL.Path, err)
+			c.Next()
+			return
+		case stored != nil:
+			c.Header(IdempotentReplayedHeader, "true")
+			c.Data(stored.Status, stored.ContentType, stored.Body)
+			c.Abort()
+			return
+		}
+
+		w := &recordingWriter{ResponseWriter: c.Writer}
+		c.Writer = w
+		c.Next()
+
+		saveCtx, saveCancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer saveCancel()
+
+		status := w.Status()
+		if status >= http.StatusInternalServerError {
+			if err := store.Abandon(saveCtx, key); err != nil {
+				log.Printf("⚠️ Failed to release idempotency key %s: %v", key, err)
+			}
+			return
+		}
+		if err := store.Complete(saveCtx, key, fingerprint, status, w.Header().Get("Content-Type"), w.body.Bytes()); err != nil {
+			log.Printf("⚠️ Failed to store idempotent response for key %s: %v", key, err)
+		}
+	}
+}
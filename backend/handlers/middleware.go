@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"cinema-booking-system/config"
+	"cinema-booking-system/events"
+	"cinema-booking-system/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const (
+	CorrelationIDHeader = "X-Correlation-ID"
+	correlationIDKey    = "correlationId"
+
+	UserEmailHeader = "X-User-Email"
+	callerEmailKey  = "callerEmail"
+)
+
+func CorrelationID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(CorrelationIDHeader)
+		if id == "" || len(id) > 128 {
+			id = events.NewID()
+		}
+
+		c.Set(correlationIDKey, id)
+		c.Header(CorrelationIDHeader, id)
+		c.Next()
+	}
+}
+
+func eventContext(c *gin.Context) context.Context {
+	return events.WithCorrelationID(context.Background(), c.GetString(correlationIDKey))
+}
+
+// RequireRole only lets through callers whose X-User-Email belongs to a user
+// with one of the given roles. Admins are always allowed.
+func RequireRole(roles ...models.UserRole) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		email := c.GetHeader(UserEmailHeader)
+		if email == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, models.APIResponse{
+				Success: false,
+				Error:   UserEmailHeader + " header is required",
+			})
+			return
+		}
+		if config.MongoDB == nil {
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, models.APIResponse{
+				Success: false,
+				Error:   "Database not available",
+			})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		var user models.User
+		if err := config.MongoDB.Collection("users").FindOne(ctx, bson.M{"email": email}).Decode(&user); err != nil {
+			c.AbortWithStatusJSON(http.StatusForbidden, models.APIResponse{
+				Success: false,
+				Error:   "Access denied",
+			})
+			return
+		}
+
+		allowed := user.Role == models.RoleAdmin
+		for _, r := range roles {
+			allowed = allowed || user.Role == r
+		}
+		if !allowed {
+			c.AbortWithStatusJSON(http.StatusForbidden, models.APIResponse{
+				Success: false,
+				Error:   "Access denied",
+			})
+			return
+		}
+
+		c.Set(callerEmailKey, user.Email)
+		c.Next()
+	}
+}
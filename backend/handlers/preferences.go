@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"cinema-booking-system/config"
+	"cinema-booking-system/models"
+	"cinema-booking-system/services"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func (h *AuthHandler) GetNotificationPreferences(c *gin.Context) {
+	oid, err := primitive.ObjectIDFromHex(c.Param("userId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "Invalid user ID",
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var user models.User
+	err = config.MongoDB.Collection("users").FindOne(ctx, bson.M{"_id": oid}).Decode(&user)
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Error:   "User not found",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Error:   "Database error",
+		})
+		return
+	}
+
+	prefs := models.DefaultNotificationPreferences()
+	if user.NotificationPreferences != nil {
+		prefs = *user.NotificationPreferences
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data: gin.H{
+			"preferences":      prefs,
+			"availableOffsets": reminderOffsetLabels(),
+		},
+	})
+}
+
+func (h *AuthHandler) UpdateNotificationPreferences(c *gin.Context) {
+	oid, err := primitive.ObjectIDFromHex(c.Param("userId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "Invalid user ID",
+		})
+		return
+	}
+
+	var req struct {
+		EmailReminders  *bool    `json:"emailReminders" binding:"required"`
+		ReminderOffsets []string `json:"reminderOffsets"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "Invalid request: " + err.Error(),
+		})
+		return
+	}
+
+	available := reminderOffsetLabels()
+	for _, o := range req.ReminderOffsets {
+		if !containsLabel(available, o) {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Error:   "Unknown reminder offset " + o,
+			})
+			return
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	prefs := models.NotificationPreferences{
+		EmailReminders:  *req.EmailReminders,
+		ReminderOffsets: req.ReminderOffsets,
+		UpdatedAt:       time.Now().UTC(),
+	}
+	result, err := config.MongoDB.Collection("users").UpdateOne(ctx,
+		bson.M{"_id": oid},
+		bson.M{"$set": bson.M{"notificationPreferences": prefs, "updatedAt": prefs.UpdatedAt}},
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Error:   "Failed to update preferences",
+		})
+		return
+	}
+	if result.MatchedCount == 0 {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Error:   "User not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Notification preferences updated",
+		Data:    prefs,
+	})
+}
+
+func reminderOffsetLabels() []string {
+	labels := []string{}
+	for _, o := range config.AppConfig.ReminderOffsets {
+		labels = append(labels, services.ReminderOffsetLabel(o))
+	}
+	return labels
+}
+
+func containsLabel(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,186 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"cinema-booking-system/models"
+	"cinema-booking-system/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RunReconciliation scans for drift between locks, seats and bookings. It is
+// a dry run unless the body says {"dryRun": false}, in which case the safe
+// repairs are applied too. The report is stored either way, so its other
+// repairs can be approved later.
+func (h *AdminHandler) RunReconciliation(c *gin.Context) {
+	if h.reconciler == nil {
+		c.JSON(http.StatusServiceUnavailable, models.APIResponse{
+			Success: false,
+			Error:   "Reconciler is not configured",
+		})
+		return
+	}
+
+	req := struct {
+		DryRun *bool `json:"dryRun"`
+	}{}
+	if err := c.ShouldBindJSON(&req); err != nil && c.Request.ContentLength > 0 {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "Invalid request: " + err.Error(),
+		})
+		return
+	}
+	dryRun := req.DryRun == nil || *req.DryRun
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	report, err := h.reconciler.Run(ctx, services.ReconcileTriggerAdmin, dryRun)
+	if errors.Is(err, services.ErrReconcileRunning) {
+		c.JSON(http.StatusConflict, models.APIResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+	if err != nil && report == nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Error:   "Reconciliation failed: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: err == nil,
+		Data:    report,
+		Error:   report.Error,
+	})
+}
+
+func (h *AdminHandler) GetReconciliationReports(c *gin.Context) {
+	if h.reconciler == nil {
+		c.JSON(http.StatusServiceUnavailable, models.APIResponse{
+			Success: false,
+			Error:   "Reconciler is not configured",
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	reports, err := h.reconciler.RecentReports(ctx, 20)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Error:   "Failed to fetch reconciliation reports",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    reports,
+	})
+}
+
+func (h *AdminHandler) GetReconciliationReport(c *gin.Context) {
+	if h.reconciler == nil {
+		c.JSON(http.StatusServiceUnavailable, models.APIResponse{
+			Success: false,
+			Error:   "Reconciler is not configured",
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	report, err := h.reconciler.GetReport(ctx, c.Param("id"))
+	if errors.Is(err, services.ErrReportNotFound) {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Error:   "Report not found",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Error:   "Failed to fetch reconciliation report",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    report,
+	})
+}
+
+// ApproveReconciliationRepairs applies the repairs an admin picked from a
+// report. It is mounted behind RequireRole, which records the approver.
+func (h *AdminHandler) ApproveReconciliationRepairs(c *gin.Context) {
+	if h.reconciler == nil {
+		c.JSON(http.StatusServiceUnavailable, models.APIResponse{
+			Success: false,
+			Error:   "Reconciler is not configured",
+		})
+		return
+	}
+
+	var req struct {
+		DiscrepancyIDs []string `json:"discrepancyIds" binding:"required,min=1"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "Invalid request: " + err.Error(),
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	results, err := h.reconciler.ApproveRepairs(ctx, c.Param("id"), req.DiscrepancyIDs, c.GetString(callerEmailKey))
+	if errors.Is(err, services.ErrReportNotFound) {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Error:   "Report not found",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Error:   "Failed to apply repairs",
+		})
+		return
+	}
+
+	repaired := 0
+	failed := make(map[string]string)
+	for id, msg := range results {
+		if msg == "" {
+			repaired++
+		} else {
+			failed[id] = msg
+		}
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: len(failed) == 0,
+		Message: "Repairs applied",
+		Data: gin.H{
+			"repaired": repaired,
+			"failed":   failed,
+		},
+	})
+}
@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"cinema-booking-system/config"
+	"cinema-booking-system/models"
+	"cinema-booking-system/services"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// UpdateSeatRules turns the single-seat gap rule on or off for one session.
+// Sending null drops the override, so the theater's setting applies again.
+func (h *AdminHandler) UpdateSeatRules(c *gin.Context) {
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "Invalid session ID",
+		})
+		return
+	}
+
+	var req struct {
+		NoSingleSeatGap *bool `json:"noSingleSeatGap"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "Invalid request: " + err.Error(),
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	update := bson.M{"$set": bson.M{"updatedAt": time.Now().UTC()}}
+	if req.NoSingleSeatGap == nil {
+		update["$unset"] = bson.M{"noSingleSeatGap": ""}
+	} else {
+		update["$set"].(bson.M)["noSingleSeatGap"] = *req.NoSingleSeatGap
+	}
+
+	var session models.MovieSession
+	err = config.MongoDB.Collection("sessions").FindOneAndUpdate(ctx, bson.M{"_id": objectID}, update,
+		options.FindOneAndUpdate().
+			SetReturnDocument(options.After).
+			SetProjection(bson.M{"theater": 1, "noSingleSeatGap": 1}),
+	).Decode(&session)
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Error:   "Session not found",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Error:   "Failed to update seat rules",
+		})
+		return
+	}
+	services.InvalidateSeatMap(ctx, session.ID.Hex())
+
+	applies := services.SingleSeatGapRuleApplies(&session)
+	log.Printf("🪑 Single-seat gap rule for session %s is now %v (override %v) by %s", session.ID.Hex(), applies, req.NoSingleSeatGap != nil, c.GetString(callerEmailKey))
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Seat rules updated",
+		Data: gin.H{
+			"sessionId":       session.ID.Hex(),
+			"theater":         session.Theater,
+			"noSingleSeatGap": session.NoSingleSeatGap,
+			"ruleApplies":     applies,
+		},
+	})
+}
@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"cinema-booking-system/models"
+	"cinema-booking-system/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CancelSession voids a whole session. The session goes off sale at once;
+// refunds, emails and booking cancellations run in the background, and the
+// returned job reports their progress. It is mounted behind RequireRole, which
+// records who asked.
+func (h *AdminHandler) CancelSession(c *gin.Context) {
+	if h.sessionCancellations == nil {
+		c.JSON(http.StatusServiceUnavailable, models.APIResponse{
+			Success: false,
+			Error:   "Session cancellation is not configured",
+		})
+		return
+	}
+
+	var req struct {
+		Reason string `json:"reason" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "Invalid request: " + err.Error(),
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(eventContext(c), 10*time.Second)
+	defer cancel()
+
+	job, err := h.sessionCancellations.Cancel(ctx, c.Param("id"), req.Reason, c.GetString(callerEmailKey))
+	switch {
+	case errors.Is(err, services.ErrSessionNotFound):
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Error:   "Session not found",
+		})
+		return
+	case errors.Is(err, services.ErrSessionFinished):
+		c.JSON(http.StatusConflict, models.APIResponse{
+			Success: false,
+			Error:   "Session has already finished",
+		})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Error:   "Failed to cancel session",
+		})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, models.APIResponse{
+		Success: true,
+		Message: "Session cancelled, refunds are being processed",
+		Data:    job,
+	})
+}
+
+func (h *AdminHandler) GetSessionCancellations(c *gin.Context) {
+	if h.sessionCancellations == nil {
+		c.JSON(http.StatusServiceUnavailable, models.APIResponse{
+			Success: false,
+			Error:   "Session cancellation is not configured",
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	jobs, err := h.sessionCancellations.Recent(ctx, 20)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Error:   "Failed to fetch session cancellations",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    jobs,
+	})
+}
+
+func (h *AdminHandler) GetSessionCancellation(c *gin.Context) {
+	if h.sessionCancellations == nil {
+		c.JSON(http.StatusServiceUnavailable, models.APIResponse{
+			Success: false,
+			Error:   "Session cancellation is not configured",
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	job, err := h.sessionCancellations.Get(ctx, c.Param("id"))
+	if errors.Is(err, services.ErrSessionCancellationNotFound) {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Error:   "Session cancellation not found",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Error:   "Failed to fetch session cancellation",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    job,
+	})
+}
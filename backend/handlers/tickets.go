@@ -0,0 +1,221 @@
+package handlers
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"cinema-booking-system/config"
+	"cinema-booking-system/models"
+	"cinema-booking-system/services"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// loadTicketBooking loads the booking behind a ticket link. Access needs
+// either the signed token from the confirmation email or the userId that made
+// the booking.
+func loadTicketBooking(ctx context.Context, c *gin.Context) (models.Booking, bool) {
+	var booking models.Booking
+
+	bookingID := c.Param("id")
+	oid, err := primitive.ObjectIDFromHex(bookingID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "Invalid booking ID",
+		})
+		return booking, false
+	}
+
+	if err := config.MongoDB.Collection("bookings").FindOne(ctx, bson.M{"_id": oid}).Decode(&booking); err != nil {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Error:   "Booking not found",
+		})
+		return booking, false
+	}
+
+	tokenOK := services.VerifyToken(services.TokenPurposeBookingTickets, bookingID, c.Query("token"))
+	if !tokenOK && (c.Query("userId") == "" || c.Query("userId") != booking.UserID) {
+		c.JSON(http.StatusForbidden, models.APIResponse{
+			Success: false,
+			Error:   "Invalid or missing token",
+		})
+		return booking, false
+	}
+	if booking.Status == models.BookingStatusCancelled {
+		c.JSON(http.StatusGone, models.APIResponse{
+			Success: false,
+			Error:   "Booking was cancelled",
+		})
+		return booking, false
+	}
+	return booking, true
+}
+
+// GetBookingTickets returns a booking's e-tickets. ?seat=A1&format=png
+// returns that seat's QR code as an image.
+func (h *Handler) GetBookingTickets(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	booking, ok := loadTicketBooking(ctx, c)
+	if !ok {
+		return
+	}
+	bookingID := booking.ID.Hex()
+
+	tickets := services.BookingTickets(booking)
+
+	if seat := c.Query("seat"); seat != "" {
+		for _, t := range tickets {
+			if t.SeatID != seat {
+				continue
+			}
+			png, err := services.TicketQRCode(t.Token, services.TicketQRSize)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, models.APIResponse{
+					Success: false,
+					Error:   "Failed to render ticket",
+				})
+				return
+			}
+			if c.Query("format") == "png" {
+				c.Header("Content-Disposition", `inline; filename="ticket-`+seat+`.png"`)
+				c.Data(http.StatusOK, "image/png", png)
+				return
+			}
+			tickets = []services.Ticket{t}
+			break
+		}
+		if len(tickets) != 1 || tickets[0].SeatID != seat {
+			c.JSON(http.StatusNotFound, models.APIResponse{
+				Success: false,
+				Error:   "Seat " + seat + " is not part of this booking",
+			})
+			return
+		}
+	}
+
+	out := make([]gin.H, 0, len(tickets))
+	for _, t := range tickets {
+		png, err := services.TicketQRCode(t.Token, services.TicketQRSize)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.APIResponse{
+				Success: false,
+				Error:   "Failed to render ticket",
+			})
+			return
+		}
+		out = append(out, gin.H{
+			"seatId":     t.SeatID,
+			"token":      t.Token,
+			"admittedAt": t.AdmittedAt,
+			"qrCode":     "data:image/png;base64," + base64.StdEncoding.EncodeToString(png),
+		})
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data: gin.H{
+			"bookingId": bookingID,
+			"sessionId": booking.SessionID.Hex(),
+			"tickets":   out,
+		},
+	})
+}
+
+// GetBookingTicketPDF serves a printable ticket with the same QR codes as
+// the e-tickets.
+func (h *Handler) GetBookingTicketPDF(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	booking, ok := loadTicketBooking(ctx, c)
+	if !ok {
+		return
+	}
+
+	var session models.MovieSession
+	if err := config.MongoDB.Collection("sessions").FindOne(ctx, bson.M{"_id": booking.SessionID}).Decode(&session); err != nil {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Error:   "Session not found",
+		})
+		return
+	}
+
+	pdf, err := services.RenderTicketPDF(booking, session)
+	if err != nil {
+		log.Printf("❌ Failed to render ticket PDF for booking %s: %v", booking.ID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Error:   "Failed to render ticket",
+		})
+		return
+	}
+
+	disposition := "attachment"
+	if c.Query("inline") == "true" {
+		disposition = "inline"
+	}
+	c.Header("Content-Disposition", disposition+`; filename="ticket-`+booking.ID.Hex()+`.pdf"`)
+	c.Data(http.StatusOK, services.TicketPDFContentType, pdf)
+}
+
+// CheckIn admits the seat behind a scanned ticket. It is mounted behind
+// RequireRole, which records the scanning staff member.
+func (h *Handler) CheckIn(c *gin.Context) {
+	var req struct {
+		Token     string `json:"token" binding:"required"`
+		SessionID string `json:"sessionId"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "Invalid request: " + err.Error(),
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(eventContext(c), 10*time.Second)
+	defer cancel()
+
+	result, err := h.tickets.CheckIn(ctx, req.Token, req.SessionID, c.GetString(callerEmailKey))
+	if err != nil {
+		var checkInErr *services.CheckInError
+		if !errors.As(err, &checkInErr) {
+			c.JSON(http.StatusInternalServerError, models.APIResponse{
+				Success: false,
+				Error:   "Failed to check in ticket",
+			})
+			return
+		}
+
+		status := http.StatusUnprocessableEntity
+		switch checkInErr.Reason {
+		case services.CheckInAlreadyAdmitted:
+			status = http.StatusConflict
+		case services.CheckInBookingNotFound, services.CheckInSessionNotFound:
+			status = http.StatusNotFound
+		}
+		c.JSON(status, models.APIResponse{
+			Success: false,
+			Error:   checkInErr.Message,
+			Data:    checkInErr,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Seat " + result.SeatID + " admitted",
+		Data:    result,
+	})
+}
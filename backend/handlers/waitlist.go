@@ -0,0 +1,198 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"cinema-booking-system/models"
+	"cinema-booking-system/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// JoinWaitlist puts a customer on the waitlist of a sold-out session. Joining
+// again returns the entry they already have.
+func (h *Handler) JoinWaitlist(c *gin.Context) {
+	if h.waitlist == nil {
+		respondWaitlistUnavailable(c)
+		return
+	}
+
+	var req models.JoinWaitlistRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "Invalid request: " + err.Error(),
+		})
+		return
+	}
+	userID := requestUserID(c)
+	if userID == "" {
+		userID = req.UserID
+	}
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Error:   "User ID is required",
+		})
+		return
+	}
+	req.Locale = services.ResolveLocale(req.Locale + "," + c.GetHeader("Accept-Language")).Tag
+
+	ctx, cancel := context.WithTimeout(eventContext(c), 10*time.Second)
+	defer cancel()
+
+	entry, created, err := h.waitlist.Join(ctx, c.Param("id"), userID, req)
+	if err != nil {
+		var notOnSale *services.SessionNotOnSaleError
+		var available *services.SeatsAvailableError
+		switch {
+		case errors.Is(err, services.ErrSessionNotFound):
+			c.JSON(http.StatusNotFound, models.APIResponse{
+				Success: false,
+				Error:   "Session not found",
+			})
+		case errors.Is(err, services.ErrInvalidPartySize):
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Error:   err.Error(),
+			})
+		case errors.As(err, &notOnSale):
+			respondNotOnSale(c, notOnSale)
+		case errors.As(err, &available):
+			c.JSON(http.StatusConflict, models.APIResponse{
+				Success: false,
+				Error:   available.Error(),
+				Data:    gin.H{"code": "SEATS_AVAILABLE", "available": available.Available},
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, models.APIResponse{
+				Success: false,
+				Error:   "Failed to join waitlist",
+			})
+		}
+		return
+	}
+
+	if !created {
+		c.JSON(http.StatusOK, models.APIResponse{
+			Success: true,
+			Message: "Already on the waitlist",
+			Data:    entry,
+		})
+		return
+	}
+	c.JSON(http.StatusCreated, models.APIResponse{
+		Success: true,
+		Message: "Joined the waitlist",
+		Data:    entry,
+	})
+}
+
+// GetWaitlistEntry returns the caller's place on a session's waitlist, or
+// the seats held for them.
+func (h *Handler) GetWaitlistEntry(c *gin.Context) {
+	if h.waitlist == nil {
+		respondWaitlistUnavailable(c)
+		return
+	}
+	userID := requestUserID(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Error:   "User ID is required",
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	entry, err := h.waitlist.Get(ctx, c.Param("id"), userID)
+	if err != nil {
+		respondWaitlistEntryError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    entry,
+	})
+}
+
+// LeaveWaitlist takes the caller off a session's waitlist. Seats held for
+// them go to the next customer.
+func (h *Handler) LeaveWaitlist(c *gin.Context) {
+	if h.waitlist == nil {
+		respondWaitlistUnavailable(c)
+		return
+	}
+	userID := requestUserID(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Error:   "User ID is required",
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(eventContext(c), 10*time.Second)
+	defer cancel()
+
+	entry, err := h.waitlist.Leave(ctx, c.Param("id"), userID)
+	if err != nil {
+		respondWaitlistEntryError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Left the waitlist",
+		Data:    entry,
+	})
+}
+
+// GetWaitlistDepth lists every session with customers on its waitlist.
+func (h *AdminHandler) GetWaitlistDepth(c *gin.Context) {
+	if h.waitlist == nil {
+		respondWaitlistUnavailable(c)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	depths, err := h.waitlist.Depth(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Error:   "Failed to fetch waitlists",
+		})
+		return
+	}
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    depths,
+	})
+}
+
+func respondWaitlistEntryError(c *gin.Context, err error) {
+	if errors.Is(err, services.ErrWaitlistEntryNotFound) {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Error:   "You are not on the waitlist for this session",
+		})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, models.APIResponse{
+		Success: false,
+		Error:   "Failed to fetch waitlist entry",
+	})
+}
+
+func respondWaitlistUnavailable(c *gin.Context) {
+	c.JSON(http.StatusServiceUnavailable, models.APIResponse{
+		Success: false,
+		Error:   "Waitlist is not available",
+	})
+}
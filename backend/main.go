@@ -4,9 +4,11 @@ import (
 	"context"
 	"log"
 	"net/http"
+	"os"
 
 	"cinema-booking-system/config"
 	"cinema-booking-system/handlers"
+	"cinema-booking-system/models"
 	"cinema-booking-system/services"
 	"cinema-booking-system/websocket"
 
@@ -15,6 +17,10 @@ import (
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "replay" {
+		os.Exit(runReplay(os.Args[2:]))
+	}
+
 	cfg := config.LoadConfig()
 	log.Printf("🎬 Starting Cinema Booking System on port %s", cfg.Port)
 
@@ -24,30 +30,81 @@ func main() {
 	}
 
 	config.InitRedis(cfg.RedisHost, cfg.RedisPort)
-	config.InitKafka(cfg.KafkaBroker, cfg.KafkaTopic)
+	bus := config.InitEventBus(cfg.EventBus, cfg.KafkaBroker)
 	defer config.CloseConnections()
 
 	wsHub := websocket.NewHub()
 	go wsHub.Run()
 
-	lockMonitor := services.NewLockExpiryMonitor(wsHub)
+	auditStore := services.NewDefaultAuditLogStore()
+	if config.MongoDB != nil {
+		if err := auditStore.EnsureIndexes(context.Background()); err != nil {
+			log.Printf("⚠️ Failed to create audit log indexes: %v", err)
+		}
+	}
+
+	auditConsumer := services.NewAuditLogConsumerService(bus, cfg.KafkaTopic, "audit-log-consumer", auditStore)
+	go auditConsumer.Start(context.Background())
+
+	var archiver *services.AuditArchiver
+	if config.MongoDB != nil {
+		if store, err := services.NewArchiveStore(cfg); err != nil {
+			log.Printf("⚠️ Audit archiver disabled: %v", err)
+		} else {
+			archiver = services.NewAuditArchiver(store, cfg.AuditRetention, cfg.AuditArchiveInterval)
+			go archiver.Start(context.Background())
+		}
+	}
+
+	emailService := services.NewEmailService()
+	emailOutbox := services.NewEmailOutboxService(emailService)
+	go emailOutbox.Start(context.Background())
+
+	payments, err := services.NewPaymentGatewayFromEnv()
+	if err != nil {
+		log.Printf("⚠️ Payments disabled, session cancellation and paid exchanges are unavailable: %v", err)
+	}
+
+	var reconciler *services.Reconciler
+	var sessionCancellations *services.SessionCancellationService
+	var waitlist *services.WaitlistService
+	if config.MongoDB != nil {
+		reminders := services.NewReminderScheduler(emailOutbox, services.NewEventProducerService(), cfg.ReminderOffsets, cfg.ReminderInterval)
+		go reminders.Start(context.Background())
+
+		lifecycle := services.NewSessionLifecycle(services.NewEventProducerService(), wsHub, cfg.LifecycleInterval, cfg.SalesCutoff)
+		go lifecycle.Start(context.Background())
+
+		waitlist = services.NewWaitlistService(emailOutbox, services.NewEventProducerService(), wsHub, cfg.WaitlistHold)
+		go waitlist.Start(context.Background())
+
+		reconciler = services.NewReconciler(services.NewEventProducerService(), cfg.ReconcileInterval, cfg.ReconcileRepair)
+		go reconciler.Start(context.Background())
+
+		if payments != nil {
+			sessionCancellations = services.NewSessionCancellationService(payments, emailOutbox, services.NewEventProducerService(), wsHub)
+			go sessionCancellations.Start(context.Background())
+		}
+	}
+
+	lockMonitor := services.NewLockExpiryMonitor(wsHub, waitlist)
 	go lockMonitor.Start(context.Background())
 
-	kafkaConsumer := services.NewKafkaConsumerService(cfg.KafkaBroker, cfg.KafkaTopic, "audit-log-consumer")
-	go kafkaConsumer.Start(context.Background())
+	h := handlers.NewHandler(wsHub, emailOutbox, payments, waitlist)
+	adminHandler := handlers.NewAdminHandler(archiver, emailOutbox, reconciler, sessionCancellations, waitlist)
 
-	h := handlers.NewHandler(wsHub)
-	adminHandler := handlers.NewAdminHandler()
+	idempotent := handlers.Idempotency(services.NewIdempotencyStore(cfg.IdempotencyTTL))
 
 	router := gin.Default()
 
 	router.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"http://localhost:5173", "http://localhost:3000", "*"},
 		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", handlers.CorrelationIDHeader, handlers.UserEmailHeader, handlers.UserIDHeader, handlers.IdempotencyKeyHeader},
+		ExposeHeaders:    []string{"Content-Length", handlers.CorrelationIDHeader, handlers.IdempotentReplayedHeader},
 		AllowCredentials: true,
 	}))
+	router.Use(handlers.CorrelationID())
 
 	router.GET("/health", h.HealthCheck)
 
@@ -57,22 +114,64 @@ func main() {
 		api.GET("/sessions/:id", h.GetSession)
 		api.POST("/sessions/demo", h.CreateDemoSession)
 
-		api.POST("/seats/lock", h.LockSeats)
+		api.POST("/seats/lock", idempotent, h.LockSeats)
+		api.POST("/sessions/:id/best-available", idempotent, h.BestAvailable)
 		api.POST("/seats/unlock", h.UnlockSeats)
 
-		api.POST("/bookings", h.CreateBooking)
+		api.POST("/sessions/:id/waitlist", idempotent, h.JoinWaitlist)
+		api.GET("/sessions/:id/waitlist", h.GetWaitlistEntry)
+		api.DELETE("/sessions/:id/waitlist", h.LeaveWaitlist)
+
+		api.POST("/bookings", idempotent, h.CreateBooking)
+		api.GET("/me/bookings", h.GetMyBookings)
+		api.GET("/bookings/:id", h.GetBooking)
+		api.POST("/bookings/:id/cancel", idempotent, h.CancelBooking)
+		api.POST("/bookings/:id/exchange", idempotent, h.ExchangeBooking)
+		api.GET("/bookings/:id/calendar.ics", h.GetBookingCalendar)
+		api.GET("/users/:userId/calendar.ics", h.GetUserCalendarFeed)
+		api.GET("/bookings/:id/tickets", h.GetBookingTickets)
+		api.GET("/bookings/:id/ticket.pdf", h.GetBookingTicketPDF)
+		api.POST("/checkin", handlers.RequireRole(models.RoleStaff), h.CheckIn)
 
 		authHandler := handlers.NewAuthHandler()
 		api.POST("/auth/login", authHandler.Login)
 		api.GET("/auth/role", authHandler.GetUserRole)
 		api.POST("/auth/role", authHandler.SetUserRole)
+		api.GET("/users/:userId/notification-preferences", authHandler.GetNotificationPreferences)
+		api.PUT("/users/:userId/notification-preferences", authHandler.UpdateNotificationPreferences)
 	}
 
 	admin := router.Group("/api/admin")
 	{
 		admin.GET("/bookings", adminHandler.GetBookings)
 		admin.GET("/bookings/stats", adminHandler.GetBookingStats)
+		admin.GET("/emails", handlers.RequireRole(), adminHandler.GetEmails)
+		admin.POST("/emails/:id/resend", handlers.RequireRole(), adminHandler.ResendEmail)
 		admin.GET("/audit-logs", adminHandler.GetAuditLogs)
+		admin.GET("/audit-logs/export", adminHandler.ExportAuditLogs)
+		admin.GET("/audit-logs/verify", adminHandler.VerifyAuditChain)
+		admin.GET("/audit-logs/retention", adminHandler.GetRetentionStatus)
+		admin.POST("/audit-logs/retention/archive", handlers.RequireRole(), adminHandler.TriggerArchive)
+		admin.POST("/reconciliation/run", handlers.RequireRole(), adminHandler.RunReconciliation)
+		admin.GET("/reconciliation/reports", adminHandler.GetReconciliationReports)
+		admin.GET("/reconciliation/reports/:id", adminHandler.GetReconciliationReport)
+		admin.POST("/reconciliation/reports/:id/repairs", handlers.RequireRole(), adminHandler.ApproveReconciliationRepairs)
+		admin.POST("/sessions/:id/cancel", handlers.RequireRole(), adminHandler.CancelSession)
+		admin.PUT("/sessions/:id/seat-rules", handlers.RequireRole(), adminHandler.UpdateSeatRules)
+		admin.GET("/session-cancellations", adminHandler.GetSessionCancellations)
+		admin.GET("/session-cancellations/:id", adminHandler.GetSessionCancellation)
+		admin.GET("/waitlists", adminHandler.GetWaitlistDepth)
+	}
+
+	if mailbox, ok := emailService.Mailer().(*services.MemoryMailer); ok {
+		devHandler := handlers.NewDevHandler(mailbox)
+		dev := router.Group("/api/dev")
+		{
+			dev.GET("/emails", devHandler.GetEmails)
+			dev.GET("/emails/:id", devHandler.GetEmail)
+			dev.DELETE("/emails", devHandler.ClearEmails)
+		}
+		log.Printf("Dev mailbox: http://localhost:%s/api/dev/emails", cfg.Port)
 	}
 
 	router.GET("/ws", func(c *gin.Context) {
@@ -0,0 +1,54 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type EmailStatus string
+
+const (
+	EmailQueued  EmailStatus = "QUEUED"
+	EmailSending EmailStatus = "SENDING"
+	EmailSent    EmailStatus = "SENT"
+	EmailFailed  EmailStatus = "FAILED"
+	EmailBounced EmailStatus = "BOUNCED"
+)
+
+const (
+	EmailKindBookingConfirmation = "booking_confirmation"
+	EmailKindBookingCancellation = "booking_cancellation"
+	EmailKindBookingReminder     = "booking_reminder"
+	EmailKindRefund              = "refund"
+	EmailKindWaitlistOffer       = "waitlist_offer"
+)
+
+type EmailMessage struct {
+	ID            primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	Kind          string             `json:"kind" bson:"kind"`
+	To            string             `json:"to" bson:"to"`
+	Subject       string             `json:"subject" bson:"subject"`
+	Locale        string             `json:"locale,omitempty" bson:"locale,omitempty"`
+	HTMLBody      string             `json:"-" bson:"htmlBody"`
+	TextBody      string             `json:"-" bson:"textBody,omitempty"`
+	Attachments   []EmailAttachment  `json:"attachments,omitempty" bson:"attachments,omitempty"`
+	BookingID     string             `json:"bookingId,omitempty" bson:"bookingId,omitempty"`
+	DedupeKey     string             `json:"dedupeKey,omitempty" bson:"dedupeKey,omitempty"`
+	Status        EmailStatus        `json:"status" bson:"status"`
+	Attempts      int                `json:"attempts" bson:"attempts"`
+	MaxAttempts   int                `json:"maxAttempts" bson:"maxAttempts"`
+	NextAttemptAt time.Time          `json:"nextAttemptAt" bson:"nextAttemptAt"`
+	LockedUntil   *time.Time         `json:"-" bson:"lockedUntil,omitempty"`
+	LastError     string             `json:"lastError,omitempty" bson:"lastError,omitempty"`
+	CreatedAt     time.Time          `json:"createdAt" bson:"createdAt"`
+	UpdatedAt     time.Time          `json:"updatedAt" bson:"updatedAt"`
+	SentAt        *time.Time         `json:"sentAt,omitempty" bson:"sentAt,omitempty"`
+}
+
+type EmailAttachment struct {
+	Filename    string `json:"filename" bson:"filename"`
+	ContentType string `json:"contentType" bson:"contentType"`
+	ContentID   string `json:"contentId,omitempty" bson:"contentId,omitempty"`
+	Data        []byte `json:"-" bson:"data"`
+}
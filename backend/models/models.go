@@ -12,6 +12,15 @@ const (
 	SeatAvailable SeatStatus = "AVAILABLE"
 	SeatLocked    SeatStatus = "LOCKED"
 	SeatBooked    SeatStatus = "BOOKED"
+	// SeatBlocked is a seat taken out of sale, e.g. broken or reserved for
+	// wheelchair access. It cannot be locked or booked.
+	SeatBlocked SeatStatus = "BLOCKED"
+)
+
+// Seat categories. Seats stored without one are STANDARD.
+const (
+	SeatCategoryStandard = "STANDARD"
+	SeatCategoryPremium  = "PREMIUM"
 )
 
 type Seat struct {
@@ -22,8 +31,22 @@ type Seat struct {
 	LockedBy string     `json:"lockedBy,omitempty" bson:"lockedBy,omitempty"`
 	LockedAt *time.Time `json:"lockedAt,omitempty" bson:"lockedAt,omitempty"`
 	Price    float64    `json:"price" bson:"price"`
+	Category string     `json:"category,omitempty" bson:"category,omitempty"`
 }
 
+// SessionStatus is where a session is in its lifecycle. Apart from
+// CANCELLED it follows from the session's times and the sales cutoff.
+type SessionStatus string
+
+const (
+	SessionScheduled   SessionStatus = "SCHEDULED"
+	SessionOnSale      SessionStatus = "ON_SALE"
+	SessionSalesClosed SessionStatus = "SALES_CLOSED"
+	SessionInProgress  SessionStatus = "IN_PROGRESS"
+	SessionFinished    SessionStatus = "FINISHED"
+	SessionCancelled   SessionStatus = "CANCELLED"
+)
+
 type MovieSession struct {
 	ID          primitive.ObjectID `json:"id" bson:"_id,omitempty"`
 	MovieTitle  string             `json:"movieTitle" bson:"movieTitle"`
@@ -33,31 +56,84 @@ type MovieSession struct {
 	EndTime     time.Time          `json:"endTime" bson:"endTime"`
 	Seats       []Seat             `json:"seats" bson:"seats"`
 	TotalSeats  int                `json:"totalSeats" bson:"totalSeats"`
-	CreatedAt   time.Time          `json:"createdAt" bson:"createdAt"`
-	UpdatedAt   time.Time          `json:"updatedAt" bson:"updatedAt"`
+	Version     int64              `json:"version" bson:"version"`
+	Status      SessionStatus      `json:"status" bson:"status,omitempty"`
+	// SalesOpenAt keeps a session SCHEDULED until then. Without it the
+	// session is on sale as soon as it is created.
+	SalesOpenAt     *time.Time `json:"salesOpenAt,omitempty" bson:"salesOpenAt,omitempty"`
+	SalesCloseAt    *time.Time `json:"salesCloseAt,omitempty" bson:"-"`
+	StatusChangedAt *time.Time `json:"statusChangedAt,omitempty" bson:"statusChangedAt,omitempty"`
+	CreatedAt       time.Time  `json:"createdAt" bson:"createdAt"`
+	UpdatedAt       time.Time  `json:"updatedAt" bson:"updatedAt"`
+	// NoSingleSeatGap overrides the theater's single-seat gap rule for this
+	// session when set.
+	NoSingleSeatGap *bool `json:"noSingleSeatGap,omitempty" bson:"noSingleSeatGap,omitempty"`
 }
 
+const (
+	BookingStatusConfirmed = "CONFIRMED"
+	BookingStatusCancelled = "CANCELLED"
+)
+
 type Booking struct {
-	ID          primitive.ObjectID `json:"id" bson:"_id,omitempty"`
-	SessionID   primitive.ObjectID `json:"sessionId" bson:"sessionId"`
-	UserID      string             `json:"userId" bson:"userId"`
-	UserEmail   string             `json:"userEmail" bson:"userEmail"`
-	Seats       []string           `json:"seats" bson:"seats"`
-	TotalAmount float64            `json:"totalAmount" bson:"totalAmount"`
-	Status      string             `json:"status" bson:"status"`
-	PaymentID   string             `json:"paymentId,omitempty" bson:"paymentId,omitempty"`
-	CreatedAt   time.Time          `json:"createdAt" bson:"createdAt"`
-	ConfirmedAt *time.Time         `json:"confirmedAt,omitempty" bson:"confirmedAt,omitempty"`
+	ID           primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	SessionID    primitive.ObjectID `json:"sessionId" bson:"sessionId"`
+	UserID       string             `json:"userId" bson:"userId"`
+	UserEmail    string             `json:"userEmail" bson:"userEmail"`
+	Seats        []string           `json:"seats" bson:"seats"`
+	TotalAmount  float64            `json:"totalAmount" bson:"totalAmount"`
+	Status       string             `json:"status" bson:"status"`
+	PaymentID    string             `json:"paymentId,omitempty" bson:"paymentId,omitempty"`
+	Locale       string             `json:"locale,omitempty" bson:"locale,omitempty"`
+	Admissions   []SeatAdmission    `json:"admissions,omitempty" bson:"admissions,omitempty"`
+	CreatedAt    time.Time          `json:"createdAt" bson:"createdAt"`
+	ConfirmedAt  *time.Time         `json:"confirmedAt,omitempty" bson:"confirmedAt,omitempty"`
+	CancelledAt  *time.Time         `json:"cancelledAt,omitempty" bson:"cancelledAt,omitempty"`
+	CancelReason string             `json:"cancelReason,omitempty" bson:"cancelReason,omitempty"`
+	Refund       *Refund            `json:"refund,omitempty" bson:"refund,omitempty"`
+	// Revision counts exchanges; Revisions keeps what the booking was
+	// before each of them, oldest first.
+	Revision  int               `json:"revision" bson:"revision,omitempty"`
+	Revisions []BookingRevision `json:"revisions,omitempty" bson:"revisions,omitempty"`
+}
+
+// BookingRevision is an earlier version of a booking, replaced by an
+// exchange at ReplacedAt. PriceDifference is what the exchange charged
+// (positive) or refunded (negative), under PaymentReference.
+type BookingRevision struct {
+	Revision         int                `json:"revision" bson:"revision"`
+	SessionID        primitive.ObjectID `json:"sessionId" bson:"sessionId"`
+	Seats            []string           `json:"seats" bson:"seats"`
+	TotalAmount      float64            `json:"totalAmount" bson:"totalAmount"`
+	ReplacedAt       time.Time          `json:"replacedAt" bson:"replacedAt"`
+	PriceDifference  float64            `json:"priceDifference" bson:"priceDifference"`
+	PaymentReference string             `json:"paymentReference,omitempty" bson:"paymentReference,omitempty"`
+}
+
+type SeatAdmission struct {
+	SeatID     string    `json:"seatId" bson:"seatId"`
+	AdmittedAt time.Time `json:"admittedAt" bson:"admittedAt"`
+	AdmittedBy string    `json:"admittedBy" bson:"admittedBy"`
 }
 
 type AuditLog struct {
-	ID          primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
-	EventType   string             `json:"eventType" bson:"eventType"`
-	SessionID   string             `json:"sessionId" bson:"sessionId"`
-	UserID      string             `json:"userId" bson:"userId"`
-	SeatIDs     []string           `json:"seatIds" bson:"seatIds"`
-	Timestamp   time.Time          `json:"timestamp" bson:"timestamp"`
-	Description string             `json:"description" bson:"description"`
+	ID            primitive.ObjectID     `json:"id,omitempty" bson:"_id,omitempty"`
+	EventID       string                 `json:"eventId,omitempty" bson:"eventId,omitempty"`
+	EventType     string                 `json:"eventType" bson:"eventType"`
+	SchemaVersion int                    `json:"schemaVersion,omitempty" bson:"schemaVersion,omitempty"`
+	SessionID     string                 `json:"sessionId" bson:"sessionId"`
+	UserID        string                 `json:"userId" bson:"userId"`
+	SeatIDs       []string               `json:"seatIds" bson:"seatIds"`
+	ActorType     string                 `json:"actorType,omitempty" bson:"actorType,omitempty"`
+	ActorID       string                 `json:"actorId,omitempty" bson:"actorId,omitempty"`
+	CorrelationID string                 `json:"correlationId,omitempty" bson:"correlationId,omitempty"`
+	Timestamp     time.Time              `json:"timestamp" bson:"timestamp"`
+	Description   string                 `json:"description" bson:"description"`
+	Payload       map[string]interface{} `json:"payload,omitempty" bson:"payload,omitempty"`
+	ChainID       string                 `json:"chainId,omitempty" bson:"chainId,omitempty"`
+	ChainSeq      int64                  `json:"chainSeq,omitempty" bson:"chainSeq,omitempty"`
+	PrevHash      string                 `json:"prevHash,omitempty" bson:"prevHash,omitempty"`
+	Hash          string                 `json:"hash,omitempty" bson:"hash,omitempty"`
 }
 
 type WSMessage struct {
@@ -72,6 +148,20 @@ type SeatUpdate struct {
 	LockedBy string     `json:"lockedBy,omitempty"`
 }
 
+type Refund struct {
+	Amount    float64   `json:"amount" bson:"amount"`
+	Reference string    `json:"reference" bson:"reference"`
+	Provider  string    `json:"provider" bson:"provider"`
+	IssuedAt  time.Time `json:"issuedAt" bson:"issuedAt"`
+}
+
+type SessionStatusUpdate struct {
+	Status         SessionStatus `json:"status"`
+	PreviousStatus SessionStatus `json:"previousStatus,omitempty"`
+	SalesCloseAt   *time.Time    `json:"salesCloseAt,omitempty"`
+	Reason         string        `json:"reason,omitempty"`
+}
+
 type LockSeatRequest struct {
 	SessionID string   `json:"sessionId" binding:"required"`
 	SeatIDs   []string `json:"seatIds" binding:"required"`
@@ -84,11 +174,27 @@ type UnlockSeatRequest struct {
 	UserID    string   `json:"userId" binding:"required"`
 }
 
+type ExchangeBookingRequest struct {
+	UserID    string   `json:"userId"`
+	SessionID string   `json:"sessionId"`
+	SeatIDs   []string `json:"seatIds" binding:"required"`
+}
+
+// BestAvailableRequest asks for the best block of PartySize adjacent seats.
+// Preferences are any of "center", "aisle" and "back".
+type BestAvailableRequest struct {
+	UserID      string   `json:"userId"`
+	PartySize   int      `json:"partySize" binding:"required"`
+	Category    string   `json:"category"`
+	Preferences []string `json:"preferences"`
+}
+
 type BookingRequest struct {
 	SessionID string   `json:"sessionId" binding:"required"`
 	SeatIDs   []string `json:"seatIds" binding:"required"`
 	UserID    string   `json:"userId" binding:"required"`
 	UserEmail string   `json:"userEmail" binding:"required"`
+	Locale    string   `json:"locale,omitempty"`
 }
 
 type APIResponse struct {
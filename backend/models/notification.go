@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type NotificationPreferences struct {
+	EmailReminders  bool      `json:"emailReminders" bson:"emailReminders"`
+	ReminderOffsets []string  `json:"reminderOffsets,omitempty" bson:"reminderOffsets,omitempty"`
+	UpdatedAt       time.Time `json:"updatedAt" bson:"updatedAt"`
+}
+
+func DefaultNotificationPreferences() NotificationPreferences {
+	return NotificationPreferences{EmailReminders: true}
+}
+
+type ReminderStatus string
+
+const (
+	ReminderPending ReminderStatus = "PENDING"
+	ReminderQueued  ReminderStatus = "QUEUED"
+	ReminderSkipped ReminderStatus = "SKIPPED"
+)
+
+// BookingReminder records that one reminder of a booking has been handled.
+// Its ID is "<bookingId>:<offset>", which makes every reminder a unique key.
+type BookingReminder struct {
+	ID        string             `json:"id" bson:"_id"`
+	BookingID primitive.ObjectID `json:"bookingId" bson:"bookingId"`
+	Offset    string             `json:"offset" bson:"offset"`
+	Status    ReminderStatus     `json:"status" bson:"status"`
+	Reason    string             `json:"reason,omitempty" bson:"reason,omitempty"`
+	EmailID   primitive.ObjectID `json:"emailId,omitempty" bson:"emailId,omitempty"`
+	ClaimedBy string             `json:"claimedBy" bson:"claimedBy"`
+	ClaimedAt time.Time          `json:"claimedAt" bson:"claimedAt"`
+	UpdatedAt time.Time          `json:"updatedAt" bson:"updatedAt"`
+}
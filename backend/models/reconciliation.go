@@ -0,0 +1,52 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type DiscrepancyKind string
+
+const (
+	// A seat is BOOKED in its session but no confirmed booking holds it.
+	DiscrepancyOrphanBookedSeat DiscrepancyKind = "ORPHAN_BOOKED_SEAT"
+	// A confirmed booking holds a seat that is not BOOKED in its session.
+	DiscrepancyUnbookedSeat DiscrepancyKind = "UNBOOKED_SEAT"
+	// More than one confirmed booking holds the same seat.
+	DiscrepancyDoubleBooking DiscrepancyKind = "DOUBLE_BOOKING"
+	// A Redis seat lock outlived its session, or its session is gone.
+	DiscrepancyOrphanLock DiscrepancyKind = "ORPHAN_LOCK"
+)
+
+// Discrepancy is one inconsistency found by the reconciler. Its ID is derived
+// from its contents, so the same problem gets the same ID in every report.
+type Discrepancy struct {
+	ID         string          `json:"id" bson:"id"`
+	Kind       DiscrepancyKind `json:"kind" bson:"kind"`
+	SessionID  string          `json:"sessionId" bson:"sessionId"`
+	SeatID     string          `json:"seatId" bson:"seatId"`
+	BookingIDs []string        `json:"bookingIds,omitempty" bson:"bookingIds,omitempty"`
+	LockOwner  string          `json:"lockOwner,omitempty" bson:"lockOwner,omitempty"`
+	Detail     string          `json:"detail" bson:"detail"`
+	// Repair describes the fix, empty when it needs a human. AutoRepair
+	// marks fixes that are safe to apply without approval.
+	Repair     string     `json:"repair,omitempty" bson:"repair,omitempty"`
+	AutoRepair bool       `json:"autoRepair" bson:"autoRepair"`
+	RepairedAt *time.Time `json:"repairedAt,omitempty" bson:"repairedAt,omitempty"`
+	RepairedBy string     `json:"repairedBy,omitempty" bson:"repairedBy,omitempty"`
+	RepairNote string     `json:"repairNote,omitempty" bson:"repairNote,omitempty"`
+}
+
+type ReconciliationReport struct {
+	ID              primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	Trigger         string             `json:"trigger" bson:"trigger"`
+	DryRun          bool               `json:"dryRun" bson:"dryRun"`
+	StartedAt       time.Time          `json:"startedAt" bson:"startedAt"`
+	FinishedAt      time.Time          `json:"finishedAt" bson:"finishedAt"`
+	SessionsChecked int                `json:"sessionsChecked" bson:"sessionsChecked"`
+	LocksChecked    int                `json:"locksChecked" bson:"locksChecked"`
+	Discrepancies   []Discrepancy      `json:"discrepancies" bson:"discrepancies"`
+	Repaired        int                `json:"repaired" bson:"repaired"`
+	Error           string             `json:"error,omitempty" bson:"error,omitempty"`
+}
@@ -0,0 +1,43 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type SessionCancellationStatus string
+
+const (
+	SessionCancellationPending   SessionCancellationStatus = "PENDING"
+	SessionCancellationRunning   SessionCancellationStatus = "RUNNING"
+	SessionCancellationCompleted SessionCancellationStatus = "COMPLETED"
+	// Some bookings could not be refunded or cancelled. Starting the
+	// cancellation again retries them.
+	SessionCancellationCompletedWithErrors SessionCancellationStatus = "COMPLETED_WITH_ERRORS"
+)
+
+// SessionCancellation tracks voiding a whole session: one per session,
+// resumed after a restart until every booking has been refunded.
+type SessionCancellation struct {
+	ID             primitive.ObjectID        `json:"id" bson:"_id,omitempty"`
+	SessionID      primitive.ObjectID        `json:"sessionId" bson:"sessionId"`
+	MovieTitle     string                    `json:"movieTitle" bson:"movieTitle"`
+	Reason         string                    `json:"reason" bson:"reason"`
+	RequestedBy    string                    `json:"requestedBy" bson:"requestedBy"`
+	Status         SessionCancellationStatus `json:"status" bson:"status"`
+	TotalBookings  int                       `json:"totalBookings" bson:"totalBookings"`
+	Processed      int                       `json:"processed" bson:"processed"`
+	RefundedAmount float64                   `json:"refundedAmount" bson:"refundedAmount"`
+	Failures       []BookingFailure          `json:"failures,omitempty" bson:"failures,omitempty"`
+	Attempts       int                       `json:"attempts" bson:"attempts"`
+	CreatedAt      time.Time                 `json:"createdAt" bson:"createdAt"`
+	StartedAt      *time.Time                `json:"startedAt,omitempty" bson:"startedAt,omitempty"`
+	FinishedAt     *time.Time                `json:"finishedAt,omitempty" bson:"finishedAt,omitempty"`
+	UpdatedAt      time.Time                 `json:"updatedAt" bson:"updatedAt"`
+}
+
+type BookingFailure struct {
+	BookingID string `json:"bookingId" bson:"bookingId"`
+	Error     string `json:"error" bson:"error"`
+}
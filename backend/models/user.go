@@ -10,6 +10,7 @@ type UserRole string
 
 const (
 	RoleUser  UserRole = "user"
+	RoleStaff UserRole = "staff"
 	RoleAdmin UserRole = "admin"
 )
 
@@ -23,6 +24,8 @@ type User struct {
 	CreatedAt time.Time          `json:"createdAt" bson:"createdAt"`
 	UpdatedAt time.Time          `json:"updatedAt" bson:"updatedAt"`
 	LastLogin time.Time          `json:"lastLogin" bson:"lastLogin"`
+
+	NotificationPreferences *NotificationPreferences `json:"notificationPreferences,omitempty" bson:"notificationPreferences,omitempty"`
 }
 
 type UserResponse struct {
@@ -0,0 +1,64 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type WaitlistStatus string
+
+const (
+	WaitlistWaiting WaitlistStatus = "WAITING"
+	// Seats are held for the customer until OfferExpiresAt.
+	WaitlistOffered WaitlistStatus = "OFFERED"
+	WaitlistBooked  WaitlistStatus = "BOOKED"
+	WaitlistExpired WaitlistStatus = "EXPIRED"
+	WaitlistLeft    WaitlistStatus = "LEFT"
+)
+
+// WaitlistEntry is one customer waiting for seats in a sold-out session.
+// Entries are served in the order they joined.
+type WaitlistEntry struct {
+	ID             primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	SessionID      primitive.ObjectID `json:"sessionId" bson:"sessionId"`
+	UserID         string             `json:"userId" bson:"userId"`
+	UserEmail      string             `json:"userEmail,omitempty" bson:"userEmail,omitempty"`
+	Locale         string             `json:"locale,omitempty" bson:"locale,omitempty"`
+	PartySize      int                `json:"partySize" bson:"partySize"`
+	Status         WaitlistStatus     `json:"status" bson:"status"`
+	OfferedSeats   []string           `json:"offeredSeats,omitempty" bson:"offeredSeats,omitempty"`
+	OfferedAt      *time.Time         `json:"offeredAt,omitempty" bson:"offeredAt,omitempty"`
+	OfferExpiresAt *time.Time         `json:"offerExpiresAt,omitempty" bson:"offerExpiresAt,omitempty"`
+	CreatedAt      time.Time          `json:"createdAt" bson:"createdAt"`
+	UpdatedAt      time.Time          `json:"updatedAt" bson:"updatedAt"`
+	// Position is the entry's place in the queue, counted from 1. Only set
+	// in API responses for waiting entries.
+	Position int `json:"position,omitempty" bson:"-"`
+}
+
+type JoinWaitlistRequest struct {
+	UserID    string `json:"userId"`
+	UserEmail string `json:"userEmail"`
+	PartySize int    `json:"partySize" binding:"required"`
+	Locale    string `json:"locale"`
+}
+
+// WaitlistOffer tells a waitlisted customer which seats are held for them.
+type WaitlistOffer struct {
+	EntryID   string    `json:"entryId"`
+	UserID    string    `json:"userId"`
+	SeatIDs   []string  `json:"seatIds"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// WaitlistDepth summarises the waitlist of one session for admins.
+type WaitlistDepth struct {
+	SessionID   primitive.ObjectID `json:"sessionId" bson:"_id"`
+	MovieTitle  string             `json:"movieTitle" bson:"movieTitle"`
+	StartTime   time.Time          `json:"startTime" bson:"startTime"`
+	Waiting     int                `json:"waiting" bson:"waiting"`
+	SeatsWanted int                `json:"seatsWanted" bson:"seatsWanted"`
+	Offered     int                `json:"offered" bson:"offered"`
+	OldestAt    *time.Time         `json:"oldestAt,omitempty" bson:"oldestAt,omitempty"`
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"time"
+
+	"cinema-booking-system/config"
+	"cinema-booking-system/eventbus"
+	"cinema-booking-system/services"
+)
+
+func runReplay(args []string) int {
+	fs := flag.NewFlagSet("replay", flag.ContinueOnError)
+	fromOffset := fs.Int64("from-offset", -1, "start offset in every partition; -1 means the earliest retained message")
+	fromTime := fs.String("from-time", "", "start at the first message at or after this RFC3339 time")
+	until := fs.String("until", "", "skip events that occurred after this RFC3339 time")
+	sinkName := fs.String("sink", "stdout", "where to write events: mongo, jsonl or stdout")
+	collection := fs.String("collection", "audit_logs", "target collection for the mongo sink")
+	out := fs.String("out", "", "output file for the jsonl sink")
+	dryRun := fs.Bool("dry-run", false, "decode and count events without writing them")
+	rate := fs.Int("rate", 0, "maximum events written per second (0 = unlimited)")
+	progress := fs.Duration("progress", 5*time.Second, "progress report interval")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: main replay [flags]")
+		fmt.Fprintln(fs.Output(), "Replays the audit topic into a sink.")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	cfg := config.LoadConfig()
+
+	opts := services.ReplayOptions{
+		Topic:        cfg.KafkaTopic,
+		From:         eventbus.Position{Offset: *fromOffset},
+		DryRun:       *dryRun,
+		RatePerSec:   *rate,
+		ProgressEach: *progress,
+	}
+	if *fromTime != "" {
+		t, err := time.Parse(time.RFC3339, *fromTime)
+		if err != nil {
+			log.Printf("❌ Invalid -from-time: %v", err)
+			return 2
+		}
+		opts.From.Time = t
+	}
+	if *until != "" {
+		t, err := time.Parse(time.RFC3339, *until)
+		if err != nil {
+			log.Printf("❌ Invalid -until: %v", err)
+			return 2
+		}
+		opts.Until = t
+	}
+
+	var sink services.ReplaySink
+	if !*dryRun {
+		switch *sinkName {
+		case "mongo":
+			if _, err := config.InitMongoDB(cfg.MongoURI); err != nil {
+				log.Printf("❌ MongoDB connection failed: %v", err)
+				return 1
+			}
+			defer config.CloseConnections()
+			store := services.NewMongoAuditLogStore(config.MongoDB.Collection(*collection))
+			if err := store.EnsureIndexes(context.Background()); err != nil {
+				log.Printf("⚠️ Failed to create indexes on %s: %v", *collection, err)
+			}
+			sink = services.NewStoreReplaySink(store)
+		case "jsonl":
+			if *out == "" {
+				log.Println("❌ -out is required for the jsonl sink")
+				return 2
+			}
+			f, err := os.Create(*out)
+			if err != nil {
+				log.Printf("❌ Failed to create %s: %v", *out, err)
+				return 1
+			}
+			sink = services.NewJSONLReplaySink(f)
+		case "stdout":
+			sink = services.NewJSONLReplaySink(os.Stdout)
+		default:
+			log.Printf("❌ Unknown sink %q", *sinkName)
+			return 2
+		}
+	}
+
+	source := eventbus.NewKafkaBus(cfg.KafkaBroker)
+	defer source.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	log.Printf("⏪ Replaying topic %s (sink=%s, dry-run=%v)", opts.Topic, *sinkName, opts.DryRun)
+	if _, err := services.NewReplayService(source, sink).Run(ctx, opts); err != nil {
+		log.Printf("❌ Replay failed: %v", err)
+		return 1
+	}
+	return 0
+}
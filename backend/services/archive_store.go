@@ -0,0 +1,177 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"cinema-booking-system/config"
+)
+
+type ArchiveStore interface {
+	Put(ctx context.Context, name string, body io.ReadSeeker, size int64) (string, error)
+	Kind() string
+}
+
+func NewArchiveStore(cfg *config.Config) (ArchiveStore, error) {
+	switch cfg.AuditArchiveStore {
+	case "local", "":
+		return &LocalArchiveStore{dir: cfg.AuditArchiveDir}, nil
+	case "s3":
+		if cfg.S3Endpoint == "" || cfg.S3Bucket == "" {
+			return nil, fmt.Errorf("S3_ENDPOINT and S3_BUCKET are required for the s3 archive store")
+		}
+		return &S3ArchiveStore{
+			endpoint:  strings.TrimRight(cfg.S3Endpoint, "/"),
+			region:    cfg.S3Region,
+			bucket:    cfg.S3Bucket,
+			accessKey: cfg.S3AccessKey,
+			secretKey: cfg.S3SecretKey,
+			client:    &http.Client{Timeout: 5 * time.Minute},
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown archive store %q", cfg.AuditArchiveStore)
+	}
+}
+
+type LocalArchiveStore struct {
+	dir string
+}
+
+func (s *LocalArchiveStore) Kind() string { return "local" }
+
+func (s *LocalArchiveStore) Put(ctx context.Context, name string, body io.ReadSeeker, size int64) (string, error) {
+	path := filepath.Join(s.dir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return "", err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".archive-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, body); err != nil {
+		tmp.Close()
+		return "", err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// S3ArchiveStore uploads with a single SigV4-signed PUT, which works against
+// AWS S3 and S3-compatible stores such as MinIO (path-style addressing).
+type S3ArchiveStore struct {
+	endpoint  string
+	region    string
+	bucket    string
+	accessKey string
+	secretKey string
+	client    *http.Client
+}
+
+func (s *S3ArchiveStore) Kind() string { return "s3" }
+
+func (s *S3ArchiveStore) Put(ctx context.Context, name string, body io.ReadSeeker, size int64) (string, error) {
+	h := sha256.New()
+	if _, err := io.Copy(h, body); err != nil {
+		return "", err
+	}
+	payloadHash := hex.EncodeToString(h.Sum(nil))
+	if _, err := body.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+
+	objectURL := s.endpoint + "/" + s.bucket + "/" + escapePath(name)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, objectURL, body)
+	if err != nil {
+		return "", err
+	}
+	req.ContentLength = size
+	req.Header.Set("Content-Type", "application/gzip")
+	s.sign(req, payloadHash, time.Now().UTC())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to upload archive: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return "", fmt.Errorf("failed to upload archive: %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return "s3://" + s.bucket + "/" + name, nil
+}
+
+func (s *S3ArchiveStore) sign(req *http.Request, payloadHash string, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+
+	req.Header.Set("Host", req.URL.Host)
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	signedHeaders := "content-type;host;x-amz-content-sha256;x-amz-date"
+	canonicalHeaders := "content-type:" + req.Header.Get("Content-Type") + "\n" +
+		"host:" + req.URL.Host + "\n" +
+		"x-amz-content-sha256:" + payloadHash + "\n" +
+		"x-amz-date:" + amzDate + "\n"
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		"",
+		canonicalHeaders,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + s.region + "/s3/aws4_request"
+	crHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(crHash[:])
+
+	key := hmacSHA256([]byte("AWS4"+s.secretKey), date)
+	key = hmacSHA256(key, s.region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.accessKey, scope, signedHeaders, signature,
+	))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	m := hmac.New(sha256.New, key)
+	m.Write([]byte(data))
+	return m.Sum(nil)
+}
+
+func escapePath(name string) string {
+	parts := strings.Split(name, "/")
+	for i, p := range parts {
+		parts[i] = url.PathEscape(p)
+	}
+	return strings.Join(parts, "/")
+}
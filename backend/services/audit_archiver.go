@@ -0,0 +1,423 @@
+package services
+
+import (
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"cinema-booking-system/config"
+	"cinema-booking-system/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	archiveBatchSize  = 5000
+	archiverLeaseName = "audit-archiver"
+)
+
+var ErrArchiveRunning = errors.New("an archive run is already in progress")
+
+type AuditArchive struct {
+	ID          primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	EventType   string             `json:"eventType" bson:"eventType"`
+	Store       string             `json:"store" bson:"store"`
+	Location    string             `json:"location" bson:"location"`
+	Count       int                `json:"count" bson:"count"`
+	SizeBytes   int64              `json:"sizeBytes" bson:"sizeBytes"`
+	SHA256      string             `json:"sha256" bson:"sha256"`
+	FirstRecord time.Time          `json:"firstRecord" bson:"firstRecord"`
+	LastRecord  time.Time          `json:"lastRecord" bson:"lastRecord"`
+	CreatedAt   time.Time          `json:"createdAt" bson:"createdAt"`
+}
+
+// ArchivedRange stands in for a run of consecutive chain records that were
+// moved to an archive, so chain verification can step over them.
+type ArchivedRange struct {
+	ChainID       string             `json:"chainId" bson:"chainId"`
+	FromSeq       int64              `json:"fromSeq" bson:"fromSeq"`
+	ToSeq         int64              `json:"toSeq" bson:"toSeq"`
+	FirstPrevHash string             `json:"firstPrevHash" bson:"firstPrevHash"`
+	LastHash      string             `json:"lastHash" bson:"lastHash"`
+	ArchiveID     primitive.ObjectID `json:"archiveId" bson:"archiveId"`
+}
+
+type RetentionStatus struct {
+	EventType    string     `json:"eventType"`
+	Retention    string     `json:"retention"`
+	Total        int64      `json:"total"`
+	Expired      int64      `json:"expired"`
+	OldestRecord *time.Time `json:"oldestRecord,omitempty"`
+}
+
+type ArchiveRunResult struct {
+	StartedAt  time.Time      `json:"startedAt"`
+	FinishedAt time.Time      `json:"finishedAt"`
+	Archived   map[string]int `json:"archived"`
+	Files      int            `json:"files"`
+	Error      string         `json:"error,omitempty"`
+}
+
+type AuditArchiver struct {
+	logs     *mongo.Collection
+	archives *mongo.Collection
+	ranges   *mongo.Collection
+	events   *mongo.Collection
+	store    ArchiveStore
+	policies map[string]time.Duration
+	interval time.Duration
+
+	mu      sync.Mutex
+	running bool
+	lastRun *ArchiveRunResult
+}
+
+func NewAuditArchiver(store ArchiveStore, policies map[string]time.Duration, interval time.Duration) *AuditArchiver {
+	return &AuditArchiver{
+		logs:     config.MongoDB.Collection("audit_logs"),
+		archives: config.MongoDB.Collection("audit_archives"),
+		ranges:   config.MongoDB.Collection("audit_archived_ranges"),
+		events:   config.MongoDB.Collection("audit_archived_events"),
+		store:    store,
+		policies: policies,
+		interval: interval,
+	}
+}
+
+func (a *AuditArchiver) Start(ctx context.Context) {
+	if a.interval <= 0 {
+		log.Println("⚠️ Audit archiver disabled (AUDIT_ARCHIVE_INTERVAL is 0)")
+		return
+	}
+
+	a.ranges.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "chainId", Value: 1}, {Key: "fromSeq", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+
+	log.Printf("🗄️ Audit archiver started (every %s)", a.interval)
+
+	ticker := time.NewTicker(a.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("🗄️ Audit archiver stopped")
+			return
+		case <-ticker.C:
+			if _, err := a.Run(ctx); err != nil && !errors.Is(err, ErrArchiveRunning) {
+				log.Printf("⚠️ Audit archive run failed: %v", err)
+			}
+		}
+	}
+}
+
+func (a *AuditArchiver) Policies() map[string]time.Duration {
+	return a.policies
+}
+
+func (a *AuditArchiver) LastRun() (*ArchiveRunResult, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.lastRun, a.running
+}
+
+// Run archives every expired record under the configured policies. Only one
+// run at a time is allowed per process, and a lease keeps replicas from
+// archiving concurrently.
+func (a *AuditArchiver) Run(ctx context.Context) (*ArchiveRunResult, error) {
+	a.mu.Lock()
+	if a.running {
+		a.mu.Unlock()
+		return nil, ErrArchiveRunning
+	}
+	a.running = true
+	a.mu.Unlock()
+
+	result := &ArchiveRunResult{StartedAt: time.Now().UTC(), Archived: map[string]int{}}
+	err := a.run(ctx, result)
+	result.FinishedAt = time.Now().UTC()
+	if err != nil {
+		result.Error = err.Error()
+	}
+
+	a.mu.Lock()
+	a.running = false
+	a.lastRun = result
+	a.mu.Unlock()
+
+	return result, err
+}
+
+func (a *AuditArchiver) run(ctx context.Context, result *ArchiveRunResult) error {
+	ok, err := AcquireLease(ctx, archiverLeaseName, 30*time.Minute)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrArchiveRunning
+	}
+	defer ReleaseLease(context.Background(), archiverLeaseName)
+
+	types := make([]string, 0, len(a.policies))
+	for eventType := range a.policies {
+		types = append(types, eventType)
+	}
+	sort.Strings(types)
+
+	for _, eventType := range types {
+		retention := a.policies[eventType]
+		if retention <= 0 {
+			continue
+		}
+		cutoff := time.Now().UTC().Add(-retention)
+
+		for {
+			n, err := a.archiveBatch(ctx, eventType, cutoff)
+			if err != nil {
+				return fmt.Errorf("archiving %s: %w", eventType, err)
+			}
+			if n == 0 {
+				break
+			}
+			result.Archived[eventType] += n
+			result.Files++
+			if n < archiveBatchSize {
+				break
+			}
+		}
+	}
+
+	if result.Files > 0 {
+		log.Printf("🗄️ Audit archive run finished: %v", result.Archived)
+	}
+	return nil
+}
+
+func (a *AuditArchiver) archiveBatch(ctx context.Context, eventType string, cutoff time.Time) (int, error) {
+	cursor, err := a.logs.Find(ctx,
+		bson.M{"eventType": eventType, "timestamp": bson.M{"$lt": cutoff}},
+		options.Find().
+			SetSort(bson.D{{Key: "timestamp", Value: 1}, {Key: "_id", Value: 1}}).
+			SetLimit(archiveBatchSize),
+	)
+	if err != nil {
+		return 0, err
+	}
+	var records []models.AuditLog
+	if err := cursor.All(ctx, &records); err != nil {
+		return 0, err
+	}
+	if len(records) == 0 {
+		return 0, nil
+	}
+
+	tmp, err := os.CreateTemp("", "audit-archive-*.jsonl.gz")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	hash := sha256.New()
+	gz := gzip.NewWriter(io.MultiWriter(tmp, hash))
+	enc := json.NewEncoder(gz)
+	for _, r := range records {
+		if err := enc.Encode(r); err != nil {
+			return 0, err
+		}
+	}
+	if err := gz.Close(); err != nil {
+		return 0, err
+	}
+
+	size, err := tmp.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return 0, err
+	}
+
+	first, last := records[0].Timestamp, records[len(records)-1].Timestamp
+	name := fmt.Sprintf("audit-logs/%s/%s/%s_%s_%s.jsonl.gz",
+		eventType,
+		first.Format("2006/01"),
+		eventType,
+		first.Format("20060102T150405Z"),
+		primitive.NewObjectID().Hex(),
+	)
+
+	location, err := a.store.Put(ctx, name, tmp, size)
+	if err != nil {
+		return 0, err
+	}
+
+	archive := AuditArchive{
+		ID:          primitive.NewObjectID(),
+		EventType:   eventType,
+		Store:       a.store.Kind(),
+		Location:    location,
+		Count:       len(records),
+		SizeBytes:   size,
+		SHA256:      hex.EncodeToString(hash.Sum(nil)),
+		FirstRecord: first,
+		LastRecord:  last,
+		CreatedAt:   time.Now().UTC(),
+	}
+	if _, err := a.archives.InsertOne(ctx, archive); err != nil {
+		return 0, err
+	}
+
+	for _, r := range archivedRanges(records, archive.ID) {
+		_, err := a.ranges.UpdateOne(ctx,
+			bson.M{"chainId": r.ChainID, "fromSeq": r.FromSeq},
+			bson.M{"$set": r},
+			options.Update().SetUpsert(true),
+		)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	if err := a.rememberEvents(ctx, records, archive.ID); err != nil {
+		return 0, err
+	}
+
+	ids := make([]primitive.ObjectID, len(records))
+	for i, r := range records {
+		ids[i] = r.ID
+	}
+	if _, err := a.logs.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": ids}}); err != nil {
+		return 0, err
+	}
+
+	log.Printf("🗄️ Archived %d %s records to %s", len(records), eventType, location)
+	return len(records), nil
+}
+
+// rememberEvents keeps the event IDs of archived records, so a replay of
+// those events is recognised as a duplicate once the records are gone.
+func (a *AuditArchiver) rememberEvents(ctx context.Context, records []models.AuditLog, archiveID primitive.ObjectID) error {
+	var docs []interface{}
+	for _, r := range records {
+		if r.EventID != "" {
+			docs = append(docs, bson.M{"_id": r.EventID, "archiveId": archiveID})
+		}
+	}
+	if len(docs) == 0 {
+		return nil
+	}
+	// A run interrupted after this step archives the same records again;
+	// their IDs are already known.
+	_, err := a.events.InsertMany(ctx, docs, options.InsertMany().SetOrdered(false))
+	if err != nil && !mongo.IsDuplicateKeyError(err) {
+		return err
+	}
+	return nil
+}
+
+func archivedRanges(records []models.AuditLog, archiveID primitive.ObjectID) []ArchivedRange {
+	byChain := make(map[string][]models.AuditLog)
+	for _, r := range records {
+		if r.ChainID != "" {
+			byChain[r.ChainID] = append(byChain[r.ChainID], r)
+		}
+	}
+
+	var ranges []ArchivedRange
+	for chainID, recs := range byChain {
+		sort.Slice(recs, func(i, j int) bool { return recs[i].ChainSeq < recs[j].ChainSeq })
+
+		cur := ArchivedRange{ChainID: chainID, FromSeq: recs[0].ChainSeq, ToSeq: recs[0].ChainSeq,
+			FirstPrevHash: recs[0].PrevHash, LastHash: recs[0].Hash, ArchiveID: archiveID}
+		for _, r := range recs[1:] {
+			if r.ChainSeq == cur.ToSeq+1 && r.PrevHash == cur.LastHash {
+				cur.ToSeq = r.ChainSeq
+				cur.LastHash = r.Hash
+				continue
+			}
+			ranges = append(ranges, cur)
+			cur = ArchivedRange{ChainID: chainID, FromSeq: r.ChainSeq, ToSeq: r.ChainSeq,
+				FirstPrevHash: r.PrevHash, LastHash: r.Hash, ArchiveID: archiveID}
+		}
+		ranges = append(ranges, cur)
+	}
+	return ranges
+}
+
+func (a *AuditArchiver) Status(ctx context.Context) ([]RetentionStatus, error) {
+	cursor, err := a.logs.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$group", Value: bson.M{
+			"_id":    "$eventType",
+			"total":  bson.M{"$sum": 1},
+			"oldest": bson.M{"$min": "$timestamp"},
+		}}},
+		{{Key: "$sort", Value: bson.M{"_id": 1}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	var groups []struct {
+		EventType string    `bson:"_id"`
+		Total     int64     `bson:"total"`
+		Oldest    time.Time `bson:"oldest"`
+	}
+	if err := cursor.All(ctx, &groups); err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	statuses := make([]RetentionStatus, 0, len(groups))
+	for _, g := range groups {
+		seen[g.EventType] = true
+		st := RetentionStatus{EventType: g.EventType, Retention: "forever", Total: g.Total}
+		if !g.Oldest.IsZero() {
+			oldest := g.Oldest
+			st.OldestRecord = &oldest
+		}
+		if retention := a.policies[g.EventType]; retention > 0 {
+			st.Retention = retention.String()
+			st.Expired, err = a.logs.CountDocuments(ctx, bson.M{
+				"eventType": g.EventType,
+				"timestamp": bson.M{"$lt": time.Now().UTC().Add(-retention)},
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+		statuses = append(statuses, st)
+	}
+
+	for eventType, retention := range a.policies {
+		if !seen[eventType] && retention > 0 {
+			statuses = append(statuses, RetentionStatus{EventType: eventType, Retention: retention.String()})
+		}
+	}
+	return statuses, nil
+}
+
+func (a *AuditArchiver) RecentArchives(ctx context.Context, limit int64) ([]AuditArchive, error) {
+	cursor, err := a.archives.Find(ctx, bson.M{},
+		options.Find().SetSort(bson.D{{Key: "createdAt", Value: -1}}).SetLimit(limit))
+	if err != nil {
+		return nil, err
+	}
+	archives := []AuditArchive{}
+	err = cursor.All(ctx, &archives)
+	return archives, err
+}
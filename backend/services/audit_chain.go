@@ -0,0 +1,539 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"cinema-booking-system/config"
+	"cinema-booking-system/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	GlobalChainID     = "_global"
+	maxChainAppendTry = 10
+)
+
+// MongoAuditLogStore appends audit records to per-session hash chains. Each
+// record stores the hash of its own content and the hash of the previous
+// record in the same chain; a unique (chainId, chainSeq) index makes
+// concurrent appenders retry instead of forking the chain.
+type MongoAuditLogStore struct {
+	collection *mongo.Collection
+}
+
+func NewMongoAuditLogStore(collection *mongo.Collection) *MongoAuditLogStore {
+	return &MongoAuditLogStore{collection: collection}
+}
+
+func NewDefaultAuditLogStore() *MongoAuditLogStore {
+	if config.MongoDB == nil {
+		return &MongoAuditLogStore{}
+	}
+	return NewMongoAuditLogStore(config.MongoDB.Collection("audit_logs"))
+}
+
+func (s *MongoAuditLogStore) EnsureIndexes(ctx context.Context) error {
+	_, err := s.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "eventId", Value: 1}},
+			Options: options.Index().
+				SetUnique(true).
+				SetPartialFilterExpression(bson.M{"eventId": bson.M{"$exists": true}}),
+		},
+		{
+			Keys: bson.D{{Key: "chainId", Value: 1}, {Key: "chainSeq", Value: 1}},
+			Options: options.Index().
+				SetUnique(true).
+				SetPartialFilterExpression(bson.M{"chainId": bson.M{"$exists": true}}),
+		},
+		{
+			Keys: bson.D{{Key: "timestamp", Value: -1}, {Key: "_id", Value: -1}},
+		},
+		{
+			Keys: bson.D{{Key: "eventType", Value: 1}, {Key: "timestamp", Value: -1}},
+		},
+		{
+			Keys: bson.D{{Key: "sessionId", Value: 1}, {Key: "timestamp", Value: -1}},
+		},
+		{
+			Keys: bson.D{{Key: "userId", Value: 1}, {Key: "timestamp", Value: -1}},
+		},
+		{
+			Keys: bson.D{{Key: "seatIds", Value: 1}},
+		},
+	})
+	return err
+}
+
+func (s *MongoAuditLogStore) SaveAuditLog(ctx context.Context, auditLog models.AuditLog) error {
+	if s.collection == nil {
+		return errors.New("mongodb not initialized")
+	}
+
+	if auditLog.EventID != "" {
+		saved, err := s.eventSaved(ctx, auditLog.EventID)
+		if err != nil {
+			return err
+		}
+		if saved {
+			return nil
+		}
+	}
+
+	auditLog.ChainID = ChainIDFor(auditLog)
+	auditLog.Timestamp = auditLog.Timestamp.UTC().Truncate(time.Millisecond)
+
+	for attempt := 0; attempt < maxChainAppendTry; attempt++ {
+		head, err := s.chainHead(ctx, auditLog.ChainID)
+		if err != nil {
+			return err
+		}
+
+		auditLog.ChainSeq = head.ChainSeq + 1
+		auditLog.PrevHash = head.Hash
+		auditLog.Hash = ComputeAuditHash(auditLog)
+
+		_, err = s.collection.InsertOne(ctx, auditLog)
+		if err == nil {
+			s.advanceHead(ctx, auditLog)
+			return nil
+		}
+		if !mongo.IsDuplicateKeyError(err) {
+			return err
+		}
+		if auditLog.EventID != "" {
+			if saved, _ := s.eventSaved(ctx, auditLog.EventID); saved {
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("failed to append to audit chain %s after %d attempts", auditLog.ChainID, maxChainAppendTry)
+}
+
+// ChainHead is the newest link appended to a chain. It is kept apart from
+// the records, so deleting the end of a chain is detected.
+type ChainHead struct {
+	ChainID   string    `json:"chainId" bson:"_id"`
+	Seq       int64     `json:"seq" bson:"seq"`
+	Hash      string    `json:"hash" bson:"hash"`
+	UpdatedAt time.Time `json:"updatedAt" bson:"updatedAt"`
+}
+
+// advanceHead moves a chain's head to a newly appended record. A head that
+// lags behind its chain, after a crash between the two writes, is tolerated
+// by verification; one that is ahead of it is not.
+func (s *MongoAuditLogStore) advanceHead(ctx context.Context, auditLog models.AuditLog) {
+	_, err := s.chainHeads().UpdateOne(ctx,
+		bson.M{"_id": auditLog.ChainID, "seq": bson.M{"$lt": auditLog.ChainSeq}},
+		bson.M{"$set": bson.M{"seq": auditLog.ChainSeq, "hash": auditLog.Hash, "updatedAt": time.Now().UTC()}},
+		options.Update().SetUpsert(true),
+	)
+	// A duplicate key means a newer link already moved the head.
+	if err != nil && !mongo.IsDuplicateKeyError(err) {
+		log.Printf("⚠️ Failed to advance head of audit chain %s to %d: %v", auditLog.ChainID, auditLog.ChainSeq, err)
+	}
+}
+
+// eventSaved reports whether an event already has a record, either live or
+// moved to an archive.
+func (s *MongoAuditLogStore) eventSaved(ctx context.Context, eventID string) (bool, error) {
+	n, err := s.collection.CountDocuments(ctx, bson.M{"eventId": eventID}, options.Count().SetLimit(1))
+	if err != nil || n > 0 {
+		return n > 0, err
+	}
+	n, err = s.archivedEvents().CountDocuments(ctx, bson.M{"_id": eventID}, options.Count().SetLimit(1))
+	return n > 0, err
+}
+
+// chainHead returns the sequence number and hash of the newest link in a
+// chain, which may have been moved to an archive.
+func (s *MongoAuditLogStore) chainHead(ctx context.Context, chainID string) (models.AuditLog, error) {
+	var head models.AuditLog
+	err := s.collection.FindOne(ctx,
+		bson.M{"chainId": chainID},
+		options.FindOne().SetSort(bson.D{{Key: "chainSeq", Value: -1}}),
+	).Decode(&head)
+	if err != nil && err != mongo.ErrNoDocuments {
+		return models.AuditLog{}, err
+	}
+
+	var archived ArchivedRange
+	err = s.archivedRanges().FindOne(ctx,
+		bson.M{"chainId": chainID, "toSeq": bson.M{"$gt": head.ChainSeq}},
+		options.FindOne().SetSort(bson.D{{Key: "toSeq", Value: -1}}),
+	).Decode(&archived)
+	if err == mongo.ErrNoDocuments {
+		return head, nil
+	}
+	if err != nil {
+		return models.AuditLog{}, err
+	}
+	return models.AuditLog{ChainID: chainID, ChainSeq: archived.ToSeq, Hash: archived.LastHash}, nil
+}
+
+func ChainIDFor(auditLog models.AuditLog) string {
+	if auditLog.SessionID == "" {
+		return GlobalChainID
+	}
+	return auditLog.SessionID
+}
+
+// ComputeAuditHash hashes every field of a record except its storage ID and
+// its own hash. Values are normalised to what survives a MongoDB round trip
+// (millisecond timestamps, JSON-shaped payloads) so a stored record re-hashes
+// to the same value.
+func ComputeAuditHash(auditLog models.AuditLog) string {
+	payload, _ := json.Marshal(normalizePayload(auditLog.Payload))
+
+	content := struct {
+		EventID       string          `json:"eventId"`
+		EventType     string          `json:"eventType"`
+		SchemaVersion int             `json:"schemaVersion"`
+		SessionID     string          `json:"sessionId"`
+		UserID        string          `json:"userId"`
+		SeatIDs       []string        `json:"seatIds"`
+		ActorType     string          `json:"actorType"`
+		ActorID       string          `json:"actorId"`
+		CorrelationID string          `json:"correlationId"`
+		Timestamp     string          `json:"timestamp"`
+		Description   string          `json:"description"`
+		Payload       json.RawMessage `json:"payload"`
+		ChainID       string          `json:"chainId"`
+		ChainSeq      int64           `json:"chainSeq"`
+		PrevHash      string          `json:"prevHash"`
+	}{
+		EventID:       auditLog.EventID,
+		EventType:     auditLog.EventType,
+		SchemaVersion: auditLog.SchemaVersion,
+		SessionID:     auditLog.SessionID,
+		UserID:        auditLog.UserID,
+		SeatIDs:       auditLog.SeatIDs,
+		ActorType:     auditLog.ActorType,
+		ActorID:       auditLog.ActorID,
+		CorrelationID: auditLog.CorrelationID,
+		Timestamp:     auditLog.Timestamp.UTC().Truncate(time.Millisecond).Format(time.RFC3339Nano),
+		Description:   auditLog.Description,
+		Payload:       payload,
+		ChainID:       auditLog.ChainID,
+		ChainSeq:      auditLog.ChainSeq,
+		PrevHash:      auditLog.PrevHash,
+	}
+
+	data, _ := json.Marshal(content)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func normalizePayload(payload map[string]interface{}) interface{} {
+	if len(payload) == 0 {
+		return nil
+	}
+	data, _ := json.Marshal(payload)
+	var normalized interface{}
+	json.Unmarshal(data, &normalized)
+	return normalized
+}
+
+type ChainVerifyOptions struct {
+	ChainID string
+	From    time.Time
+	To      time.Time
+}
+
+type ChainBreak struct {
+	ChainID      string    `json:"chainId"`
+	ChainSeq     int64     `json:"chainSeq"`
+	EventID      string    `json:"eventId,omitempty"`
+	Timestamp    time.Time `json:"timestamp"`
+	Reason       string    `json:"reason"`
+	ExpectedHash string    `json:"expectedHash,omitempty"`
+	ActualHash   string    `json:"actualHash,omitempty"`
+}
+
+type ChainVerifyReport struct {
+	Valid            bool         `json:"valid"`
+	ChainsChecked    int          `json:"chainsChecked"`
+	RecordsChecked   int64        `json:"recordsChecked"`
+	UnchainedRecords int64        `json:"unchainedRecords"`
+	FirstBroken      *ChainBreak  `json:"firstBroken,omitempty"`
+	Broken           []ChainBreak `json:"broken"`
+}
+
+// VerifyChains checks every chain that has records in the time range. The
+// range only selects which links to check: verification always walks the
+// contiguous sequence between the first and last matching record, anchored
+// on the record just before it. The stored head of each of those chains, and
+// of chains appended to within the range, must match the chain's newest
+// record, so records missing from the end are caught too.
+func (s *MongoAuditLogStore) VerifyChains(ctx context.Context, opts ChainVerifyOptions) (*ChainVerifyReport, error) {
+	rangeFilter := bson.M{}
+	if !opts.From.IsZero() || !opts.To.IsZero() {
+		ts := bson.M{}
+		if !opts.From.IsZero() {
+			ts["$gte"] = opts.From
+		}
+		if !opts.To.IsZero() {
+			ts["$lte"] = opts.To
+		}
+		rangeFilter["timestamp"] = ts
+	}
+
+	unchainedFilter := bson.M{"chainId": bson.M{"$exists": false}}
+	for k, v := range rangeFilter {
+		unchainedFilter[k] = v
+	}
+	if opts.ChainID != "" {
+		unchainedFilter["sessionId"] = opts.ChainID
+	}
+	unchained, err := s.collection.CountDocuments(ctx, unchainedFilter)
+	if err != nil {
+		return nil, err
+	}
+
+	match := bson.M{"chainId": bson.M{"$exists": true}}
+	if opts.ChainID != "" {
+		match["chainId"] = opts.ChainID
+	}
+	for k, v := range rangeFilter {
+		match[k] = v
+	}
+
+	cursor, err := s.collection.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$group", Value: bson.M{
+			"_id":    "$chainId",
+			"minSeq": bson.M{"$min": "$chainSeq"},
+			"maxSeq": bson.M{"$max": "$chainSeq"},
+		}}},
+		{{Key: "$sort", Value: bson.M{"_id": 1}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	var ranges []struct {
+		ChainID string `bson:"_id"`
+		MinSeq  int64  `bson:"minSeq"`
+		MaxSeq  int64  `bson:"maxSeq"`
+	}
+	if err := cursor.All(ctx, &ranges); err != nil {
+		return nil, err
+	}
+
+	report := &ChainVerifyReport{Valid: true, UnchainedRecords: unchained, Broken: []ChainBreak{}}
+	addBreak := func(brk ChainBreak) {
+		report.Valid = false
+		report.Broken = append(report.Broken, brk)
+		if report.FirstBroken == nil || brk.Timestamp.Before(report.FirstBroken.Timestamp) {
+			first := brk
+			report.FirstBroken = &first
+		}
+	}
+
+	checkedChains := make(map[string]bool, len(ranges))
+	brokenChains := make(map[string]bool)
+	for _, r := range ranges {
+		brk, checked, err := s.verifyChain(ctx, r.ChainID, r.MinSeq, r.MaxSeq)
+		if err != nil {
+			return nil, err
+		}
+		report.ChainsChecked++
+		report.RecordsChecked += checked
+		checkedChains[r.ChainID] = true
+		if brk != nil {
+			brokenChains[r.ChainID] = true
+			addBreak(*brk)
+		}
+	}
+
+	headFilter := bson.M{}
+	if opts.ChainID != "" {
+		headFilter["_id"] = opts.ChainID
+	} else if ts, ok := rangeFilter["timestamp"]; ok {
+		chainIDs := make([]string, 0, len(checkedChains))
+		for chainID := range checkedChains {
+			chainIDs = append(chainIDs, chainID)
+		}
+		headFilter["$or"] = bson.A{
+			bson.M{"_id": bson.M{"$in": chainIDs}},
+			bson.M{"updatedAt": ts},
+		}
+	}
+	headCursor, err := s.chainHeads().Find(ctx, headFilter, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	var heads []ChainHead
+	if err := headCursor.All(ctx, &heads); err != nil {
+		return nil, err
+	}
+	for _, head := range heads {
+		if brokenChains[head.ChainID] {
+			continue
+		}
+		if !checkedChains[head.ChainID] {
+			report.ChainsChecked++
+		}
+		brk, err := s.verifyHead(ctx, head)
+		if err != nil {
+			return nil, err
+		}
+		if brk != nil {
+			addBreak(*brk)
+		}
+	}
+	return report, nil
+}
+
+// verifyHead compares a chain's stored head with its newest record, live or
+// archived.
+func (s *MongoAuditLogStore) verifyHead(ctx context.Context, head ChainHead) (*ChainBreak, error) {
+	newest, err := s.chainHead(ctx, head.ChainID)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case newest.ChainSeq < head.Seq:
+		return &ChainBreak{
+			ChainID:      head.ChainID,
+			ChainSeq:     newest.ChainSeq + 1,
+			Timestamp:    head.UpdatedAt,
+			Reason:       fmt.Sprintf("missing records %d-%d at the end of the chain", newest.ChainSeq+1, head.Seq),
+			ExpectedHash: head.Hash,
+		}, nil
+	case newest.ChainSeq == head.Seq && newest.Hash != head.Hash:
+		return &ChainBreak{
+			ChainID:      head.ChainID,
+			ChainSeq:     head.Seq,
+			EventID:      newest.EventID,
+			Timestamp:    head.UpdatedAt,
+			Reason:       "newest record does not match the chain head",
+			ExpectedHash: head.Hash,
+			ActualHash:   newest.Hash,
+		}, nil
+	}
+	return nil, nil
+}
+
+func (s *MongoAuditLogStore) verifyChain(ctx context.Context, chainID string, minSeq, maxSeq int64) (*ChainBreak, int64, error) {
+	rangesColl := s.archivedRanges()
+
+	var prevHash string
+	havePrev := false
+	if minSeq > 1 {
+		var anchor models.AuditLog
+		err := s.collection.FindOne(ctx, bson.M{"chainId": chainID, "chainSeq": minSeq - 1}).Decode(&anchor)
+		switch {
+		case err == nil:
+			prevHash, havePrev = anchor.Hash, true
+		case err != mongo.ErrNoDocuments:
+			return nil, 0, err
+		default:
+			var r ArchivedRange
+			err := rangesColl.FindOne(ctx, bson.M{"chainId": chainID, "toSeq": minSeq - 1}).Decode(&r)
+			if err == nil {
+				prevHash, havePrev = r.LastHash, true
+			} else if err != mongo.ErrNoDocuments {
+				return nil, 0, err
+			}
+		}
+	}
+
+	rangeCursor, err := rangesColl.Find(ctx,
+		bson.M{"chainId": chainID, "fromSeq": bson.M{"$gte": minSeq, "$lte": maxSeq}},
+		options.Find().SetSort(bson.D{{Key: "fromSeq", Value: 1}}),
+	)
+	if err != nil {
+		return nil, 0, err
+	}
+	var ranges []ArchivedRange
+	if err := rangeCursor.All(ctx, &ranges); err != nil {
+		return nil, 0, err
+	}
+
+	cursor, err := s.collection.Find(ctx,
+		bson.M{"chainId": chainID, "chainSeq": bson.M{"$gte": minSeq, "$lte": maxSeq}},
+		options.Find().SetSort(bson.D{{Key: "chainSeq", Value: 1}}),
+	)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var checked int64
+	expectedSeq := minSeq
+	for cursor.Next(ctx) {
+		var rec models.AuditLog
+		if err := cursor.Decode(&rec); err != nil {
+			return nil, checked, err
+		}
+		checked++
+
+		brk := func(seq int64, reason, expected, actual string) *ChainBreak {
+			return &ChainBreak{
+				ChainID:      chainID,
+				ChainSeq:     seq,
+				EventID:      rec.EventID,
+				Timestamp:    rec.Timestamp,
+				Reason:       reason,
+				ExpectedHash: expected,
+				ActualHash:   actual,
+			}
+		}
+
+		for len(ranges) > 0 && ranges[0].FromSeq < rec.ChainSeq {
+			r := ranges[0]
+			ranges = ranges[1:]
+			if r.FromSeq != expectedSeq {
+				return brk(r.FromSeq, fmt.Sprintf("missing records %d-%d before archived records", expectedSeq, r.FromSeq-1), "", ""), checked, nil
+			}
+			if havePrev && r.FirstPrevHash != prevHash {
+				return brk(r.FromSeq, fmt.Sprintf("archived records %d-%d do not link to the preceding record", r.FromSeq, r.ToSeq), prevHash, r.FirstPrevHash), checked, nil
+			}
+			prevHash, havePrev = r.LastHash, true
+			expectedSeq = r.ToSeq + 1
+		}
+
+		if rec.ChainSeq != expectedSeq {
+			return brk(rec.ChainSeq, fmt.Sprintf("missing records %d-%d before this one", expectedSeq, rec.ChainSeq-1), "", ""), checked, nil
+		}
+
+		switch {
+		case havePrev && rec.PrevHash != prevHash:
+			return brk(rec.ChainSeq, "previous hash does not match the preceding record", prevHash, rec.PrevHash), checked, nil
+		case !havePrev && rec.ChainSeq > 1:
+			return brk(rec.ChainSeq, "preceding record is missing", "", rec.PrevHash), checked, nil
+		case !havePrev && rec.PrevHash != "":
+			return brk(rec.ChainSeq, "first record of the chain has a previous hash", "", rec.PrevHash), checked, nil
+		}
+
+		if computed := ComputeAuditHash(rec); computed != rec.Hash {
+			return brk(rec.ChainSeq, "record content does not match its hash", computed, rec.Hash), checked, nil
+		}
+
+		prevHash, havePrev = rec.Hash, true
+		expectedSeq++
+	}
+	return nil, checked, cursor.Err()
+}
+
+func (s *MongoAuditLogStore) archivedRanges() *mongo.Collection {
+	return s.collection.Database().Collection("audit_archived_ranges")
+}
+
+func (s *MongoAuditLogStore) chainHeads() *mongo.Collection {
+	return s.collection.Database().Collection("audit_chain_heads")
+}
+
+func (s *MongoAuditLogStore) archivedEvents() *mongo.Collection {
+	return s.collection.Database().Collection("audit_archived_events")
+}
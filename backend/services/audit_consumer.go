@@ -0,0 +1,83 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"cinema-booking-system/eventbus"
+	"cinema-booking-system/events"
+	"cinema-booking-system/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type AuditLogStore interface {
+	SaveAuditLog(ctx context.Context, auditLog models.AuditLog) error
+}
+
+type AuditLogConsumerService struct {
+	bus      eventbus.EventBus
+	topic    string
+	groupID  string
+	store    AuditLogStore
+	registry *events.Registry
+}
+
+func NewAuditLogConsumerService(bus eventbus.EventBus, topic, groupID string, store AuditLogStore) *AuditLogConsumerService {
+	return &AuditLogConsumerService{
+		bus:      bus,
+		topic:    topic,
+		groupID:  groupID,
+		store:    store,
+		registry: events.Default,
+	}
+}
+
+func (s *AuditLogConsumerService) Start(ctx context.Context) {
+	if s.bus == nil {
+		log.Println("⚠️ Event bus not available, audit log consumer disabled")
+		return
+	}
+
+	log.Println("🎧 Audit log consumer started, listening for audit logs...")
+
+	err := s.bus.Subscribe(ctx, s.topic, s.groupID, s.HandleMessage)
+	if err != nil && ctx.Err() == nil && !errors.Is(err, eventbus.ErrClosed) {
+		log.Printf("⚠️ Audit log consumer stopped: %v", err)
+		return
+	}
+	log.Println("🛑 Audit log consumer stopped")
+}
+
+func (s *AuditLogConsumerService) HandleMessage(ctx context.Context, msg eventbus.Message) error {
+	auditLog, err := DecodeAuditLog(s.registry, msg.Value)
+	if err != nil {
+		// A message that can never be decoded would block its partition
+		// forever if it were retried, so it is logged and skipped.
+		log.Printf("⚠️ Dropping undecodable audit message %s[%d]@%d: %v", msg.Topic, msg.Partition, msg.Offset, err)
+		return nil
+	}
+
+	if auditLog.ID.IsZero() {
+		auditLog.ID = primitive.NewObjectID()
+	}
+
+	if err := s.store.SaveAuditLog(ctx, auditLog); err != nil {
+		return err
+	}
+
+	log.Printf("💾 Audit log saved: %s - %s", auditLog.EventType, auditLog.Description)
+	return nil
+}
+
+func DecodeAuditLog(registry *events.Registry, data []byte) (models.AuditLog, error) {
+	env, payload, err := registry.Decode(data)
+	if errors.Is(err, events.ErrLegacyMessage) {
+		return events.DecodeLegacy(data)
+	}
+	if err != nil {
+		return models.AuditLog{}, err
+	}
+	return events.ToAuditLog(env, payload), nil
+}
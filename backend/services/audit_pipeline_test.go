@@ -0,0 +1,190 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"cinema-booking-system/eventbus"
+	"cinema-booking-system/events"
+	"cinema-booking-system/models"
+)
+
+const pipelineTopic = "audit-logs"
+
+// memoryAuditStore records saved audit logs and can fail the first saves.
+type memoryAuditStore struct {
+	mu       sync.Mutex
+	failures int
+	attempts int
+	logs     []models.AuditLog
+}
+
+func (s *memoryAuditStore) SaveAuditLog(ctx context.Context, auditLog models.AuditLog) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.attempts++
+	if s.failures > 0 {
+		s.failures--
+		return errors.New("store unavailable")
+	}
+	s.logs = append(s.logs, auditLog)
+	return nil
+}
+
+func (s *memoryAuditStore) saved() []models.AuditLog {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]models.AuditLog(nil), s.logs...)
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestAuditPipelineSavesPublishedEvents(t *testing.T) {
+	bus := eventbus.NewMemoryBus(4)
+	defer bus.Close()
+	store := &memoryAuditStore{}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go NewAuditLogConsumerService(bus, pipelineTopic, "audit", store).Start(ctx)
+
+	producer := NewEventProducerServiceWithBus(bus, pipelineTopic)
+	if err := producer.LogSeatLocked(ctx, "session-1", "user-1", []string{"A1", "A2"}); err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+	if err := producer.LogBookingSuccess(ctx, "session-1", "user-1", []string{"A1", "A2"}, "booking-1", 300); err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+
+	waitFor(t, "both audit logs", func() bool { return len(store.saved()) == 2 })
+	waitFor(t, "offsets to be committed", func() bool { return bus.Lag(pipelineTopic, "audit") == 0 })
+
+	logs := store.saved()
+	if logs[0].EventType != events.TypeSeatsLocked || logs[1].EventType != events.TypeBookingConfirmed {
+		t.Fatalf("saved %s, %s; want SEAT_LOCKED, BOOKING_SUCCESS", logs[0].EventType, logs[1].EventType)
+	}
+	for _, l := range logs {
+		if l.EventID == "" || l.ID.IsZero() || l.SessionID != "session-1" || l.UserID != "user-1" {
+			t.Errorf("audit log missing fields: %+v", l)
+		}
+	}
+}
+
+func TestAuditPipelineRedeliversAfterStoreError(t *testing.T) {
+	bus := eventbus.NewMemoryBus(1)
+	defer bus.Close()
+	store := &memoryAuditStore{failures: 2}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go NewAuditLogConsumerService(bus, pipelineTopic, "audit", store).Start(ctx)
+
+	producer := NewEventProducerServiceWithBus(bus, pipelineTopic)
+	if err := producer.LogSeatUnlocked(ctx, "session-1", "user-1", []string{"B3"}, "USER_CANCELLED"); err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+
+	waitFor(t, "the audit log to be saved", func() bool { return len(store.saved()) == 1 })
+	waitFor(t, "the offset to be committed", func() bool { return bus.Lag(pipelineTopic, "audit") == 0 })
+
+	store.mu.Lock()
+	attempts := store.attempts
+	store.mu.Unlock()
+	if attempts != 3 {
+		t.Errorf("store called %d times, want 3 (two failures, then success)", attempts)
+	}
+	if logs := store.saved(); logs[0].EventType != events.TypeSeatsUnlocked {
+		t.Errorf("saved %s, want SEAT_UNLOCKED", logs[0].EventType)
+	}
+}
+
+// partitionRecorder is an audit store that remembers which partition each
+// of its logs came from.
+type partitionRecorder struct {
+	memoryAuditStore
+	partitions map[int]bool
+}
+
+func TestAuditPipelineSplitsPartitionsAcrossGroup(t *testing.T) {
+	bus := eventbus.NewMemoryBus(4)
+	defer bus.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	consumers := []*partitionRecorder{
+		{partitions: make(map[int]bool)},
+		{partitions: make(map[int]bool)},
+	}
+	for _, rec := range consumers {
+		rec := rec
+		consumer := NewAuditLogConsumerService(bus, pipelineTopic, "audit", rec)
+		go bus.Subscribe(ctx, pipelineTopic, "audit", func(ctx context.Context, msg eventbus.Message) error {
+			rec.mu.Lock()
+			rec.partitions[msg.Partition] = true
+			rec.mu.Unlock()
+			return consumer.HandleMessage(ctx, msg)
+		})
+	}
+	// Let both members join before anything is published, so no partition
+	// moves between them mid-test.
+	time.Sleep(100 * time.Millisecond)
+
+	producer := NewEventProducerServiceWithBus(bus, pipelineTopic)
+	const published = 40
+	for i := 0; i < published; i++ {
+		if err := producer.LogSeatLocked(ctx, fmt.Sprintf("session-%d", i), "user-1", []string{"A1"}); err != nil {
+			t.Fatalf("publish: %v", err)
+		}
+	}
+
+	waitFor(t, "every audit log", func() bool {
+		return len(consumers[0].saved())+len(consumers[1].saved()) == published
+	})
+	waitFor(t, "offsets to be committed", func() bool { return bus.Lag(pipelineTopic, "audit") == 0 })
+
+	seen := make(map[string]bool)
+	for i, rec := range consumers {
+		logs := rec.saved()
+		if len(logs) == 0 {
+			t.Errorf("consumer %d got no partitions", i)
+		}
+		for _, l := range logs {
+			if seen[l.EventID] {
+				t.Errorf("event %s delivered twice", l.EventID)
+			}
+			seen[l.EventID] = true
+		}
+	}
+	for p := range consumers[0].partitions {
+		if consumers[1].partitions[p] {
+			t.Errorf("partition %d consumed by both members", p)
+		}
+	}
+}
+
+func TestDecodeAuditLogFallsBackToLegacyMessages(t *testing.T) {
+	legacy := []byte(`{"eventType":"BOOKING_SUCCESS","sessionId":"session-1","userId":"user-1","seatIds":["A1"]}`)
+
+	l, err := DecodeAuditLog(events.Default, legacy)
+	if err != nil {
+		t.Fatalf("DecodeAuditLog: %v", err)
+	}
+	if l.EventType != events.TypeBookingConfirmed || l.SessionID != "session-1" || l.SchemaVersion != 0 {
+		t.Errorf("legacy message decoded as %+v", l)
+	}
+}
@@ -0,0 +1,210 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"cinema-booking-system/models"
+)
+
+// Seat preferences accepted by the best-available search.
+const (
+	SeatPreferenceCenter = "center"
+	SeatPreferenceAisle  = "aisle"
+	SeatPreferenceBack   = "back"
+)
+
+const (
+	// MaxPartySize caps how many seats one request can ask for.
+	MaxPartySize = 10
+
+	// bestAvailableAttempts is how many of the best blocks are tried when
+	// others lock them first.
+	bestAvailableAttempts = 3
+
+	// idealRowPosition is where the view is best without a preference: two
+	// thirds of the way back from the screen.
+	idealRowPosition = 2.0 / 3.0
+)
+
+// SeatPreferences is a validated best-available request.
+type SeatPreferences struct {
+	PartySize int
+	Category  string
+	Center    bool
+	Aisle     bool
+	Back      bool
+}
+
+// NewSeatPreferences validates a best-available request.
+func NewSeatPreferences(req models.BestAvailableRequest) (SeatPreferences, error) {
+	prefs := SeatPreferences{
+		PartySize: req.PartySize,
+		Category:  strings.ToUpper(strings.TrimSpace(req.Category)),
+	}
+	if prefs.PartySize < 1 || prefs.PartySize > MaxPartySize {
+		return prefs, fmt.Errorf("party size must be between 1 and %d", MaxPartySize)
+	}
+	for _, p := range req.Preferences {
+		switch strings.ToLower(strings.TrimSpace(p)) {
+		case SeatPreferenceCenter:
+			prefs.Center = true
+		case SeatPreferenceAisle:
+			prefs.Aisle = true
+		case SeatPreferenceBack:
+			prefs.Back = true
+		default:
+			return prefs, fmt.Errorf("unknown seat preference %q", p)
+		}
+	}
+	return prefs, nil
+}
+
+// SeatBlock is a run of adjacent free seats in one row. Lower scores are
+// better.
+type SeatBlock struct {
+	Row     string   `json:"row"`
+	SeatIDs []string `json:"seatIds"`
+	Score   float64  `json:"score"`
+
+	rowIndex int
+	first    int
+}
+
+// RankSeatBlocks lists every block of prefs.PartySize adjacent free seats,
+// best first. A seat is free when it is AVAILABLE, not in locked and of the
+// requested category. Rows are ordered by label, A being nearest the screen;
+// seats at either end of a row count as aisle seats. Equal scores are broken
+// by row and then seat number, so the same seat map always gives the same
+// answer.
+func RankSeatBlocks(seats []models.Seat, locked map[string]string, prefs SeatPreferences) []SeatBlock {
+	byRow := make(map[string][]models.Seat)
+	for _, seat := range seats {
+		byRow[seat.Row] = append(byRow[seat.Row], seat)
+	}
+	rows := make([]string, 0, len(byRow))
+	for row := range byRow {
+		rows = append(rows, row)
+	}
+	sort.Strings(rows)
+
+	var blocks []SeatBlock
+	for rowIndex, row := range rows {
+		rowSeats := byRow[row]
+		sort.Slice(rowSeats, func(i, j int) bool { return rowSeats[i].Number < rowSeats[j].Number })
+		rowMin, rowMax := rowSeats[0].Number, rowSeats[len(rowSeats)-1].Number
+
+		start := 0
+		for i, seat := range rowSeats {
+			if !seatFree(seat, locked, prefs.Category) {
+				start = i + 1
+				continue
+			}
+			if i > start && seat.Number != rowSeats[i-1].Number+1 {
+				start = i
+			}
+			if i-start+1 < prefs.PartySize {
+				continue
+			}
+
+			run := rowSeats[i-prefs.PartySize+1 : i+1]
+			ids := make([]string, len(run))
+			for k, s := range run {
+				ids[k] = s.ID
+			}
+			blocks = append(blocks, SeatBlock{
+				Row:      row,
+				SeatIDs:  ids,
+				Score:    scoreSeatBlock(run[0].Number, seat.Number, rowMin, rowMax, rowIndex, len(rows), prefs),
+				rowIndex: rowIndex,
+				first:    run[0].Number,
+			})
+		}
+	}
+
+	sort.SliceStable(blocks, func(i, j int) bool {
+		a, b := blocks[i], blocks[j]
+		if math.Abs(a.Score-b.Score) > 1e-9 {
+			return a.Score < b.Score
+		}
+		if a.rowIndex != b.rowIndex {
+			return a.rowIndex < b.rowIndex
+		}
+		return a.first < b.first
+	})
+	return blocks
+}
+
+func seatFree(seat models.Seat, locked map[string]string, category string) bool {
+	if seat.Status != models.SeatAvailable && seat.Status != "" {
+		return false
+	}
+	if _, ok := locked[seat.ID]; ok {
+		return false
+	}
+	if category == "" {
+		return true
+	}
+	seatCategory := seat.Category
+	if seatCategory == "" {
+		seatCategory = models.SeatCategoryStandard
+	}
+	return strings.EqualFold(seatCategory, category)
+}
+
+// scoreSeatBlock weighs how far a block is from the middle of its row, how
+// far its row is from the preferred depth and whether it reaches an aisle.
+// Without preferences blocks near the centre, two thirds back, win.
+func scoreSeatBlock(first, last, rowMin, rowMax, rowIndex, rows int, prefs SeatPreferences) float64 {
+	centerDist := 0.0
+	if half := float64(rowMax-rowMin) / 2; half > 0 {
+		rowCenter := float64(rowMin+rowMax) / 2
+		centerDist = math.Abs(float64(first+last)/2-rowCenter) / half
+	}
+	rowPos := 0.0
+	if rows > 1 {
+		rowPos = float64(rowIndex) / float64(rows-1)
+	}
+
+	score := centerDist
+	if prefs.Center {
+		score = 3 * centerDist
+	}
+	if prefs.Back {
+		score += 3 * (1 - rowPos)
+	} else {
+		score += math.Abs(rowPos - idealRowPosition)
+	}
+	if prefs.Aisle && first != rowMin && last != rowMax {
+		score += 2
+	}
+	return score
+}
+
+// LockBestAvailable finds the best free block in a session's seat map and
+// locks it for userID in one all-or-nothing call. If someone locks a block
+// first, the next best is tried. Sessions with the single-seat gap rule skip
+// blocks that would leave a lone seat.
+func LockBestAvailable(ctx context.Context, locks *RedisLockService, session *models.MovieSession, userID string, prefs SeatPreferences) (*SeatBlock, error) {
+	sessionID := session.ID.Hex()
+
+	owners, err := locks.LockOwners(ctx, sessionID, availableSeatIDs(session))
+	if err != nil {
+		return nil, err
+	}
+
+	blocks := RankSeatBlocks(session.Seats, owners, prefs)
+	if SingleSeatGapRuleApplies(session) {
+		blocks = withoutSingleSeatGaps(session.Seats, owners, blocks)
+	}
+	for i := 0; i < len(blocks) && i < bestAvailableAttempts; i++ {
+		_, failed, err := locks.LockMultipleSeats(ctx, sessionID, blocks[i].SeatIDs, userID)
+		if err == nil && len(failed) == 0 {
+			return &blocks[i], nil
+		}
+	}
+	return nil, &LockRejectedError{Code: LockRejectNoBlock}
+}
@@ -0,0 +1,230 @@
+package services
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"cinema-booking-system/models"
+)
+
+// hall builds a seat map with rows of seats numbered 1 to perRow, all
+// AVAILABLE and of the standard category.
+func hall(rows string, perRow int) []models.Seat {
+	var seats []models.Seat
+	for _, r := range rows {
+		for n := 1; n <= perRow; n++ {
+			seats = append(seats, models.Seat{
+				ID:     fmt.Sprintf("%c%d", r, n),
+				Row:    string(r),
+				Number: n,
+				Status: models.SeatAvailable,
+			})
+		}
+	}
+	return seats
+}
+
+// withSeats applies fn to the named seats of a seat map.
+func withSeats(seats []models.Seat, fn func(*models.Seat), ids ...string) []models.Seat {
+	for i := range seats {
+		if containsString(ids, seats[i].ID) {
+			fn(&seats[i])
+		}
+	}
+	return seats
+}
+
+func booked(seat *models.Seat) { seat.Status = models.SeatBooked }
+
+func removed(seats []models.Seat, ids ...string) []models.Seat {
+	var out []models.Seat
+	for _, seat := range seats {
+		if !containsString(ids, seat.ID) {
+			out = append(out, seat)
+		}
+	}
+	return out
+}
+
+// nearlyFullRowC is four rows of eight where only C3 and C4 are left in row
+// C, the row nearest the ideal depth.
+func nearlyFullRowC() []models.Seat {
+	return withSeats(hall("ABCD", 8), booked, "C1", "C2", "C5", "C6", "C7", "C8")
+}
+
+func TestRankSeatBlocks(t *testing.T) {
+	tests := []struct {
+		name      string
+		seats     []models.Seat
+		locked    map[string]string
+		prefs     SeatPreferences
+		wantFirst []string
+		wantCount int // -1 to skip the check
+	}{
+		{
+			name:      "centre of the row two thirds back",
+			seats:     hall("ABC", 6),
+			prefs:     SeatPreferences{PartySize: 2},
+			wantFirst: []string{"B3", "B4"},
+			wantCount: 15,
+		},
+		{
+			name:      "gap in seat numbering splits a row",
+			seats:     removed(hall("A", 6), "A4"),
+			prefs:     SeatPreferences{PartySize: 3},
+			wantFirst: []string{"A1", "A2", "A3"},
+			wantCount: 1,
+		},
+		{
+			name: "category filter",
+			seats: withSeats(hall("AB", 4), func(s *models.Seat) { s.Category = "PREMIUM" },
+				"B1", "B2", "B3", "B4"),
+			prefs:     SeatPreferences{PartySize: 2, Category: "PREMIUM"},
+			wantFirst: []string{"B2", "B3"},
+			wantCount: 3,
+		},
+		{
+			name:      "booked and locked seats are skipped",
+			seats:     withSeats(hall("A", 5), booked, "A3"),
+			locked:    map[string]string{"A4": "someone-else"},
+			prefs:     SeatPreferences{PartySize: 2},
+			wantFirst: []string{"A1", "A2"},
+			wantCount: 1,
+		},
+		{
+			name:      "off-centre seats at the ideal depth beat the centre elsewhere",
+			seats:     nearlyFullRowC(),
+			prefs:     SeatPreferences{PartySize: 2},
+			wantFirst: []string{"C3", "C4"},
+			wantCount: -1,
+		},
+		{
+			name:      "center preference",
+			seats:     nearlyFullRowC(),
+			prefs:     SeatPreferences{PartySize: 2, Center: true},
+			wantFirst: []string{"B4", "B5"},
+			wantCount: -1,
+		},
+		{
+			name:      "back preference",
+			seats:     nearlyFullRowC(),
+			prefs:     SeatPreferences{PartySize: 2, Back: true},
+			wantFirst: []string{"D4", "D5"},
+			wantCount: -1,
+		},
+		{
+			name:      "aisle preference",
+			seats:     nearlyFullRowC(),
+			prefs:     SeatPreferences{PartySize: 2, Aisle: true},
+			wantFirst: []string{"B1", "B2"},
+			wantCount: -1,
+		},
+		{
+			name:      "aisle counts the ends of a row with a numbering gap",
+			seats:     removed(hall("A", 6), "A1"),
+			prefs:     SeatPreferences{PartySize: 2, Aisle: true},
+			wantFirst: []string{"A2", "A3"},
+			wantCount: 4,
+		},
+		{
+			name:      "party larger than any block",
+			seats:     withSeats(hall("AB", 6), booked, "A4", "B3"),
+			prefs:     SeatPreferences{PartySize: 4},
+			wantCount: 0,
+		},
+		{
+			name:      "ties go to the lower seat number",
+			seats:     hall("A", 5),
+			prefs:     SeatPreferences{PartySize: 2},
+			wantFirst: []string{"A2", "A3"},
+			wantCount: 4,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			blocks := RankSeatBlocks(tt.seats, tt.locked, tt.prefs)
+
+			if tt.wantCount >= 0 && len(blocks) != tt.wantCount {
+				t.Errorf("got %d blocks, want %d: %+v", len(blocks), tt.wantCount, blocks)
+			}
+			if tt.wantFirst == nil {
+				if len(blocks) != 0 {
+					t.Errorf("best block = %v, want none", blocks[0].SeatIDs)
+				}
+				return
+			}
+			if len(blocks) == 0 {
+				t.Fatalf("no blocks, want %v", tt.wantFirst)
+			}
+			if !reflect.DeepEqual(blocks[0].SeatIDs, tt.wantFirst) {
+				t.Errorf("best block = %v, want %v", blocks[0].SeatIDs, tt.wantFirst)
+			}
+			for _, block := range blocks {
+				if len(block.SeatIDs) != tt.prefs.PartySize {
+					t.Errorf("block %v has %d seats, want %d", block.SeatIDs, len(block.SeatIDs), tt.prefs.PartySize)
+				}
+			}
+		})
+	}
+}
+
+func TestRankSeatBlocksIsDeterministic(t *testing.T) {
+	seats := hall("ABCD", 8)
+	want := RankSeatBlocks(seats, nil, SeatPreferences{PartySize: 2, Aisle: true})
+
+	// The same seat map in reverse order must rank the same way, ties
+	// included.
+	reversed := make([]models.Seat, len(seats))
+	for i, seat := range seats {
+		reversed[len(seats)-1-i] = seat
+	}
+	for i := 0; i < 5; i++ {
+		got := RankSeatBlocks(reversed, nil, SeatPreferences{PartySize: 2, Aisle: true})
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("ranking changed with seat order:\ngot  %+v\nwant %+v", got, want)
+		}
+	}
+}
+
+func TestNewSeatPreferences(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     models.BestAvailableRequest
+		want    SeatPreferences
+		wantErr bool
+	}{
+		{
+			name: "preferences and category are normalised",
+			req:  models.BestAvailableRequest{PartySize: 2, Category: " premium ", Preferences: []string{"Center", " back"}},
+			want: SeatPreferences{PartySize: 2, Category: "PREMIUM", Center: true, Back: true},
+		},
+		{
+			name: "largest party",
+			req:  models.BestAvailableRequest{PartySize: MaxPartySize, Preferences: []string{"aisle"}},
+			want: SeatPreferences{PartySize: MaxPartySize, Aisle: true},
+		},
+		{name: "empty party", req: models.BestAvailableRequest{PartySize: 0}, wantErr: true},
+		{name: "party too large", req: models.BestAvailableRequest{PartySize: MaxPartySize + 1}, wantErr: true},
+		{name: "unknown preference", req: models.BestAvailableRequest{PartySize: 2, Preferences: []string{"window"}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewSeatPreferences(tt.req)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("got %+v, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
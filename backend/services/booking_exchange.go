@@ -0,0 +1,399 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"cinema-booking-system/config"
+	"cinema-booking-system/events"
+	"cinema-booking-system/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	ExchangeBlockedNoChange = "NO_CHANGE"
+
+	SystemErrorExchangeRefund = "EXCHANGE_REFUND_FAILED"
+)
+
+var (
+	ErrBookingChanged        = errors.New("booking changed while it was being exchanged")
+	ErrPaymentsNotConfigured = errors.New("payment provider is not configured")
+)
+
+// NotExchangeableError is returned when a booking cannot be exchanged. Reason
+// is one of the CancelBlocked* codes, since exchanges follow the cancellation
+// policy, or ExchangeBlockedNoChange.
+type NotExchangeableError struct {
+	Reason string
+}
+
+func (e *NotExchangeableError) Error() string {
+	switch e.Reason {
+	case CancelBlockedNotConfirmed:
+		return "Booking is not confirmed"
+	case CancelBlockedStarted:
+		return "Show has already started"
+	case CancelBlockedCutoff:
+		return "Changes are no longer possible for this show"
+	case CancelBlockedAdmitted:
+		return "Tickets have already been used"
+	case CancelBlockedSession:
+		return "The session was cancelled and this booking is being refunded"
+	case ExchangeBlockedNoChange:
+		return "The booking already has these seats"
+	}
+	return "Booking cannot be exchanged"
+}
+
+// ExchangeResult is a booking after an exchange and what changed.
+type ExchangeResult struct {
+	Booking  *models.Booking
+	Session  *models.MovieSession
+	Previous models.BookingRevision
+	// Released are the seats given back in the previous session, Booked
+	// the seats newly taken in the booking's session.
+	Released []string
+	Booked   []string
+}
+
+// Exchange moves a booking to other seats, in the same or another session,
+// keeping its ID. The new seats are locked in Redis first, then one MongoDB
+// transaction books them, releases the old ones and rewrites the booking,
+// recording its previous state as a revision. A higher price is charged
+// before the transaction (and refunded if it fails); a lower one is refunded
+// after it commits. Exchanges follow the cancellation policy.
+func (s *BookingService) Exchange(ctx context.Context, bookingID, userID, sessionID string, seatIDs []string) (*ExchangeResult, error) {
+	booking, current, err := s.GetForUser(ctx, bookingID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	if e := BookingCancellationEligibility(*booking, *current, now); !e.Cancellable {
+		return nil, &NotExchangeableError{Reason: e.Reason}
+	}
+
+	if sessionID == "" {
+		sessionID = booking.SessionID.Hex()
+	}
+	target, err := s.seatMaps.Get(ctx, sessionID)
+	if errors.Is(err, ErrSessionNotFound) {
+		return nil, &LockRejectedError{Code: LockRejectSessionNotFound}
+	}
+	if err != nil {
+		return nil, err
+	}
+	sameSession := target.ID == booking.SessionID
+
+	// The booking's own seats are free for it to keep.
+	held := make(map[string]bool)
+	if sameSession {
+		for _, seatID := range booking.Seats {
+			held[seatID] = true
+		}
+		seats := make([]models.Seat, len(target.Seats))
+		copy(seats, target.Seats)
+		for i := range seats {
+			if held[seats[i].ID] {
+				seats[i].Status = models.SeatAvailable
+			}
+		}
+		target.Seats = seats
+	}
+	if err := ValidateSeatLock(target, seatIDs, now, config.AppConfig.SalesCutoff); err != nil {
+		return nil, err
+	}
+
+	var booked []string
+	for _, seatID := range seatIDs {
+		if !held[seatID] {
+			booked = append(booked, seatID)
+		}
+	}
+	var released []string
+	for _, seatID := range booking.Seats {
+		if !sameSession || !containsString(seatIDs, seatID) {
+			released = append(released, seatID)
+		}
+	}
+	if len(booked) == 0 && len(released) == 0 {
+		return nil, &NotExchangeableError{Reason: ExchangeBlockedNoChange}
+	}
+
+	if len(booked) > 0 {
+		_, failed, err := s.locks.LockMultipleSeats(ctx, sessionID, booked, userID)
+		if err != nil || len(failed) > 0 {
+			rejected := &LockRejectedError{Code: LockRejectSeatLocked}
+			for _, seatID := range failed {
+				rejected.Seats = append(rejected.Seats, SeatRejection{SeatID: seatID, Code: LockRejectSeatLocked})
+			}
+			if len(rejected.Seats) == 0 {
+				return nil, fmt.Errorf("failed to lock seats: %w", err)
+			}
+			return nil, rejected
+		}
+		defer s.locks.UnlockMultipleSeats(context.Background(), sessionID, booked, userID)
+	}
+
+	newTotal := SeatsTotal(*target, seatIDs)
+	diff := math.Round((newTotal-booking.TotalAmount)*100) / 100
+	previous := models.BookingRevision{
+		Revision:        booking.Revision,
+		SessionID:       booking.SessionID,
+		Seats:           booking.Seats,
+		TotalAmount:     booking.TotalAmount,
+		ReplacedAt:      now,
+		PriceDifference: diff,
+	}
+
+	commit := func() error {
+		return s.withTransaction(ctx, func(txn mongo.SessionContext) error {
+			if err := s.moveSeats(txn, booking, target, sameSession, booked, released); err != nil {
+				return err
+			}
+
+			revisionFilter := bson.M{"revision": booking.Revision}
+			if booking.Revision == 0 {
+				revisionFilter = bson.M{"$or": []bson.M{{"revision": 0}, {"revision": bson.M{"$exists": false}}}}
+			}
+			result, err := s.bookings.UpdateOne(txn,
+				bson.M{"$and": []bson.M{
+					{"_id": booking.ID, "status": models.BookingStatusConfirmed, "admissions.0": bson.M{"$exists": false}},
+					revisionFilter,
+				}},
+				bson.M{
+					"$set": bson.M{
+						"sessionId":   target.ID,
+						"seats":       seatIDs,
+						"totalAmount": newTotal,
+						"revision":    booking.Revision + 1,
+					},
+					"$push": bson.M{"revisions": previous},
+				},
+			)
+			if err != nil {
+				return fmt.Errorf("failed to update booking: %w", err)
+			}
+			if result.MatchedCount == 0 {
+				return ErrBookingChanged
+			}
+			return nil
+		})
+	}
+	if err := s.payExchange(ctx, booking, sessionID, seatIDs, &previous, commit); err != nil {
+		var unavailable *SeatUnavailableError
+		var conflict *SessionConflictError
+		if errors.As(err, &unavailable) || errors.As(err, &conflict) {
+			eventCtx := events.WithCorrelationID(context.Background(), events.CorrelationID(ctx))
+			go s.eventService.LogSessionError(eventCtx, sessionID, SystemErrorBookingConflict, err.Error(), map[string]interface{}{
+				"bookingId": bookingID,
+				"userId":    userID,
+				"seatIds":   seatIDs,
+			})
+		}
+		return nil, err
+	}
+
+	InvalidateSeatMap(ctx, sessionID)
+	if !sameSession {
+		InvalidateSeatMap(ctx, booking.SessionID.Hex())
+	}
+
+	if diff < 0 && previous.PaymentReference != "" {
+		s.bookings.UpdateOne(ctx,
+			bson.M{"_id": booking.ID},
+			bson.M{"$set": bson.M{"revisions.$[r].paymentReference": previous.PaymentReference}},
+			options.Update().SetArrayFilters(options.ArrayFilters{Filters: []interface{}{bson.M{"r.revision": previous.Revision}}}),
+		)
+	}
+
+	updated := *booking
+	updated.SessionID = target.ID
+	updated.Seats = seatIDs
+	updated.TotalAmount = newTotal
+	updated.Revision = booking.Revision + 1
+	updated.Revisions = append(append([]models.BookingRevision{}, booking.Revisions...), previous)
+
+	session := current
+	if !sameSession {
+		session = &models.MovieSession{}
+		if err := s.sessions.FindOne(ctx, bson.M{"_id": target.ID}, sessionWithoutSeats()).Decode(session); err != nil {
+			return nil, fmt.Errorf("failed to load session: %w", err)
+		}
+	}
+
+	eventCtx := events.WithCorrelationID(context.Background(), events.CorrelationID(ctx))
+	go s.eventService.LogBookingExchanged(eventCtx, updated, previous)
+
+	return &ExchangeResult{
+		Booking:  &updated,
+		Session:  session,
+		Previous: previous,
+		Released: released,
+		Booked:   booked,
+	}, nil
+}
+
+// moveSeats books the new seats and releases the old ones inside the
+// exchange transaction. Within one session a single update does both, so
+// seats can be swapped between neighbours without a window where both or
+// neither are taken.
+func (s *BookingService) moveSeats(txn mongo.SessionContext, booking *models.Booking, target *models.MovieSession, sameSession bool, booked, released []string) error {
+	var session models.MovieSession
+	err := s.sessions.FindOne(txn, bson.M{"_id": target.ID},
+		options.FindOne().SetProjection(bson.M{
+			"seats.id": 1, "seats.status": 1, "version": 1,
+			"status": 1, "startTime": 1, "endTime": 1, "salesOpenAt": 1,
+		}),
+	).Decode(&session)
+	if err != nil {
+		return fmt.Errorf("failed to load session: %w", err)
+	}
+	if err := CheckSessionOnSale(session, time.Now().UTC(), config.AppConfig.SalesCutoff); err != nil {
+		return err
+	}
+	if err := checkSeatsBookable(session, booked); err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	set := bson.M{"updatedAt": now}
+	var filters []interface{}
+	if len(booked) > 0 {
+		set["seats.$[book].status"] = models.SeatBooked
+		filters = append(filters, bson.M{"book.id": bson.M{"$in": booked}})
+	}
+	if sameSession && len(released) > 0 {
+		set["seats.$[release].status"] = models.SeatAvailable
+		filters = append(filters, bson.M{"release.id": bson.M{"$in": released}, "release.status": models.SeatBooked})
+	}
+	update := options.Update()
+	if len(filters) > 0 {
+		update.SetArrayFilters(options.ArrayFilters{Filters: filters})
+	}
+	result, err := s.sessions.UpdateOne(txn,
+		bookableFilter(session, booked),
+		bson.M{"$set": set, "$inc": bson.M{"version": 1}},
+		update,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to book seats: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return &SessionConflictError{SessionID: session.ID.Hex(), Version: session.Version}
+	}
+
+	if sameSession || len(released) == 0 {
+		return nil
+	}
+	_, err = s.sessions.UpdateOne(txn,
+		bson.M{"_id": booking.SessionID},
+		bson.M{
+			"$set": bson.M{"seats.$[release].status": models.SeatAvailable, "updatedAt": now},
+			"$inc": bson.M{"version": 1},
+		},
+		options.Update().SetArrayFilters(options.ArrayFilters{Filters: []interface{}{
+			bson.M{"release.id": bson.M{"$in": released}, "release.status": models.SeatBooked},
+		}}),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to release seats: %w", err)
+	}
+	return nil
+}
+
+// payExchange settles the price difference of one exchange attempt around
+// commit, the transaction that moves the seats. A higher price is charged
+// first and voided if commit fails; a lower one is refunded once commit has
+// succeeded. previous.PaymentReference is set to the charge or refund.
+func (s *BookingService) payExchange(ctx context.Context, booking *models.Booking, sessionID string, seatIDs []string, previous *models.BookingRevision, commit func() error) error {
+	bookingID := booking.ID.Hex()
+	diff := previous.PriceDifference
+	if diff != 0 && s.payments == nil {
+		return ErrPaymentsNotConfigured
+	}
+	key := exchangePaymentKey(bookingID, booking.Revision+1, sessionID, seatIDs)
+
+	if diff > 0 {
+		charge, err := s.payments.Charge(ctx, ChargeRequest{
+			BookingID:      bookingID,
+			PaymentID:      booking.PaymentID,
+			Amount:         diff,
+			Description:    "Booking exchange",
+			IdempotencyKey: key,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to charge price difference: %w", err)
+		}
+		previous.PaymentReference = charge.Reference
+	}
+
+	if err := commit(); err != nil {
+		if diff > 0 {
+			s.voidCharge(ctx, bookingID, diff, key)
+		}
+		return err
+	}
+
+	if diff < 0 {
+		refund, err := s.payments.Refund(ctx, RefundRequest{
+			BookingID:      bookingID,
+			PaymentID:      booking.PaymentID,
+			Amount:         -diff,
+			Reason:         "Booking exchange",
+			IdempotencyKey: key,
+		})
+		if err != nil {
+			log.Printf("❌ Refund of %.2f for exchanged booking %s failed: %v", -diff, bookingID, err)
+			go s.eventService.LogSessionError(context.Background(), sessionID, SystemErrorExchangeRefund, err.Error(), map[string]interface{}{
+				"bookingId": bookingID,
+				"amount":    -diff,
+			})
+		} else {
+			previous.PaymentReference = refund.Reference
+		}
+	}
+	return nil
+}
+
+// exchangePaymentKey is the idempotency key for the payment of one exchange
+// attempt. It names the seats paid for, and a fresh ID makes it unique to
+// the attempt: a failed attempt voids its charge, so a provider handed the
+// same key again would replay the voided charge and the retry would never
+// be paid for.
+func exchangePaymentKey(bookingID string, revision int, sessionID string, seatIDs []string) string {
+	seats := append([]string(nil), seatIDs...)
+	sort.Strings(seats)
+	sum := sha256.Sum256([]byte(sessionID + ":" + strings.Join(seats, ",")))
+	return fmt.Sprintf("exchange:%s:%d:%x:%s", bookingID, revision, sum[:8], primitive.NewObjectID().Hex())
+}
+
+// voidCharge refunds a price difference charged for an exchange that then
+// failed.
+func (s *BookingService) voidCharge(ctx context.Context, bookingID string, amount float64, key string) {
+	_, err := s.payments.Refund(context.Background(), RefundRequest{
+		BookingID:      bookingID,
+		Amount:         amount,
+		Reason:         "Booking exchange failed",
+		IdempotencyKey: key + ":void",
+	})
+	if err != nil {
+		log.Printf("❌ Failed to refund charge for failed exchange of booking %s: %v", bookingID, err)
+		go s.eventService.LogSystemError(events.WithCorrelationID(context.Background(), events.CorrelationID(ctx)), SystemErrorExchangeRefund, err.Error(), map[string]interface{}{
+			"bookingId": bookingID,
+			"amount":    amount,
+		})
+	}
+}
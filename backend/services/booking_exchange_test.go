@@ -0,0 +1,143 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"cinema-booking-system/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// replayingGateway is a payment provider that honours idempotency keys: a
+// request with a key it has seen gets the first result for that key back,
+// and nothing new is charged or refunded.
+type replayingGateway struct {
+	mu      sync.Mutex
+	charged map[string]*ChargeResult
+	refunds map[string]*RefundResult
+	voided  map[string]bool // charge references refunded by a void
+}
+
+func newReplayingGateway() *replayingGateway {
+	return &replayingGateway{
+		charged: make(map[string]*ChargeResult),
+		refunds: make(map[string]*RefundResult),
+		voided:  make(map[string]bool),
+	}
+}
+
+func (g *replayingGateway) Charge(ctx context.Context, req ChargeRequest) (*ChargeResult, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if result, ok := g.charged[req.IdempotencyKey]; ok {
+		return result, nil
+	}
+	result := &ChargeResult{Reference: fmt.Sprintf("ch_%d", len(g.charged)+1)}
+	g.charged[req.IdempotencyKey] = result
+	return result, nil
+}
+
+func (g *replayingGateway) Refund(ctx context.Context, req RefundRequest) (*RefundResult, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if result, ok := g.refunds[req.IdempotencyKey]; ok {
+		return result, nil
+	}
+	result := &RefundResult{Reference: fmt.Sprintf("rf_%d", len(g.refunds)+1)}
+	g.refunds[req.IdempotencyKey] = result
+	for key, charge := range g.charged {
+		if key+":void" == req.IdempotencyKey {
+			g.voided[charge.Reference] = true
+		}
+	}
+	return result, nil
+}
+
+func (g *replayingGateway) Kind() string { return "replaying" }
+
+func TestExchangeRetryAfterFailureIsChargedAgain(t *testing.T) {
+	gateway := newReplayingGateway()
+	s := &BookingService{payments: gateway}
+	booking := &models.Booking{ID: primitive.NewObjectID(), PaymentID: "pay_1", Revision: 2}
+	seatIDs := []string{"D5", "D4"}
+
+	// The first attempt charges the difference, then its transaction fails
+	// and the charge is voided.
+	first := models.BookingRevision{Revision: 2, PriceDifference: 50}
+	err := s.payExchange(context.Background(), booking, "session-1", seatIDs, &first, func() error {
+		return ErrBookingChanged
+	})
+	if !errors.Is(err, ErrBookingChanged) {
+		t.Fatalf("first attempt: err = %v, want ErrBookingChanged", err)
+	}
+	if !gateway.voided[first.PaymentReference] {
+		t.Fatalf("charge %s of the failed attempt was not voided", first.PaymentReference)
+	}
+
+	// The retry, with the same booking revision and seats, must be charged
+	// afresh rather than handed the voided charge.
+	retry := models.BookingRevision{Revision: 2, PriceDifference: 50}
+	committed := false
+	err = s.payExchange(context.Background(), booking, "session-1", seatIDs, &retry, func() error {
+		committed = true
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("retry: %v", err)
+	}
+	if !committed {
+		t.Fatal("retry did not commit")
+	}
+	if len(gateway.charged) != 2 {
+		t.Errorf("%d charges made, want 2", len(gateway.charged))
+	}
+	if retry.PaymentReference == first.PaymentReference || gateway.voided[retry.PaymentReference] {
+		t.Errorf("retry was given charge %s, the voided charge of the failed attempt", retry.PaymentReference)
+	}
+}
+
+func TestExchangeRefundsALowerPriceAfterCommit(t *testing.T) {
+	gateway := newReplayingGateway()
+	s := &BookingService{payments: gateway}
+	booking := &models.Booking{ID: primitive.NewObjectID(), PaymentID: "pay_1"}
+
+	previous := models.BookingRevision{PriceDifference: -30}
+	err := s.payExchange(context.Background(), booking, "session-1", []string{"A1"}, &previous, func() error {
+		if len(gateway.refunds) > 0 {
+			t.Error("refunded before the exchange was committed")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("payExchange: %v", err)
+	}
+	if len(gateway.charged) != 0 || len(gateway.refunds) != 1 {
+		t.Errorf("charges = %d, refunds = %d; want 0 and 1", len(gateway.charged), len(gateway.refunds))
+	}
+	if previous.PaymentReference != "rf_1" {
+		t.Errorf("payment reference = %q, want rf_1", previous.PaymentReference)
+	}
+}
+
+func TestExchangeWithoutPaymentsNeedsNoPriceChange(t *testing.T) {
+	s := &BookingService{}
+	booking := &models.Booking{ID: primitive.NewObjectID()}
+
+	previous := models.BookingRevision{PriceDifference: 20}
+	err := s.payExchange(context.Background(), booking, "session-1", []string{"A1"}, &previous, func() error {
+		t.Error("committed without a payment provider")
+		return nil
+	})
+	if !errors.Is(err, ErrPaymentsNotConfigured) {
+		t.Errorf("err = %v, want ErrPaymentsNotConfigured", err)
+	}
+
+	same := models.BookingRevision{}
+	if err := s.payExchange(context.Background(), booking, "session-1", []string{"A1"}, &same, func() error { return nil }); err != nil {
+		t.Errorf("exchange at the same price: %v", err)
+	}
+}
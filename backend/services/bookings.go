@@ -0,0 +1,423 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"cinema-booking-system/config"
+	"cinema-booking-system/events"
+	"cinema-booking-system/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readconcern"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
+)
+
+var ErrBookingNotFound = errors.New("booking not found")
+
+const SystemErrorBookingConflict = "BOOKING_CONFLICT"
+
+// SessionConflictError aborts a confirmation because the session changed
+// between reading it and booking its seats.
+type SessionConflictError struct {
+	SessionID string
+	Version   int64
+}
+
+func (e *SessionConflictError) Error() string {
+	return fmt.Sprintf("session %s changed after version %d was read", e.SessionID, e.Version)
+}
+
+// SeatUnavailableError aborts a confirmation because a seat is already
+// booked, blocked or does not exist in the session.
+type SeatUnavailableError struct {
+	SeatID string
+}
+
+func (e *SeatUnavailableError) Error() string {
+	return "seat " + e.SeatID + " is not available"
+}
+
+const (
+	CancelBlockedNotConfirmed = "NOT_CONFIRMED"
+	CancelBlockedStarted      = "SESSION_STARTED"
+	CancelBlockedCutoff       = "CUTOFF_PASSED"
+	CancelBlockedAdmitted     = "ALREADY_ADMITTED"
+	CancelBlockedSession      = "SESSION_CANCELLED"
+)
+
+// CancellationEligibility says whether a customer may still cancel a
+// booking, and if not, why.
+type CancellationEligibility struct {
+	Cancellable bool      `json:"cancellable"`
+	Deadline    time.Time `json:"deadline"`
+	Reason      string    `json:"reason,omitempty"`
+}
+
+// BookingCancellationEligibility applies the customer cancellation policy: a
+// confirmed booking can be cancelled until CANCELLATION_CUTOFF before the
+// show, as long as nobody has been admitted on it.
+func BookingCancellationEligibility(booking models.Booking, session models.MovieSession, now time.Time) CancellationEligibility {
+	e := CancellationEligibility{Deadline: session.StartTime.Add(-config.AppConfig.CancellationCutoff)}
+	switch {
+	case booking.Status != models.BookingStatusConfirmed:
+		e.Reason = CancelBlockedNotConfirmed
+	case session.Status == models.SessionCancelled:
+		e.Reason = CancelBlockedSession
+	case !now.Before(session.StartTime):
+		e.Reason = CancelBlockedStarted
+	case !now.Before(e.Deadline):
+		e.Reason = CancelBlockedCutoff
+	case len(booking.Admissions) > 0:
+		e.Reason = CancelBlockedAdmitted
+	default:
+		e.Cancellable = true
+	}
+	return e
+}
+
+// NotCancellableError is returned when the cancellation policy refuses a
+// cancellation. Reason is one of the CancelBlocked* codes.
+type NotCancellableError struct {
+	Reason string
+}
+
+func (e *NotCancellableError) Error() string {
+	switch e.Reason {
+	case CancelBlockedNotConfirmed:
+		return "Booking is not confirmed"
+	case CancelBlockedStarted:
+		return "Show has already started"
+	case CancelBlockedCutoff:
+		return "Cancellation window has closed"
+	case CancelBlockedAdmitted:
+		return "Tickets have already been used"
+	case CancelBlockedSession:
+		return "The session was cancelled and this booking is being refunded"
+	}
+	return "Booking cannot be cancelled"
+}
+
+// confirmStore is the MongoDB side of Confirm. It sits behind an interface
+// so the confirmation steps can be tested without a replica set.
+type confirmStore interface {
+	// WithTransaction runs fn in one transaction; nothing fn wrote is kept
+	// unless it returns nil and the commit succeeds.
+	WithTransaction(ctx context.Context, fn func(txn context.Context) error) error
+	LoadSession(txn context.Context, sessionID primitive.ObjectID) (models.MovieSession, error)
+	// BookSeats flips seatIDs to BOOKED and reports false, changing nothing,
+	// when the session is no longer as read or a seat was taken meanwhile.
+	BookSeats(txn context.Context, session models.MovieSession, seatIDs []string) (bool, error)
+	InsertBooking(txn context.Context, booking *models.Booking) error
+}
+
+// seatUnlocker releases the Redis locks of booked seats.
+type seatUnlocker interface {
+	UnlockMultipleSeats(ctx context.Context, sessionID string, seatIDs []string, userID string) error
+}
+
+type BookingService struct {
+	bookings     *mongo.Collection
+	sessions     *mongo.Collection
+	locks        *RedisLockService
+	seatMaps     *SeatMapCache
+	payments     PaymentGateway
+	eventService *EventProducerService
+
+	confirms confirmStore
+	unlocker seatUnlocker
+}
+
+func NewBookingService(eventService *EventProducerService, payments PaymentGateway) *BookingService {
+	bookings := config.MongoDB.Collection("bookings")
+	sessions := config.MongoDB.Collection("sessions")
+	locks := NewRedisLockService()
+	return &BookingService{
+		bookings:     bookings,
+		sessions:     sessions,
+		locks:        locks,
+		seatMaps:     NewSeatMapCache(),
+		payments:     payments,
+		eventService: eventService,
+		confirms:     &mongoConfirmStore{bookings: bookings, sessions: sessions},
+		unlocker:     locks,
+	}
+}
+
+// DefaultSeatPrice is charged for seats that have no price of their own.
+const DefaultSeatPrice = 150.0
+
+// SeatsTotal is the price of seatIDs in a session.
+func SeatsTotal(session models.MovieSession, seatIDs []string) float64 {
+	prices := make(map[string]float64, len(session.Seats))
+	for _, seat := range session.Seats {
+		prices[seat.ID] = seat.Price
+	}
+	total := 0.0
+	for _, seatID := range seatIDs {
+		if price := prices[seatID]; price > 0 {
+			total += price
+		} else {
+			total += DefaultSeatPrice
+		}
+	}
+	return total
+}
+
+// Confirm books the seats and inserts the booking in one MongoDB transaction.
+// The seats are flipped to BOOKED by a single update that only matches if
+// the session is still at the version that was read and none of the seats is
+// BOOKED, so a seat can never be sold twice even if its Redis lock was lost.
+// A session that is no longer on sale fails with SessionNotOnSaleError.
+// The booking ID is assigned up front so a retried transaction inserts the
+// same document. The customer's seat locks are only released once the
+// booking is committed; if confirmation fails they still hold the seats and
+// can retry. Transactions need MongoDB to run as a replica set.
+func (s *BookingService) Confirm(ctx context.Context, booking *models.Booking) error {
+	if booking.ID.IsZero() {
+		booking.ID = primitive.NewObjectID()
+	}
+
+	err := s.confirms.WithTransaction(ctx, func(txn context.Context) error {
+		session, err := s.confirms.LoadSession(txn, booking.SessionID)
+		if err != nil {
+			return fmt.Errorf("failed to load session: %w", err)
+		}
+		if err := CheckSessionOnSale(session, time.Now().UTC(), config.AppConfig.SalesCutoff); err != nil {
+			return err
+		}
+		if err := checkSeatsBookable(session, booking.Seats); err != nil {
+			return err
+		}
+
+		booked, err := s.confirms.BookSeats(txn, session, booking.Seats)
+		if err != nil {
+			return fmt.Errorf("failed to book seats: %w", err)
+		}
+		if !booked {
+			return &SessionConflictError{SessionID: booking.SessionID.Hex(), Version: session.Version}
+		}
+
+		if err := s.confirms.InsertBooking(txn, booking); err != nil {
+			return fmt.Errorf("failed to insert booking: %w", err)
+		}
+		return nil
+	})
+
+	if err == nil {
+		InvalidateSeatMap(ctx, booking.SessionID.Hex())
+		s.unlocker.UnlockMultipleSeats(ctx, booking.SessionID.Hex(), booking.Seats, booking.UserID)
+	}
+
+	var unavailable *SeatUnavailableError
+	var conflict *SessionConflictError
+	if errors.As(err, &unavailable) || errors.As(err, &conflict) {
+		eventCtx := events.WithCorrelationID(context.Background(), events.CorrelationID(ctx))
+		go s.eventService.LogSessionError(eventCtx, booking.SessionID.Hex(), SystemErrorBookingConflict, err.Error(), map[string]interface{}{
+			"bookingId": booking.ID.Hex(),
+			"userId":    booking.UserID,
+			"seatIds":   booking.Seats,
+		})
+	}
+	return err
+}
+
+// mongoConfirmStore is the confirmStore used outside tests.
+type mongoConfirmStore struct {
+	bookings *mongo.Collection
+	sessions *mongo.Collection
+}
+
+func (m *mongoConfirmStore) WithTransaction(ctx context.Context, fn func(txn context.Context) error) error {
+	return runTransaction(ctx, m.bookings.Database().Client(), func(txn mongo.SessionContext) error {
+		return fn(txn)
+	})
+}
+
+func (m *mongoConfirmStore) LoadSession(txn context.Context, sessionID primitive.ObjectID) (models.MovieSession, error) {
+	var session models.MovieSession
+	err := m.sessions.FindOne(txn, bson.M{"_id": sessionID},
+		options.FindOne().SetProjection(bson.M{
+			"seats.id": 1, "seats.status": 1, "version": 1,
+			"status": 1, "startTime": 1, "endTime": 1, "salesOpenAt": 1,
+		}),
+	).Decode(&session)
+	return session, err
+}
+
+func (m *mongoConfirmStore) BookSeats(txn context.Context, session models.MovieSession, seatIDs []string) (bool, error) {
+	result, err := m.sessions.UpdateOne(txn,
+		bookableFilter(session, seatIDs),
+		bson.M{
+			"$set": bson.M{"seats.$[seat].status": models.SeatBooked, "updatedAt": time.Now().UTC()},
+			"$inc": bson.M{"version": 1},
+		},
+		options.Update().SetArrayFilters(options.ArrayFilters{
+			Filters: []interface{}{bson.M{"seat.id": bson.M{"$in": seatIDs}}},
+		}),
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount > 0, nil
+}
+
+func (m *mongoConfirmStore) InsertBooking(txn context.Context, booking *models.Booking) error {
+	_, err := m.bookings.InsertOne(txn, booking)
+	return err
+}
+
+// checkSeatsBookable reports the first requested seat that does not exist in
+// the session or is already BOOKED.
+func checkSeatsBookable(session models.MovieSession, seatIDs []string) error {
+	status := make(map[string]models.SeatStatus, len(session.Seats))
+	for _, seat := range session.Seats {
+		status[seat.ID] = seat.Status
+	}
+	for _, seatID := range seatIDs {
+		if st, ok := status[seatID]; !ok || st == models.SeatBooked || st == models.SeatBlocked {
+			return &SeatUnavailableError{SeatID: seatID}
+		}
+	}
+	return nil
+}
+
+// bookableFilter matches the session only at the version that was read and
+// only while every one of seatIDs is still neither BOOKED nor BLOCKED.
+// Sessions written before versioning have no version field and count as
+// version 0.
+func bookableFilter(session models.MovieSession, seatIDs []string) bson.M {
+	conditions := []bson.M{{"_id": session.ID}}
+	if session.Version == 0 {
+		conditions = append(conditions, bson.M{"$or": []bson.M{
+			{"version": 0},
+			{"version": bson.M{"$exists": false}},
+		}})
+	} else {
+		conditions = append(conditions, bson.M{"version": session.Version})
+	}
+	for _, seatID := range seatIDs {
+		conditions = append(conditions, bson.M{"seats": bson.M{"$elemMatch": bson.M{
+			"id":     seatID,
+			"status": bson.M{"$nin": []models.SeatStatus{models.SeatBooked, models.SeatBlocked}},
+		}}})
+	}
+	return bson.M{"$and": conditions}
+}
+
+// withTransaction runs fn in a snapshot transaction with majority writes,
+// retrying it on transient errors.
+func (s *BookingService) withTransaction(ctx context.Context, fn func(txn mongo.SessionContext) error) error {
+	return runTransaction(ctx, s.bookings.Database().Client(), fn)
+}
+
+func runTransaction(ctx context.Context, client *mongo.Client, fn func(txn mongo.SessionContext) error) error {
+	session, err := client.StartSession()
+	if err != nil {
+		return fmt.Errorf("failed to start session: %w", err)
+	}
+	defer session.EndSession(ctx)
+
+	txnOpts := options.Transaction().
+		SetReadConcern(readconcern.Snapshot()).
+		SetWriteConcern(writeconcern.Majority())
+
+	_, err = session.WithTransaction(ctx, func(txn mongo.SessionContext) (interface{}, error) {
+		return nil, fn(txn)
+	}, txnOpts)
+	return err
+}
+
+// GetForUser returns a booking with its session, or ErrBookingNotFound when
+// it does not exist or belongs to someone else.
+func (s *BookingService) GetForUser(ctx context.Context, bookingID, userID string) (*models.Booking, *models.MovieSession, error) {
+	oid, err := primitive.ObjectIDFromHex(bookingID)
+	if err != nil {
+		return nil, nil, ErrBookingNotFound
+	}
+
+	var booking models.Booking
+	if err := s.bookings.FindOne(ctx, bson.M{"_id": oid, "userId": userID}).Decode(&booking); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil, ErrBookingNotFound
+		}
+		return nil, nil, err
+	}
+
+	var session models.MovieSession
+	err = s.sessions.FindOne(ctx, bson.M{"_id": booking.SessionID}, sessionWithoutSeats()).Decode(&session)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil, err
+	}
+	return &booking, &session, nil
+}
+
+// Cancel cancels a customer's own booking and frees its seats in one
+// transaction. The status update is conditional, so a booking is only ever
+// cancelled once.
+func (s *BookingService) Cancel(ctx context.Context, bookingID, userID, reason string) (*models.Booking, *models.MovieSession, error) {
+	booking, session, err := s.GetForUser(ctx, bookingID, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	now := time.Now().UTC()
+	if e := BookingCancellationEligibility(*booking, *session, now); !e.Cancellable {
+		return nil, nil, &NotCancellableError{Reason: e.Reason}
+	}
+	if reason == "" {
+		reason = "cancelled by customer"
+	}
+
+	err = s.withTransaction(ctx, func(txn mongo.SessionContext) error {
+		result, err := s.bookings.UpdateOne(txn,
+			bson.M{"_id": booking.ID, "status": models.BookingStatusConfirmed, "admissions.0": bson.M{"$exists": false}},
+			bson.M{"$set": bson.M{
+				"status":       models.BookingStatusCancelled,
+				"cancelledAt":  now,
+				"cancelReason": reason,
+			}},
+		)
+		if err != nil {
+			return err
+		}
+		if result.ModifiedCount == 0 {
+			return &NotCancellableError{Reason: CancelBlockedNotConfirmed}
+		}
+
+		for _, seatID := range booking.Seats {
+			_, err := s.sessions.UpdateOne(txn,
+				bson.M{"_id": booking.SessionID, "seats": bson.M{"$elemMatch": bson.M{"id": seatID, "status": models.SeatBooked}}},
+				bson.M{
+					"$set": bson.M{"seats.$.status": models.SeatAvailable, "updatedAt": now},
+					"$inc": bson.M{"version": 1},
+				},
+			)
+			if err != nil {
+				return fmt.Errorf("failed to release seat %s: %w", seatID, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	InvalidateSeatMap(ctx, booking.SessionID.Hex())
+	booking.Status = models.BookingStatusCancelled
+	booking.CancelledAt = &now
+	booking.CancelReason = reason
+
+	eventCtx := events.WithCorrelationID(context.Background(), events.CorrelationID(ctx))
+	go s.eventService.LogBookingCancelled(eventCtx, booking.ID.Hex(), booking.SessionID.Hex(), booking.UserID, booking.Seats, reason)
+
+	return booking, session, nil
+}
+
+func sessionWithoutSeats() *options.FindOneOptions {
+	return options.FindOne().SetProjection(bson.M{"seats": 0})
+}
@@ -0,0 +1,153 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"cinema-booking-system/config"
+	"cinema-booking-system/eventbus"
+	"cinema-booking-system/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// testMongoDB connects to the replica set in MONGO_TEST_URI and returns a
+// fresh database that is dropped when the test ends. Tests that need it are
+// skipped when the variable is not set.
+func testMongoDB(t *testing.T) *mongo.Database {
+	t.Helper()
+	uri := os.Getenv("MONGO_TEST_URI")
+	if uri == "" {
+		t.Skip("MONGO_TEST_URI not set; skipping test against a MongoDB replica set")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	if err != nil {
+		t.Fatalf("connect to MongoDB: %v", err)
+	}
+	if err := client.Ping(ctx, nil); err != nil {
+		t.Fatalf("ping MongoDB: %v", err)
+	}
+
+	db := client.Database("booking_test_" + primitive.NewObjectID().Hex())
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		db.Drop(ctx)
+		client.Disconnect(ctx)
+	})
+	// Collections cannot be created inside a transaction on older servers.
+	for _, name := range []string{"sessions", "bookings"} {
+		if err := db.CreateCollection(ctx, name); err != nil {
+			t.Fatalf("create %s: %v", name, err)
+		}
+	}
+	return db
+}
+
+// TestConfirmSellsASeatOnlyOnceInMongoDB races many confirmations for one
+// seat through the real store: bookableFilter's version and status guard,
+// inside snapshot transactions that are retried on write conflicts.
+func TestConfirmSellsASeatOnlyOnceInMongoDB(t *testing.T) {
+	db := testMongoDB(t)
+	previous := config.AppConfig
+	config.AppConfig = &config.Config{SalesCutoff: 15 * time.Minute}
+	t.Cleanup(func() { config.AppConfig = previous })
+
+	ctx := context.Background()
+	now := time.Now().UTC()
+	session := models.MovieSession{
+		ID:        primitive.NewObjectID(),
+		StartTime: now.Add(2 * time.Hour),
+		EndTime:   now.Add(4 * time.Hour),
+		Seats: []models.Seat{
+			{ID: "A1", Row: "A", Number: 1, Status: models.SeatAvailable},
+			{ID: "A2", Row: "A", Number: 2, Status: models.SeatAvailable},
+		},
+	}
+	if _, err := db.Collection("sessions").InsertOne(ctx, session); err != nil {
+		t.Fatalf("insert session: %v", err)
+	}
+
+	bus := eventbus.NewMemoryBus(1)
+	t.Cleanup(func() { bus.Close() })
+	unlocker := &fakeUnlocker{steps: &stepLog{}}
+	service := &BookingService{
+		confirms:     &mongoConfirmStore{bookings: db.Collection("bookings"), sessions: db.Collection("sessions")},
+		unlocker:     unlocker,
+		eventService: NewEventProducerServiceWithBus(bus, pipelineTopic),
+	}
+
+	const attempts = 20
+	errs := make([]error, attempts)
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			errs[i] = service.Confirm(ctx, &models.Booking{
+				SessionID: session.ID,
+				UserID:    fmt.Sprintf("user-%d", i),
+				Seats:     []string{"A1"},
+				Status:    models.BookingStatusConfirmed,
+			})
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+
+	succeeded := 0
+	for i, err := range errs {
+		if err == nil {
+			succeeded++
+			continue
+		}
+		var unavailable *SeatUnavailableError
+		var conflict *SessionConflictError
+		if !errors.As(err, &unavailable) && !errors.As(err, &conflict) {
+			t.Errorf("attempt %d: err = %v, want SeatUnavailableError or SessionConflictError", i, err)
+		}
+	}
+	if succeeded != 1 {
+		t.Fatalf("%d confirmations succeeded, want exactly 1", succeeded)
+	}
+
+	bookings, err := db.Collection("bookings").CountDocuments(ctx, bson.M{"sessionId": session.ID})
+	if err != nil {
+		t.Fatalf("count bookings: %v", err)
+	}
+	if bookings != 1 {
+		t.Errorf("%d bookings stored, want 1", bookings)
+	}
+	var stored models.MovieSession
+	if err := db.Collection("sessions").FindOne(ctx, bson.M{"_id": session.ID}).Decode(&stored); err != nil {
+		t.Fatalf("load session: %v", err)
+	}
+	if stored.Version != 1 {
+		t.Errorf("session version = %d, want 1 (one successful update)", stored.Version)
+	}
+	for _, seat := range stored.Seats {
+		want := models.SeatAvailable
+		if seat.ID == "A1" {
+			want = models.SeatBooked
+		}
+		if seat.Status != want {
+			t.Errorf("seat %s is %s, want %s", seat.ID, seat.Status, want)
+		}
+	}
+	if len(unlocker.unlocked) != 1 {
+		t.Errorf("locks released %d times, want once", len(unlocker.unlocked))
+	}
+}
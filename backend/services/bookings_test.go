@@ -0,0 +1,476 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"cinema-booking-system/config"
+	"cinema-booking-system/eventbus"
+	"cinema-booking-system/events"
+	"cinema-booking-system/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// fakeConfirmStore is an in-memory confirmStore. Writes made inside a
+// transaction are undone if it fails, and BookSeats applies the same
+// conditions as bookableFilter atomically, like a single MongoDB update.
+type fakeConfirmStore struct {
+	mu       sync.Mutex
+	session  models.MovieSession
+	bookings []models.Booking
+	steps    *stepLog
+
+	loadErr   error
+	insertErr error
+	commitErr error
+	// afterLoad runs between reading the session and booking its seats.
+	afterLoad func()
+}
+
+type fakeTxnKey struct{}
+
+type fakeTxn struct {
+	undo []func()
+}
+
+func (f *fakeConfirmStore) WithTransaction(ctx context.Context, fn func(txn context.Context) error) error {
+	txn := &fakeTxn{}
+	err := fn(context.WithValue(ctx, fakeTxnKey{}, txn))
+	if err == nil {
+		err = f.commitErr
+	}
+	if err != nil {
+		f.mu.Lock()
+		for i := len(txn.undo) - 1; i >= 0; i-- {
+			txn.undo[i]()
+		}
+		f.mu.Unlock()
+		f.steps.add("abort")
+		return err
+	}
+	f.steps.add("commit")
+	return nil
+}
+
+func (f *fakeConfirmStore) LoadSession(txn context.Context, sessionID primitive.ObjectID) (models.MovieSession, error) {
+	f.steps.add("load")
+	if f.loadErr != nil {
+		return models.MovieSession{}, f.loadErr
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	session := f.session
+	session.Seats = append([]models.Seat(nil), f.session.Seats...)
+	if f.afterLoad != nil {
+		f.afterLoad()
+	}
+	return session, nil
+}
+
+func (f *fakeConfirmStore) BookSeats(txn context.Context, session models.MovieSession, seatIDs []string) (bool, error) {
+	f.steps.add("book")
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.session.Version != session.Version {
+		return false, nil
+	}
+	wanted := make(map[string]bool, len(seatIDs))
+	for _, id := range seatIDs {
+		wanted[id] = true
+	}
+	for _, seat := range f.session.Seats {
+		if wanted[seat.ID] && (seat.Status == models.SeatBooked || seat.Status == models.SeatBlocked) {
+			return false, nil
+		}
+	}
+
+	previous := append([]models.Seat(nil), f.session.Seats...)
+	previousVersion := f.session.Version
+	for i, seat := range f.session.Seats {
+		if wanted[seat.ID] {
+			f.session.Seats[i].Status = models.SeatBooked
+		}
+	}
+	f.session.Version++
+
+	t := txn.Value(fakeTxnKey{}).(*fakeTxn)
+	t.undo = append(t.undo, func() {
+		f.session.Seats = previous
+		f.session.Version = previousVersion
+	})
+	return true, nil
+}
+
+func (f *fakeConfirmStore) InsertBooking(txn context.Context, booking *models.Booking) error {
+	f.steps.add("insert")
+	if f.insertErr != nil {
+		return f.insertErr
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.bookings = append(f.bookings, *booking)
+	t := txn.Value(fakeTxnKey{}).(*fakeTxn)
+	t.undo = append(t.undo, func() { f.bookings = f.bookings[:len(f.bookings)-1] })
+	return nil
+}
+
+// stepLog records the order in which the store and the lock service were
+// used.
+type stepLog struct {
+	mu    sync.Mutex
+	steps []string
+}
+
+func (l *stepLog) add(step string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.steps = append(l.steps, step)
+}
+
+func (l *stepLog) list() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]string(nil), l.steps...)
+}
+
+type fakeUnlocker struct {
+	steps    *stepLog
+	mu       sync.Mutex
+	unlocked [][]string
+}
+
+func (u *fakeUnlocker) UnlockMultipleSeats(ctx context.Context, sessionID string, seatIDs []string, userID string) error {
+	u.steps.add("unlock")
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.unlocked = append(u.unlocked, seatIDs)
+	return nil
+}
+
+type confirmFixture struct {
+	service  *BookingService
+	store    *fakeConfirmStore
+	unlocker *fakeUnlocker
+	steps    *stepLog
+	bus      *eventbus.MemoryBus
+}
+
+func newConfirmFixture(t *testing.T) *confirmFixture {
+	t.Helper()
+	previous := config.AppConfig
+	config.AppConfig = &config.Config{SalesCutoff: 15 * time.Minute}
+	t.Cleanup(func() { config.AppConfig = previous })
+
+	now := time.Now().UTC()
+	session := models.MovieSession{
+		ID:        primitive.NewObjectID(),
+		StartTime: now.Add(2 * time.Hour),
+		EndTime:   now.Add(4 * time.Hour),
+		Version:   3,
+		Seats: []models.Seat{
+			{ID: "A1", Row: "A", Number: 1, Status: models.SeatAvailable},
+			{ID: "A2", Row: "A", Number: 2, Status: models.SeatAvailable},
+			{ID: "A3", Row: "A", Number: 3, Status: models.SeatBooked},
+			{ID: "A4", Row: "A", Number: 4, Status: models.SeatBlocked},
+		},
+	}
+
+	steps := &stepLog{}
+	bus := eventbus.NewMemoryBus(1)
+	t.Cleanup(func() { bus.Close() })
+	store := &fakeConfirmStore{session: session, steps: steps}
+	unlocker := &fakeUnlocker{steps: steps}
+	return &confirmFixture{
+		service: &BookingService{
+			confirms:     store,
+			unlocker:     unlocker,
+			eventService: NewEventProducerServiceWithBus(bus, pipelineTopic),
+		},
+		store:    store,
+		unlocker: unlocker,
+		steps:    steps,
+		bus:      bus,
+	}
+}
+
+func (f *confirmFixture) booking(seatIDs ...string) *models.Booking {
+	return &models.Booking{
+		SessionID: f.store.session.ID,
+		UserID:    "user-1",
+		Seats:     seatIDs,
+		Status:    models.BookingStatusConfirmed,
+	}
+}
+
+func (f *confirmFixture) seatStatus(seatID string) models.SeatStatus {
+	f.store.mu.Lock()
+	defer f.store.mu.Unlock()
+	for _, seat := range f.store.session.Seats {
+		if seat.ID == seatID {
+			return seat.Status
+		}
+	}
+	return ""
+}
+
+// conflictEvents returns the BOOKING_CONFLICT system errors published so far.
+func (f *confirmFixture) conflictEvents(t *testing.T) []models.AuditLog {
+	t.Helper()
+	var found []models.AuditLog
+	err := f.bus.Replay(context.Background(), pipelineTopic, eventbus.Position{}, func(ctx context.Context, msg eventbus.Message) error {
+		l, err := DecodeAuditLog(events.Default, msg.Value)
+		if err != nil {
+			return err
+		}
+		if l.EventType == events.TypeSystemError && l.Payload["errorType"] == SystemErrorBookingConflict {
+			found = append(found, l)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("replay events: %v", err)
+	}
+	return found
+}
+
+func TestConfirmBooksSeatsAndReleasesLocksAfterCommit(t *testing.T) {
+	f := newConfirmFixture(t)
+	booking := f.booking("A1", "A2")
+
+	if err := f.service.Confirm(context.Background(), booking); err != nil {
+		t.Fatalf("Confirm: %v", err)
+	}
+
+	if booking.ID.IsZero() {
+		t.Error("booking ID was not assigned")
+	}
+	if got, want := fmt.Sprint(f.steps.list()), "[load book insert commit unlock]"; got != want {
+		t.Errorf("steps = %s, want %s", got, want)
+	}
+	for _, seatID := range []string{"A1", "A2"} {
+		if st := f.seatStatus(seatID); st != models.SeatBooked {
+			t.Errorf("seat %s is %s, want BOOKED", seatID, st)
+		}
+	}
+	if len(f.store.bookings) != 1 || f.store.bookings[0].ID != booking.ID {
+		t.Errorf("stored bookings = %+v, want the confirmed booking", f.store.bookings)
+	}
+	if f.store.session.Version != 4 {
+		t.Errorf("session version = %d, want 4", f.store.session.Version)
+	}
+}
+
+func TestConfirmFailures(t *testing.T) {
+	storeErr := errors.New("mongo unavailable")
+
+	tests := []struct {
+		name      string
+		seats     []string
+		setup     func(f *confirmFixture)
+		wantSteps string
+		check     func(t *testing.T, err error)
+		conflict  bool
+		// wantVersion is the session version afterwards, when someone else
+		// changed it.
+		wantVersion int64
+	}{
+		{
+			name:      "session load fails",
+			seats:     []string{"A1"},
+			setup:     func(f *confirmFixture) { f.store.loadErr = storeErr },
+			wantSteps: "[load abort]",
+			check: func(t *testing.T, err error) {
+				if !errors.Is(err, storeErr) {
+					t.Errorf("err = %v, want the load error", err)
+				}
+			},
+		},
+		{
+			name:      "seat already booked",
+			seats:     []string{"A1", "A3"},
+			wantSteps: "[load abort]",
+			check: func(t *testing.T, err error) {
+				var unavailable *SeatUnavailableError
+				if !errors.As(err, &unavailable) || unavailable.SeatID != "A3" {
+					t.Errorf("err = %v, want SeatUnavailableError for A3", err)
+				}
+			},
+			conflict: true,
+		},
+		{
+			name:      "seat blocked",
+			seats:     []string{"A4"},
+			wantSteps: "[load abort]",
+			check: func(t *testing.T, err error) {
+				var unavailable *SeatUnavailableError
+				if !errors.As(err, &unavailable) {
+					t.Errorf("err = %v, want SeatUnavailableError", err)
+				}
+			},
+			conflict: true,
+		},
+		{
+			name:  "seat flip conflict",
+			seats: []string{"A1"},
+			setup: func(f *confirmFixture) {
+				// Another booking commits between the read and the update.
+				f.store.afterLoad = func() {
+					f.store.session.Seats[1].Status = models.SeatBooked
+					f.store.session.Version++
+				}
+			},
+			wantVersion: 4,
+			wantSteps:   "[load book abort]",
+			check: func(t *testing.T, err error) {
+				var conflict *SessionConflictError
+				if !errors.As(err, &conflict) {
+					t.Errorf("err = %v, want SessionConflictError", err)
+				}
+			},
+			conflict: true,
+		},
+		{
+			name:      "booking insert fails",
+			seats:     []string{"A1"},
+			setup:     func(f *confirmFixture) { f.store.insertErr = storeErr },
+			wantSteps: "[load book insert abort]",
+			check: func(t *testing.T, err error) {
+				if !errors.Is(err, storeErr) {
+					t.Errorf("err = %v, want the insert error", err)
+				}
+			},
+		},
+		{
+			name:      "commit aborts",
+			seats:     []string{"A1"},
+			setup:     func(f *confirmFixture) { f.store.commitErr = storeErr },
+			wantSteps: "[load book insert abort]",
+			check: func(t *testing.T, err error) {
+				if !errors.Is(err, storeErr) {
+					t.Errorf("err = %v, want the commit error", err)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newConfirmFixture(t)
+			if tt.setup != nil {
+				tt.setup(f)
+			}
+			wantVersion := f.store.session.Version
+			if tt.wantVersion != 0 {
+				wantVersion = tt.wantVersion
+			}
+
+			err := f.service.Confirm(context.Background(), f.booking(tt.seats...))
+			if err == nil {
+				t.Fatal("Confirm succeeded, want an error")
+			}
+			tt.check(t, err)
+
+			if got := fmt.Sprint(f.steps.list()); got != tt.wantSteps {
+				t.Errorf("steps = %s, want %s", got, tt.wantSteps)
+			}
+			if len(f.unlocker.unlocked) != 0 {
+				t.Errorf("locks released after a failed confirmation: %v", f.unlocker.unlocked)
+			}
+			if st := f.seatStatus("A1"); st != models.SeatAvailable {
+				t.Errorf("seat A1 is %s after rollback, want AVAILABLE", st)
+			}
+			if len(f.store.bookings) != 0 || f.store.session.Version != wantVersion {
+				t.Errorf("writes survived the failed transaction: %d bookings, version %d", len(f.store.bookings), f.store.session.Version)
+			}
+
+			if tt.conflict {
+				waitFor(t, "a BOOKING_CONFLICT event", func() bool { return len(f.conflictEvents(t)) == 1 })
+			} else if n := len(f.conflictEvents(t)); n != 0 {
+				t.Errorf("%d BOOKING_CONFLICT events for a non-conflict failure", n)
+			}
+		})
+	}
+}
+
+// TestConfirmHandlesConcurrentLosersWithFakeStore covers Confirm's side of a
+// race: only the attempt the store lets through is booked and unlocked, and
+// every other one fails as a conflict with a BOOKING_CONFLICT event. The fake
+// store serialises on a mutex, so this says nothing about the MongoDB guard
+// itself; TestConfirmSellsASeatOnlyOnceInMongoDB covers that.
+func TestConfirmHandlesConcurrentLosersWithFakeStore(t *testing.T) {
+	f := newConfirmFixture(t)
+
+	const attempts = 50
+	errs := make([]error, attempts)
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			booking := f.booking("A1")
+			booking.UserID = fmt.Sprintf("user-%d", i)
+			errs[i] = f.service.Confirm(context.Background(), booking)
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+
+	succeeded := 0
+	for i, err := range errs {
+		if err == nil {
+			succeeded++
+			continue
+		}
+		var unavailable *SeatUnavailableError
+		var conflict *SessionConflictError
+		if !errors.As(err, &unavailable) && !errors.As(err, &conflict) {
+			t.Errorf("attempt %d: err = %v, want SeatUnavailableError or SessionConflictError", i, err)
+		}
+	}
+	if succeeded != 1 {
+		t.Fatalf("%d confirmations succeeded, want exactly 1", succeeded)
+	}
+	if len(f.store.bookings) != 1 {
+		t.Errorf("%d bookings stored, want 1", len(f.store.bookings))
+	}
+	if len(f.unlocker.unlocked) != 1 {
+		t.Errorf("locks released %d times, want once", len(f.unlocker.unlocked))
+	}
+	waitFor(t, "a BOOKING_CONFLICT event per losing attempt", func() bool {
+		return len(f.conflictEvents(t)) == attempts-1
+	})
+}
+
+func TestBookableFilterRequiresVersionAndFreeSeats(t *testing.T) {
+	session := models.MovieSession{ID: primitive.NewObjectID(), Version: 5}
+	conditions := bookableFilter(session, []string{"A1", "A2"})["$and"].([]bson.M)
+
+	if len(conditions) != 4 {
+		t.Fatalf("%d conditions, want id, version and one per seat", len(conditions))
+	}
+	if conditions[0]["_id"] != session.ID || conditions[1]["version"] != int64(5) {
+		t.Errorf("filter does not pin the session version: %v", conditions[:2])
+	}
+	for i, seatID := range []string{"A1", "A2"} {
+		match := conditions[2+i]["seats"].(bson.M)["$elemMatch"].(bson.M)
+		excluded := match["status"].(bson.M)["$nin"].([]models.SeatStatus)
+		if match["id"] != seatID || len(excluded) != 2 {
+			t.Errorf("seat condition %d = %v, want %s neither BOOKED nor BLOCKED", i, match, seatID)
+		}
+	}
+
+	unversioned := bookableFilter(models.MovieSession{ID: session.ID}, []string{"A1"})["$and"].([]bson.M)
+	if _, ok := unversioned[1]["$or"]; !ok {
+		t.Errorf("sessions without a version must also match a missing field: %v", unversioned[1])
+	}
+}
@@ -0,0 +1,101 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"cinema-booking-system/models"
+)
+
+const (
+	CalendarContentType     = "text/calendar; charset=UTF-8"
+	defaultSessionRunLength = 2 * time.Hour
+)
+
+type BookingCalendarEntry struct {
+	Booking models.Booking
+	Session models.MovieSession
+}
+
+// BuildBookingCalendar renders bookings as an RFC 5545 calendar. Each booking
+// keeps the same UID everywhere it appears, so a cancelled booking replaces
+// the event a client imported earlier instead of duplicating it.
+func BuildBookingCalendar(name string, entries []BookingCalendarEntry) []byte {
+	now := time.Now().UTC()
+
+	var buf bytes.Buffer
+	writeICSLine(&buf, "BEGIN:VCALENDAR")
+	writeICSLine(&buf, "VERSION:2.0")
+	writeICSLine(&buf, "PRODID:-//Cinema Booking System//Bookings//EN")
+	writeICSLine(&buf, "CALSCALE:GREGORIAN")
+	writeICSLine(&buf, "METHOD:PUBLISH")
+	writeICSLine(&buf, "X-WR-CALNAME:"+escapeICSText(name))
+
+	for _, e := range entries {
+		start := e.Session.StartTime.UTC()
+		end := e.Session.EndTime.UTC()
+		if !end.After(start) {
+			end = start.Add(defaultSessionRunLength)
+		}
+
+		status, sequence := "CONFIRMED", 0
+		if e.Booking.Status == models.BookingStatusCancelled {
+			status, sequence = "CANCELLED", 1
+		}
+
+		writeICSLine(&buf, "BEGIN:VEVENT")
+		writeICSLine(&buf, "UID:"+e.Booking.ID.Hex()+"@cinema-booking-system")
+		writeICSLine(&buf, "DTSTAMP:"+formatICSTime(now))
+		writeICSLine(&buf, "DTSTART:"+formatICSTime(start))
+		writeICSLine(&buf, "DTEND:"+formatICSTime(end))
+		writeICSLine(&buf, "SUMMARY:"+escapeICSText("🎬 "+e.Session.MovieTitle))
+		writeICSLine(&buf, "LOCATION:"+escapeICSText(e.Session.Theater))
+		writeICSLine(&buf, "DESCRIPTION:"+escapeICSText(fmt.Sprintf(
+			"Booking ID: %s\nSeats: %s\nPlease arrive 15 minutes before showtime.",
+			e.Booking.ID.Hex(), strings.Join(e.Booking.Seats, ", "),
+		)))
+		writeICSLine(&buf, "STATUS:"+status)
+		writeICSLine(&buf, fmt.Sprintf("SEQUENCE:%d", sequence))
+		writeICSLine(&buf, "TRANSP:OPAQUE")
+		if status == "CONFIRMED" {
+			writeICSLine(&buf, "BEGIN:VALARM")
+			writeICSLine(&buf, "ACTION:DISPLAY")
+			writeICSLine(&buf, "DESCRIPTION:"+escapeICSText(e.Session.MovieTitle))
+			writeICSLine(&buf, "TRIGGER:-PT1H")
+			writeICSLine(&buf, "END:VALARM")
+		}
+		writeICSLine(&buf, "END:VEVENT")
+	}
+
+	writeICSLine(&buf, "END:VCALENDAR")
+	return buf.Bytes()
+}
+
+func formatICSTime(t time.Time) string {
+	return t.UTC().Format("20060102T150405Z")
+}
+
+func escapeICSText(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`).Replace(s)
+}
+
+// writeICSLine folds content lines longer than 75 octets without splitting a
+// UTF-8 sequence, as RFC 5545 section 3.1 requires.
+func writeICSLine(buf *bytes.Buffer, line string) {
+	limit := 75
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		buf.WriteString(line[:cut])
+		buf.WriteString("\r\n ")
+		line = line[cut:]
+		limit = 74
+	}
+	buf.WriteString(line)
+	buf.WriteString("\r\n")
+}
@@ -0,0 +1,313 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math/rand"
+	"time"
+
+	"cinema-booking-system/config"
+	"cinema-booking-system/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	DefaultEmailMaxAttempts = 8
+	emailPollInterval       = 5 * time.Second
+	emailSendTimeout        = 2 * time.Minute
+	emailBackoffBase        = 30 * time.Second
+	emailBackoffMax         = time.Hour
+)
+
+var ErrEmailNotResendable = errors.New("email not found or currently being sent")
+
+// EmailOutboxService persists outgoing email in the email_outbox collection
+// and delivers it from a background worker, so a message survives restarts
+// and transient SMTP failures are retried with exponential backoff.
+type EmailOutboxService struct {
+	collection   *mongo.Collection
+	emailService *EmailService
+	wake         chan struct{}
+}
+
+func NewEmailOutboxService(emailService *EmailService) *EmailOutboxService {
+	s := &EmailOutboxService{
+		emailService: emailService,
+		wake:         make(chan struct{}, 1),
+	}
+	if config.MongoDB != nil {
+		s.collection = config.MongoDB.Collection("email_outbox")
+	}
+	return s
+}
+
+func (s *EmailOutboxService) EnsureIndexes(ctx context.Context) error {
+	_, err := s.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "nextAttemptAt", Value: 1}}},
+		{Keys: bson.D{{Key: "bookingId", Value: 1}}},
+		{
+			Keys: bson.D{{Key: "dedupeKey", Value: 1}},
+			Options: options.Index().SetUnique(true).
+				SetPartialFilterExpression(bson.M{"dedupeKey": bson.M{"$exists": true}}),
+		},
+	})
+	return err
+}
+
+func (s *EmailOutboxService) Enqueue(ctx context.Context, msg models.EmailMessage) (primitive.ObjectID, error) {
+	now := time.Now().UTC()
+	msg.ID = primitive.NewObjectID()
+	msg.Status = models.EmailQueued
+	msg.Attempts = 0
+	if msg.MaxAttempts == 0 {
+		msg.MaxAttempts = DefaultEmailMaxAttempts
+	}
+	msg.NextAttemptAt = now
+	msg.CreatedAt = now
+	msg.UpdatedAt = now
+
+	if _, err := s.collection.InsertOne(ctx, msg); err != nil {
+		if msg.DedupeKey != "" && mongo.IsDuplicateKeyError(err) {
+			var existing models.EmailMessage
+			if err := s.collection.FindOne(ctx, bson.M{"dedupeKey": msg.DedupeKey}).Decode(&existing); err != nil {
+				return primitive.NilObjectID, err
+			}
+			return existing.ID, nil
+		}
+		return primitive.NilObjectID, err
+	}
+
+	log.Printf("📬 Email queued: %s to %s", msg.Kind, msg.To)
+	s.notify()
+	return msg.ID, nil
+}
+
+// EnqueueBookingEmail renders one of the booking email templates in the
+// recipient's locale and queues it.
+func (s *EmailOutboxService) EnqueueBookingEmail(ctx context.Context, kind, to string, data BookingEmailData, attachments ...models.EmailAttachment) (primitive.ObjectID, error) {
+	return s.EnqueueBookingEmailOnce(ctx, "", kind, to, data, attachments...)
+}
+
+// EnqueueBookingEmailOnce is EnqueueBookingEmail with a dedupe key: queueing
+// the same key again returns the message that is already in the outbox.
+func (s *EmailOutboxService) EnqueueBookingEmailOnce(ctx context.Context, dedupeKey, kind, to string, data BookingEmailData, attachments ...models.EmailAttachment) (primitive.ObjectID, error) {
+	content, err := s.emailService.Render(kind, data.Locale, data)
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+	return s.Enqueue(ctx, models.EmailMessage{
+		Kind:        kind,
+		To:          to,
+		Locale:      data.Locale,
+		Subject:     content.Subject,
+		HTMLBody:    content.HTMLBody,
+		TextBody:    content.TextBody,
+		Attachments: attachments,
+		BookingID:   data.BookingID,
+		DedupeKey:   dedupeKey,
+	})
+}
+
+func (s *EmailOutboxService) Start(ctx context.Context) {
+	if s.collection == nil {
+		log.Println("⚠️ MongoDB not available, email outbox worker disabled")
+		return
+	}
+
+	if err := s.EnsureIndexes(ctx); err != nil {
+		log.Printf("⚠️ Failed to create email outbox indexes: %v", err)
+	}
+
+	log.Println("📬 Email outbox worker started")
+
+	ticker := time.NewTicker(emailPollInterval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			msg, err := s.claimNext(ctx)
+			if err != nil {
+				if ctx.Err() == nil {
+					log.Printf("⚠️ Failed to claim email: %v", err)
+				}
+				break
+			}
+			if msg == nil {
+				break
+			}
+			s.deliver(ctx, msg)
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Println("📬 Email outbox worker stopped")
+			return
+		case <-ticker.C:
+		case <-s.wake:
+		}
+	}
+}
+
+// claimNext atomically moves one due message to SENDING. Messages stuck in
+// SENDING past their lock (the worker died mid-send) are picked up again.
+func (s *EmailOutboxService) claimNext(ctx context.Context) (*models.EmailMessage, error) {
+	now := time.Now().UTC()
+	lockedUntil := now.Add(emailSendTimeout)
+
+	var msg models.EmailMessage
+	err := s.collection.FindOneAndUpdate(ctx,
+		bson.M{"$or": []bson.M{
+			{"status": models.EmailQueued, "nextAttemptAt": bson.M{"$lte": now}},
+			{"status": models.EmailSending, "lockedUntil": bson.M{"$lt": now}},
+		}},
+		bson.M{
+			"$set": bson.M{"status": models.EmailSending, "lockedUntil": lockedUntil, "updatedAt": now},
+			"$inc": bson.M{"attempts": 1},
+		},
+		options.FindOneAndUpdate().
+			SetSort(bson.D{{Key: "nextAttemptAt", Value: 1}}).
+			SetReturnDocument(options.After),
+	).Decode(&msg)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &msg, nil
+}
+
+func (s *EmailOutboxService) deliver(ctx context.Context, msg *models.EmailMessage) {
+	sendCtx, cancel := context.WithTimeout(ctx, emailSendTimeout)
+	mail := &Mail{
+		To:       msg.To,
+		Subject:  msg.Subject,
+		HTMLBody: msg.HTMLBody,
+		TextBody: msg.TextBody,
+	}
+	for _, a := range msg.Attachments {
+		mail.Attachments = append(mail.Attachments, Attachment{
+			Filename:    a.Filename,
+			ContentType: a.ContentType,
+			ContentID:   a.ContentID,
+			Data:        a.Data,
+		})
+	}
+	err := s.emailService.Send(sendCtx, mail)
+	cancel()
+	now := time.Now().UTC()
+
+	update := bson.M{"updatedAt": now}
+	unset := bson.M{"lockedUntil": ""}
+
+	switch {
+	case err == nil:
+		update["status"] = models.EmailSent
+		update["sentAt"] = now
+		unset["lastError"] = ""
+	case errors.Is(err, ErrEmailNotConfigured):
+		update["status"] = models.EmailFailed
+		update["lastError"] = err.Error()
+		log.Printf("📧 Email not sent (not configured): %s for %s", msg.Kind, msg.To)
+	case IsPermanentEmailError(err):
+		update["status"] = models.EmailBounced
+		update["lastError"] = err.Error()
+		log.Printf("❌ Email to %s bounced: %v", msg.To, err)
+	case msg.Attempts >= msg.MaxAttempts:
+		update["status"] = models.EmailFailed
+		update["lastError"] = err.Error()
+		log.Printf("❌ Email to %s failed after %d attempts: %v", msg.To, msg.Attempts, err)
+	default:
+		next := now.Add(emailBackoff(msg.Attempts))
+		update["status"] = models.EmailQueued
+		update["nextAttemptAt"] = next
+		update["lastError"] = err.Error()
+		log.Printf("⚠️ Email to %s failed (attempt %d/%d), retrying at %s: %v",
+			msg.To, msg.Attempts, msg.MaxAttempts, next.Format(time.RFC3339), err)
+	}
+
+	_, dbErr := s.collection.UpdateOne(ctx,
+		bson.M{"_id": msg.ID, "status": models.EmailSending},
+		bson.M{"$set": update, "$unset": unset},
+	)
+	if dbErr != nil {
+		log.Printf("⚠️ Failed to record email status for %s: %v", msg.ID.Hex(), dbErr)
+	}
+}
+
+func emailBackoff(attempt int) time.Duration {
+	d := emailBackoffBase
+	for i := 1; i < attempt && d < emailBackoffMax; i++ {
+		d *= 2
+	}
+	if d > emailBackoffMax {
+		d = emailBackoffMax
+	}
+	jitter := time.Duration(rand.Int63n(int64(d) / 5))
+	return d + jitter
+}
+
+func (s *EmailOutboxService) Resend(ctx context.Context, id primitive.ObjectID) error {
+	now := time.Now().UTC()
+	result, err := s.collection.UpdateOne(ctx,
+		bson.M{"_id": id, "status": bson.M{"$ne": models.EmailSending}},
+		bson.M{
+			"$set": bson.M{
+				"status":        models.EmailQueued,
+				"attempts":      0,
+				"nextAttemptAt": now,
+				"updatedAt":     now,
+			},
+			"$unset": bson.M{"lockedUntil": "", "sentAt": ""},
+		},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrEmailNotResendable
+	}
+
+	s.notify()
+	return nil
+}
+
+func (s *EmailOutboxService) List(ctx context.Context, statuses []models.EmailStatus, page, limit int) ([]models.EmailMessage, int64, error) {
+	filter := bson.M{}
+	if len(statuses) > 0 {
+		filter["status"] = bson.M{"$in": statuses}
+	}
+
+	total, err := s.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	cursor, err := s.collection.Find(ctx, filter,
+		options.Find().
+			SetSort(bson.D{{Key: "updatedAt", Value: -1}}).
+			SetSkip(int64((page-1)*limit)).
+			SetLimit(int64(limit)),
+	)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	emails := []models.EmailMessage{}
+	if err := cursor.All(ctx, &emails); err != nil {
+		return nil, 0, err
+	}
+	return emails, total, nil
+}
+
+func (s *EmailOutboxService) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,186 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	"cinema-booking-system/templates"
+)
+
+// BookingEmailData is the data available to every booking email template.
+// Fields that do not apply to a kind of email are left zero.
+type BookingEmailData struct {
+	Locale          string
+	UserName        string
+	BookingID       string
+	MovieTitle      string
+	Theater         string
+	Seats           []string
+	TotalAmount     float64
+	ShowTime        time.Time
+	Reason          string
+	RefundAmount    float64
+	RefundReference string
+	ChargeAmount    float64
+	// Updated marks a confirmation resent after the booking was exchanged.
+	Updated         bool
+	HoldExpiresAt   time.Time
+	CalendarURL     string
+	CalendarFeedURL string
+	TicketsURL      string
+	TicketPDFURL    string
+	Tickets         []TicketImage
+}
+
+type RenderedEmail struct {
+	Subject  string
+	HTMLBody string
+	TextBody string
+}
+
+type localizedTemplate struct {
+	html *htmltemplate.Template
+	text *texttemplate.Template
+}
+
+// EmailTemplates renders emails from a directory tree laid out as
+// <locale>/<kind>.html and <locale>/<kind>.txt. The .txt file defines the
+// "subject" template and the plaintext body; the .html file defines "content",
+// which is wrapped by <locale>/layout.html. Any other .html file in a locale
+// directory is a shared partial.
+type EmailTemplates struct {
+	byLocale map[string]map[string]*localizedTemplate
+}
+
+func LoadEmailTemplates() (*EmailTemplates, error) {
+	if dir := os.Getenv("EMAIL_TEMPLATE_DIR"); dir != "" {
+		return NewEmailTemplates(os.DirFS(dir))
+	}
+	sub, err := fs.Sub(templates.Email, "email")
+	if err != nil {
+		return nil, err
+	}
+	return NewEmailTemplates(sub)
+}
+
+func NewEmailTemplates(fsys fs.FS) (*EmailTemplates, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read email templates: %w", err)
+	}
+
+	t := &EmailTemplates{byLocale: make(map[string]map[string]*localizedTemplate)}
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		kinds, err := loadLocaleTemplates(fsys, e.Name())
+		if err != nil {
+			return nil, err
+		}
+		t.byLocale[e.Name()] = kinds
+	}
+
+	if _, ok := t.byLocale[DefaultLocale]; !ok {
+		return nil, fmt.Errorf("email templates for default locale %q not found", DefaultLocale)
+	}
+	return t, nil
+}
+
+func loadLocaleTemplates(fsys fs.FS, locale string) (map[string]*localizedTemplate, error) {
+	txtFiles, err := fs.Glob(fsys, locale+"/*.txt")
+	if err != nil {
+		return nil, err
+	}
+	htmlFiles, err := fs.Glob(fsys, locale+"/*.html")
+	if err != nil {
+		return nil, err
+	}
+
+	kinds := make(map[string]bool)
+	for _, f := range txtFiles {
+		kinds[strings.TrimSuffix(path.Base(f), ".txt")] = true
+	}
+
+	var partials []string
+	for _, f := range htmlFiles {
+		name := strings.TrimSuffix(path.Base(f), ".html")
+		if !kinds[name] && name != "layout" {
+			partials = append(partials, f)
+		}
+	}
+
+	funcs := templateFuncs(ResolveLocale(locale))
+	base, err := htmltemplate.New("layout.html").Funcs(funcs).ParseFS(fsys, append([]string{locale + "/layout.html"}, partials...)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s email layout: %w", locale, err)
+	}
+
+	out := make(map[string]*localizedTemplate)
+	for kind := range kinds {
+		html, err := base.Clone()
+		if err == nil {
+			html, err = html.ParseFS(fsys, locale+"/"+kind+".html")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s/%s.html: %w", locale, kind, err)
+		}
+
+		text, err := texttemplate.New(kind+".txt").Funcs(funcs).ParseFS(fsys, locale+"/"+kind+".txt")
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s/%s.txt: %w", locale, kind, err)
+		}
+		if text.Lookup("subject") == nil {
+			return nil, fmt.Errorf("%s/%s.txt does not define a subject", locale, kind)
+		}
+
+		out[kind] = &localizedTemplate{html: html, text: text}
+	}
+	return out, nil
+}
+
+func templateFuncs(l *Locale) map[string]interface{} {
+	return map[string]interface{}{
+		"money":    l.FormatMoney,
+		"date":     l.FormatDate,
+		"time":     l.FormatTime,
+		"datetime": l.FormatDateTime,
+		"seats":    func(seats []string) string { return strings.Join(seats, ", ") },
+	}
+}
+
+// Render renders one kind of email in the requested locale, falling back to
+// DefaultLocale when that locale has no variant of it.
+func (t *EmailTemplates) Render(kind, locale string, data interface{}) (*RenderedEmail, error) {
+	tmpl, ok := t.byLocale[ResolveLocale(locale).Tag][kind]
+	if !ok {
+		tmpl, ok = t.byLocale[DefaultLocale][kind]
+	}
+	if !ok {
+		return nil, fmt.Errorf("unknown email template %q", kind)
+	}
+
+	var subject, text, html bytes.Buffer
+	if err := tmpl.text.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return nil, fmt.Errorf("failed to render %s subject: %w", kind, err)
+	}
+	if err := tmpl.text.Execute(&text, data); err != nil {
+		return nil, fmt.Errorf("failed to render %s text body: %w", kind, err)
+	}
+	if err := tmpl.html.Execute(&html, data); err != nil {
+		return nil, fmt.Errorf("failed to render %s HTML body: %w", kind, err)
+	}
+
+	return &RenderedEmail{
+		Subject:  strings.TrimSpace(subject.String()),
+		HTMLBody: html.String(),
+		TextBody: strings.TrimSpace(text.String()) + "\n",
+	}, nil
+}
@@ -0,0 +1,247 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"cinema-booking-system/config"
+	"cinema-booking-system/eventbus"
+	"cinema-booking-system/events"
+	"cinema-booking-system/models"
+)
+
+type EventProducerService struct {
+	bus      eventbus.EventBus
+	topic    string
+	registry *events.Registry
+}
+
+func NewEventProducerService() *EventProducerService {
+	topic := "audit-logs"
+	if config.AppConfig != nil {
+		topic = config.AppConfig.KafkaTopic
+	}
+	return NewEventProducerServiceWithBus(config.EventBus, topic)
+}
+
+func NewEventProducerServiceWithBus(bus eventbus.EventBus, topic string) *EventProducerService {
+	return &EventProducerService{
+		bus:      bus,
+		topic:    topic,
+		registry: events.Default,
+	}
+}
+
+func (s *EventProducerService) Publish(ctx context.Context, payload events.Payload) error {
+	env, err := s.registry.NewEnvelope(ctx, payload)
+	if err != nil {
+		return err
+	}
+
+	if s.bus == nil {
+		log.Println("⚠️ Event bus not initialized, skipping audit log")
+		return nil
+	}
+
+	data, err := json.Marshal(env)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	msg := eventbus.Message{
+		Topic: s.topic,
+		Key:   []byte(payload.Subject().SessionID),
+		Value: data,
+		Headers: map[string]string{
+			"event_id":       env.EventID,
+			"event_type":     env.Type,
+			"schema_version": strconv.Itoa(env.SchemaVersion),
+			"timestamp":      env.OccurredAt.Format(time.RFC3339),
+		},
+	}
+
+	if err := s.bus.Publish(ctx, msg); err != nil {
+		return err
+	}
+
+	log.Printf("📝 Audit log sent: %s - %s", env.Type, payload.Describe())
+	return nil
+}
+
+func (s *EventProducerService) LogSeatLocked(ctx context.Context, sessionID, userID string, seatIDs []string) error {
+	return s.Publish(ctx, events.SeatsLocked{
+		SessionID: sessionID,
+		UserID:    userID,
+		SeatIDs:   seatIDs,
+		ExpiresAt: time.Now().UTC().Add(LockDuration),
+	})
+}
+
+func (s *EventProducerService) LogSeatUnlocked(ctx context.Context, sessionID, userID string, seatIDs []string, reason string) error {
+	return s.Publish(ctx, events.SeatsUnlocked{
+		SessionID: sessionID,
+		UserID:    userID,
+		SeatIDs:   seatIDs,
+		Reason:    reason,
+	})
+}
+
+func (s *EventProducerService) LogBookingSuccess(ctx context.Context, sessionID, userID string, seatIDs []string, bookingID string, totalAmount float64) error {
+	return s.Publish(ctx, events.BookingConfirmed{
+		BookingID:   bookingID,
+		SessionID:   sessionID,
+		UserID:      userID,
+		SeatIDs:     seatIDs,
+		TotalAmount: totalAmount,
+	})
+}
+
+func (s *EventProducerService) LogBookingTimeout(ctx context.Context, sessionID, userID string, seatIDs []string) error {
+	return s.Publish(ctx, events.BookingTimeout{
+		SessionID: sessionID,
+		UserID:    userID,
+		SeatIDs:   seatIDs,
+	})
+}
+
+func (s *EventProducerService) LogBookingCancelled(ctx context.Context, bookingID, sessionID, userID string, seatIDs []string, reason string) error {
+	return s.Publish(ctx, events.BookingCancelled{
+		BookingID: bookingID,
+		SessionID: sessionID,
+		UserID:    userID,
+		SeatIDs:   seatIDs,
+		Reason:    reason,
+	})
+}
+
+// LogBookingRefunded records a booking cancelled together with its session,
+// including the refund it got.
+func (s *EventProducerService) LogBookingRefunded(ctx context.Context, booking models.Booking, reason string) error {
+	payload := events.BookingCancelled{
+		BookingID: booking.ID.Hex(),
+		SessionID: booking.SessionID.Hex(),
+		UserID:    booking.UserID,
+		SeatIDs:   booking.Seats,
+		Reason:    reason,
+	}
+	if booking.Refund != nil {
+		payload.RefundAmount = booking.Refund.Amount
+		payload.RefundReference = booking.Refund.Reference
+	}
+	return s.Publish(ctx, payload)
+}
+
+// LogBookingExchanged records an exchange as one event, from the revision it
+// replaced to the booking as it is now.
+func (s *EventProducerService) LogBookingExchanged(ctx context.Context, booking models.Booking, previous models.BookingRevision) error {
+	return s.Publish(ctx, events.BookingExchanged{
+		BookingID:        booking.ID.Hex(),
+		UserID:           booking.UserID,
+		Revision:         booking.Revision,
+		FromSessionID:    previous.SessionID.Hex(),
+		FromSeatIDs:      previous.Seats,
+		SessionID:        booking.SessionID.Hex(),
+		SeatIDs:          booking.Seats,
+		TotalAmount:      booking.TotalAmount,
+		PriceDifference:  previous.PriceDifference,
+		PaymentReference: previous.PaymentReference,
+	})
+}
+
+func (s *EventProducerService) LogSessionCancelled(ctx context.Context, c models.SessionCancellation, previous models.SessionStatus) error {
+	return s.Publish(ctx, events.SessionCancelled{
+		SessionID:      c.SessionID.Hex(),
+		CancellationID: c.ID.Hex(),
+		Reason:         c.Reason,
+		RequestedBy:    c.RequestedBy,
+		PreviousStatus: string(previous),
+	})
+}
+
+func (s *EventProducerService) LogLockExpired(ctx context.Context, sessionID string, seatIDs []string) error {
+	return s.Publish(ctx, events.LockExpired{
+		SessionID:      sessionID,
+		SeatIDs:        seatIDs,
+		LockTTLSeconds: int(LockDuration.Seconds()),
+	})
+}
+
+func (s *EventProducerService) LogReminderSent(ctx context.Context, bookingID, sessionID, userID string, seatIDs []string, offset, emailID string) error {
+	return s.Publish(ctx, events.ReminderSent{
+		BookingID: bookingID,
+		SessionID: sessionID,
+		UserID:    userID,
+		SeatIDs:   seatIDs,
+		Offset:    offset,
+		EmailID:   emailID,
+	})
+}
+
+func (s *EventProducerService) LogTicketAdmitted(ctx context.Context, bookingID, sessionID, userID, seatID, admittedBy string) error {
+	return s.Publish(ctx, events.TicketAdmitted{
+		BookingID:  bookingID,
+		SessionID:  sessionID,
+		UserID:     userID,
+		SeatID:     seatID,
+		AdmittedBy: admittedBy,
+	})
+}
+
+func (s *EventProducerService) LogSeatRepaired(ctx context.Context, reportID string, d models.Discrepancy, approvedBy string) error {
+	return s.Publish(ctx, events.SeatRepaired{
+		ReportID:      reportID,
+		DiscrepancyID: d.ID,
+		Kind:          string(d.Kind),
+		SessionID:     d.SessionID,
+		SeatID:        d.SeatID,
+		BookingIDs:    d.BookingIDs,
+		Action:        d.Repair,
+		ApprovedBy:    approvedBy,
+	})
+}
+
+func (s *EventProducerService) LogSessionStatusChanged(ctx context.Context, sessionID string, from, to models.SessionStatus) error {
+	return s.Publish(ctx, events.SessionStatusChanged{
+		SessionID: sessionID,
+		From:      string(from),
+		To:        string(to),
+	})
+}
+
+func (s *EventProducerService) LogWaitlistJoined(ctx context.Context, entry models.WaitlistEntry) error {
+	return s.Publish(ctx, events.WaitlistJoined{
+		SessionID: entry.SessionID.Hex(),
+		UserID:    entry.UserID,
+		EntryID:   entry.ID.Hex(),
+		PartySize: entry.PartySize,
+	})
+}
+
+func (s *EventProducerService) LogWaitlistOffered(ctx context.Context, sessionID string, offer models.WaitlistOffer) error {
+	return s.Publish(ctx, events.WaitlistOffered{
+		SessionID: sessionID,
+		UserID:    offer.UserID,
+		EntryID:   offer.EntryID,
+		SeatIDs:   offer.SeatIDs,
+		ExpiresAt: offer.ExpiresAt,
+	})
+}
+
+func (s *EventProducerService) LogSystemError(ctx context.Context, errorType, description string, details map[string]interface{}) error {
+	return s.LogSessionError(ctx, "", errorType, description, details)
+}
+
+// LogSessionError records a SYSTEM_ERROR that concerns one session, so it
+// shows up in that session's audit trail.
+func (s *EventProducerService) LogSessionError(ctx context.Context, sessionID, errorType, description string, details map[string]interface{}) error {
+	return s.Publish(ctx, events.SystemError{
+		ErrorType: errorType,
+		Message:   description,
+		SessionID: sessionID,
+		Details:   details,
+	})
+}
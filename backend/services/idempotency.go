@@ -0,0 +1,136 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"cinema-booking-system/config"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	IdempotencyKeyPrefix = "idempotency:"
+
+	// IdempotencyProcessingTTL bounds how long a request that crashed mid-way
+	// blocks retries with its key.
+	IdempotencyProcessingTTL = time.Minute
+)
+
+var (
+	ErrIdempotencyKeyReused    = errors.New("idempotency key was already used with a different request")
+	ErrIdempotencyInProgress   = errors.New("a request with this idempotency key is still being processed")
+	ErrIdempotencyNotAvailable = errors.New("idempotency store not available")
+)
+
+// IdempotentResponse is what is stored under an Idempotency-Key. While the
+// first request is running only Fingerprint is set.
+type IdempotentResponse struct {
+	Fingerprint string    `json:"fingerprint"`
+	Done        bool      `json:"done"`
+	Status      int       `json:"status,omitempty"`
+	ContentType string    `json:"contentType,omitempty"`
+	Body        []byte    `json:"body,omitempty"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+// IdempotencyStore keeps responses to requests sent with an Idempotency-Key in
+// Redis so retries get the original answer instead of running again.
+type IdempotencyStore struct {
+	client *redis.Client
+	ttl    time.Duration
+}
+
+func NewIdempotencyStore(ttl time.Duration) *IdempotencyStore {
+	return &IdempotencyStore{
+		client: config.RedisClient,
+		ttl:    ttl,
+	}
+}
+
+// ScopedIdempotencyKey is where a client's key is stored. Keys are chosen by
+// clients, so they are scoped to the user, method and path; two clients that
+// pick the same key never see each other's requests.
+func ScopedIdempotencyKey(userID, method, path, key string) string {
+	sum := sha256.Sum256([]byte(userID + "\n" + method + " " + path + "\n" + key))
+	return hex.EncodeToString(sum[:])
+}
+
+// RequestFingerprint identifies a request by method, path and body. JSON
+// bodies are compared after re-encoding, so whitespace and key order do not
+// matter.
+func RequestFingerprint(method, path string, body []byte) string {
+	var parsed interface{}
+	if err := json.Unmarshal(body, &parsed); err == nil {
+		if canonical, err := json.Marshal(parsed); err == nil {
+			body = canonical
+		}
+	}
+	sum := sha256.Sum256([]byte(method + " " + path + "\n" + string(body)))
+	return hex.EncodeToString(sum[:])
+}
+
+// Begin claims key, as returned by ScopedIdempotencyKey, for a request. It returns the stored response when the
+// same request already completed, ErrIdempotencyInProgress while it is still
+// running and ErrIdempotencyKeyReused when the key belongs to another request.
+// A nil response and nil error mean the caller owns the key and must call
+// Complete or Abandon.
+func (s *IdempotencyStore) Begin(ctx context.Context, key, fingerprint string) (*IdempotentResponse, error) {
+	if s.client == nil {
+		return nil, ErrIdempotencyNotAvailable
+	}
+
+	claim, _ := json.Marshal(IdempotentResponse{Fingerprint: fingerprint, CreatedAt: time.Now().UTC()})
+	ok, err := s.client.SetNX(ctx, IdempotencyKeyPrefix+key, claim, IdempotencyProcessingTTL).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim idempotency key: %w", err)
+	}
+	if ok {
+		return nil, nil
+	}
+
+	raw, err := s.client.Get(ctx, IdempotencyKeyPrefix+key).Bytes()
+	if err == redis.Nil {
+		// Expired between the two calls; the client may simply retry.
+		return nil, ErrIdempotencyInProgress
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read idempotency key: %w", err)
+	}
+
+	var stored IdempotentResponse
+	if err := json.Unmarshal(raw, &stored); err != nil {
+		return nil, fmt.Errorf("failed to decode idempotency key: %w", err)
+	}
+	switch {
+	case stored.Fingerprint != fingerprint:
+		return nil, ErrIdempotencyKeyReused
+	case !stored.Done:
+		return nil, ErrIdempotencyInProgress
+	}
+	return &stored, nil
+}
+
+// Complete stores the response for replay until the TTL runs out.
+func (s *IdempotencyStore) Complete(ctx context.Context, key, fingerprint string, status int, contentType string, body []byte) error {
+	data, _ := json.Marshal(IdempotentResponse{
+		Fingerprint: fingerprint,
+		Done:        true,
+		Status:      status,
+		ContentType: contentType,
+		Body:        body,
+		CreatedAt:   time.Now().UTC(),
+	})
+	return s.client.Set(ctx, IdempotencyKeyPrefix+key, data, s.ttl).Err()
+}
+
+// Abandon releases a claim without storing a response, so the request can be
+// retried with the same key.
+func (s *IdempotencyStore) Abandon(ctx context.Context, key string) error {
+	return s.client.Del(ctx, IdempotencyKeyPrefix+key).Err()
+}
@@ -0,0 +1,60 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"cinema-booking-system/config"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var InstanceID = func() string {
+	host, _ := os.Hostname()
+	return fmt.Sprintf("%s-%d", host, os.Getpid())
+}()
+
+// AcquireLease makes sure only one replica runs a background job at a time.
+// The lease is held until ttl passes or ReleaseLease is called; the current
+// holder can renew it by acquiring again.
+func AcquireLease(ctx context.Context, name string, ttl time.Duration) (bool, error) {
+	if config.MongoDB == nil {
+		return false, fmt.Errorf("mongodb not initialized")
+	}
+
+	now := time.Now().UTC()
+	_, err := config.MongoDB.Collection("job_leases").UpdateOne(ctx,
+		bson.M{
+			"_id": name,
+			"$or": []bson.M{
+				{"expiresAt": bson.M{"$lt": now}},
+				{"holder": InstanceID},
+			},
+		},
+		bson.M{"$set": bson.M{
+			"holder":     InstanceID,
+			"acquiredAt": now,
+			"expiresAt":  now.Add(ttl),
+		}},
+		options.Update().SetUpsert(true),
+	)
+	if mongo.IsDuplicateKeyError(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func ReleaseLease(ctx context.Context, name string) error {
+	if config.MongoDB == nil {
+		return nil
+	}
+	_, err := config.MongoDB.Collection("job_leases").DeleteOne(ctx, bson.M{"_id": name, "holder": InstanceID})
+	return err
+}
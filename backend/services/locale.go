@@ -0,0 +1,131 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const DefaultLocale = "en"
+
+// Locale holds the formatting rules used when rendering customer-facing
+// content. Amounts are always Thai baht; only their presentation changes.
+type Locale struct {
+	Tag            string
+	CurrencyPrefix string
+	CurrencySuffix string
+	formatDate     func(t time.Time) string
+	formatTime     func(t time.Time) string
+}
+
+var (
+	thaiMonths   = []string{"มกราคม", "กุมภาพันธ์", "มีนาคม", "เมษายน", "พฤษภาคม", "มิถุนายน", "กรกฎาคม", "สิงหาคม", "กันยายน", "ตุลาคม", "พฤศจิกายน", "ธันวาคม"}
+	thaiWeekdays = []string{"อาทิตย์", "จันทร์", "อังคาร", "พุธ", "พฤหัสบดี", "ศุกร์", "เสาร์"}
+)
+
+var locales = map[string]*Locale{
+	"en": {
+		Tag:            "en",
+		CurrencyPrefix: "฿",
+		formatDate:     func(t time.Time) string { return t.Format("Monday, January 2, 2006") },
+		formatTime:     func(t time.Time) string { return t.Format("3:04 PM") },
+	},
+	"th": {
+		Tag:            "th",
+		CurrencySuffix: " บาท",
+		formatDate: func(t time.Time) string {
+			// Thai dates use the Buddhist era, 543 years ahead of the Gregorian year.
+			return fmt.Sprintf("วัน%sที่ %d %s %d", thaiWeekdays[t.Weekday()], t.Day(), thaiMonths[t.Month()-1], t.Year()+543)
+		},
+		formatTime: func(t time.Time) string { return t.Format("15:04") + " น." },
+	},
+}
+
+var displayLocation = loadDisplayLocation()
+
+func loadDisplayLocation() *time.Location {
+	name := os.Getenv("DISPLAY_TIMEZONE")
+	if name == "" {
+		name = "Asia/Bangkok"
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		log.Printf("⚠️ Unknown DISPLAY_TIMEZONE %q, using UTC: %v", name, err)
+		return time.UTC
+	}
+	return loc
+}
+
+// ResolveLocale picks the best supported locale for a tag such as "th-TH" or a
+// full Accept-Language header, falling back to DefaultLocale.
+func ResolveLocale(accept string) *Locale {
+	type candidate struct {
+		tag string
+		q   float64
+	}
+
+	var candidates []candidate
+	for _, part := range strings.Split(accept, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ";")
+		tag := strings.ToLower(strings.TrimSpace(fields[0]))
+		if tag == "" {
+			continue
+		}
+		q := 1.0
+		for _, f := range fields[1:] {
+			if v, ok := strings.CutPrefix(strings.TrimSpace(f), "q="); ok {
+				if parsed, err := strconv.ParseFloat(v, 64); err == nil {
+					q = parsed
+				}
+			}
+		}
+		candidates = append(candidates, candidate{tag: tag, q: q})
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].q > candidates[j].q })
+
+	for _, c := range candidates {
+		base, _, _ := strings.Cut(strings.ReplaceAll(c.tag, "_", "-"), "-")
+		if l, ok := locales[base]; ok {
+			return l
+		}
+	}
+	return locales[DefaultLocale]
+}
+
+func (l *Locale) FormatMoney(amount float64) string {
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+
+	cents := int64(math.Round(amount * 100))
+	whole := strconv.FormatInt(cents/100, 10)
+
+	var grouped strings.Builder
+	for i, r := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			grouped.WriteByte(',')
+		}
+		grouped.WriteRune(r)
+	}
+
+	return fmt.Sprintf("%s%s%s.%02d%s", sign, l.CurrencyPrefix, grouped.String(), cents%100, l.CurrencySuffix)
+}
+
+func (l *Locale) FormatDate(t time.Time) string {
+	return l.formatDate(t.In(displayLocation))
+}
+
+func (l *Locale) FormatTime(t time.Time) string {
+	return l.formatTime(t.In(displayLocation))
+}
+
+func (l *Locale) FormatDateTime(t time.Time) string {
+	return l.FormatDate(t) + ", " + l.FormatTime(t)
+}
@@ -2,9 +2,7 @@ package services
 
 import (
 	"context"
-	"encoding/json"
 	"log"
-	"time"
 
 	"cinema-booking-system/config"
 	"cinema-booking-system/models"
@@ -15,15 +13,19 @@ import (
 
 type LockExpiryMonitor struct {
 	redisClient  *redis.Client
-	kafkaService *KafkaProducerService
+	eventService *EventProducerService
 	wsHub        *websocket.Hub
+	waitlist     *WaitlistService
 }
 
-func NewLockExpiryMonitor(wsHub *websocket.Hub) *LockExpiryMonitor {
+// NewLockExpiryMonitor watches for expired seat locks. waitlist may be nil;
+// otherwise expired seats are queued for the waitlist worker first.
+func NewLockExpiryMonitor(wsHub *websocket.Hub, waitlist *WaitlistService) *LockExpiryMonitor {
 	return &LockExpiryMonitor{
 		redisClient:  config.RedisClient,
-		kafkaService: NewKafkaProducerService(),
+		eventService: NewEventProducerService(),
 		wsHub:        wsHub,
+		waitlist:     waitlist,
 	}
 }
 
@@ -66,18 +68,16 @@ func (m *LockExpiryMonitor) handleExpiredKey(ctx context.Context, key string) {
 
 	log.Printf("⏰ Lock expired: session=%s, seat=%s", sessionID, seatID)
 
-	m.wsHub.BroadcastSeatUpdate(sessionID, models.SeatUpdate{
-		SeatID: seatID,
-		Status: models.SeatAvailable,
-	})
-
-	go m.kafkaService.SendAuditLog(ctx, models.AuditLog{
-		EventType:   "LOCK_EXPIRED",
-		SessionID:   sessionID,
-		SeatIDs:     []string{seatID},
-		Description: "Seat lock expired (5 min timeout)",
-		Timestamp:   time.Now().UTC(),
-	})
+	if m.waitlist != nil {
+		m.waitlist.SeatsReleased(ctx, sessionID, []string{seatID}, "")
+	} else {
+		m.wsHub.BroadcastSeatUpdate(sessionID, models.SeatUpdate{
+			SeatID: seatID,
+			Status: models.SeatAvailable,
+		})
+	}
+
+	go m.eventService.LogLockExpired(ctx, sessionID, []string{seatID})
 }
 
 func parseKeyParts(s string) []string {
@@ -94,55 +94,3 @@ func parseKeyParts(s string) []string {
 	}
 	return parts
 }
-
-type AuditLogPersistence struct{}
-
-func NewAuditLogPersistence() *AuditLogPersistence {
-	return &AuditLogPersistence{}
-}
-
-func (p *AuditLogPersistence) SaveAuditLog(ctx context.Context, auditLog models.AuditLog) error {
-	if config.MongoDB == nil {
-		return nil
-	}
-
-	if auditLog.Timestamp.IsZero() {
-		auditLog.Timestamp = time.Now().UTC()
-	}
-
-	_, err := config.MongoDB.Collection("audit_logs").InsertOne(ctx, auditLog)
-	return err
-}
-
-type EnhancedKafkaProducerService struct {
-	*KafkaProducerService
-	persistence *AuditLogPersistence
-}
-
-func NewEnhancedKafkaProducerService() *EnhancedKafkaProducerService {
-	return &EnhancedKafkaProducerService{
-		KafkaProducerService: NewKafkaProducerService(),
-		persistence:          NewAuditLogPersistence(),
-	}
-}
-
-func (s *EnhancedKafkaProducerService) SendAuditLog(ctx context.Context, auditLog models.AuditLog) error {
-	if err := s.persistence.SaveAuditLog(ctx, auditLog); err != nil {
-		log.Printf("⚠️ Failed to save audit log to MongoDB: %v", err)
-	}
-
-	return s.KafkaProducerService.SendAuditLog(ctx, auditLog)
-}
-
-func (s *EnhancedKafkaProducerService) LogSystemError(ctx context.Context, errorType, description string, details map[string]interface{}) error {
-	detailsJSON, _ := json.Marshal(details)
-
-	return s.SendAuditLog(ctx, models.AuditLog{
-		EventType:   "SYSTEM_ERROR",
-		SessionID:   "",
-		UserID:      "system",
-		SeatIDs:     nil,
-		Description: errorType + ": " + description + " | " + string(detailsJSON),
-		Timestamp:   time.Now().UTC(),
-	})
-}
@@ -0,0 +1,503 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	MailTransportSMTP    = "smtp"
+	MailTransportFile    = "file"
+	MailTransportMaildir = "maildir"
+	MailTransportMemory  = "memory"
+	MailTransportNone    = "none"
+
+	SMTPSecuritySTARTTLS = "starttls"
+	SMTPSecurityTLS      = "tls"
+	SMTPSecurityNone     = "none"
+
+	defaultMemoryMailboxSize = 200
+)
+
+// Mail is a single outgoing message as handed to a Mailer. When TextBody is
+// set the body is multipart/alternative with a plaintext part. Attachments
+// with a ContentID are inline images for the HTML part (multipart/related);
+// the rest wrap everything in multipart/mixed.
+type Mail struct {
+	ID          string
+	From        string
+	To          string
+	Subject     string
+	HTMLBody    string
+	TextBody    string
+	Attachments []Attachment
+	Date        time.Time
+}
+
+type Attachment struct {
+	Filename    string
+	ContentType string
+	ContentID   string
+	Data        []byte
+}
+
+type partCreator func(h textproto.MIMEHeader) io.Writer
+
+// Bytes renders the message in RFC 5322 format.
+func (m *Mail) Bytes() []byte {
+	var buf bytes.Buffer
+	writeHeader(&buf, "Message-ID", "<"+m.ID+"@cinema-booking-system>")
+	writeHeader(&buf, "Date", m.Date.Format(time.RFC1123Z))
+	writeHeader(&buf, "From", m.From)
+	writeHeader(&buf, "To", m.To)
+	writeHeader(&buf, "Subject", mime.QEncoding.Encode("utf-8", m.Subject))
+	writeHeader(&buf, "MIME-Version", "1.0")
+
+	top := func(h textproto.MIMEHeader) io.Writer {
+		for _, k := range []string{"Content-Type", "Content-Transfer-Encoding"} {
+			if v := h.Get(k); v != "" {
+				writeHeader(&buf, k, v)
+			}
+		}
+		buf.WriteString("\r\n")
+		return &buf
+	}
+
+	var inline, attached []Attachment
+	for _, a := range m.Attachments {
+		if a.ContentID != "" {
+			inline = append(inline, a)
+		} else {
+			attached = append(attached, a)
+		}
+	}
+
+	if len(attached) == 0 {
+		m.writeBody(top, inline)
+		return buf.Bytes()
+	}
+
+	mixed := nestedMultipart(top, "multipart/mixed")
+	create := partsOf(mixed)
+	m.writeBody(create, inline)
+	for _, a := range attached {
+		writeAttachment(create, a, "attachment")
+	}
+	mixed.Close()
+	return buf.Bytes()
+}
+
+func (m *Mail) writeBody(create partCreator, inline []Attachment) {
+	if m.TextBody == "" {
+		writeHTML(create, m.HTMLBody, inline)
+		return
+	}
+
+	alt := nestedMultipart(create, "multipart/alternative")
+	altCreate := partsOf(alt)
+	writeQuotedPrintable(altCreate(textPartHeader("text/plain; charset=UTF-8")), m.TextBody)
+	writeHTML(altCreate, m.HTMLBody, inline)
+	alt.Close()
+}
+
+func writeHTML(create partCreator, html string, inline []Attachment) {
+	if len(inline) == 0 {
+		writeQuotedPrintable(create(textPartHeader("text/html; charset=UTF-8")), html)
+		return
+	}
+
+	related := nestedMultipart(create, "multipart/related")
+	relCreate := partsOf(related)
+	writeQuotedPrintable(relCreate(textPartHeader("text/html; charset=UTF-8")), html)
+	for _, a := range inline {
+		writeAttachment(relCreate, a, "inline")
+	}
+	related.Close()
+}
+
+func writeAttachment(create partCreator, a Attachment, disposition string) {
+	mediaType, params, err := mime.ParseMediaType(a.ContentType)
+	if err != nil {
+		mediaType, params = "application/octet-stream", map[string]string{}
+	}
+	params["name"] = a.Filename
+
+	h := textproto.MIMEHeader{}
+	h.Set("Content-Type", mime.FormatMediaType(mediaType, params))
+	h.Set("Content-Disposition", mime.FormatMediaType(disposition, map[string]string{"filename": a.Filename}))
+	h.Set("Content-Transfer-Encoding", "base64")
+	if a.ContentID != "" {
+		h.Set("Content-ID", "<"+a.ContentID+">")
+	}
+	writeBase64(create(h), a.Data)
+}
+
+func partsOf(mw *multipart.Writer) partCreator {
+	return func(h textproto.MIMEHeader) io.Writer {
+		w, _ := mw.CreatePart(h)
+		return w
+	}
+}
+
+// nestedMultipart opens a multipart container as a part of its parent. The
+// boundary has to be known before the parent part header is written.
+func nestedMultipart(create partCreator, mediaType string) *multipart.Writer {
+	boundary := multipart.NewWriter(io.Discard).Boundary()
+	h := textproto.MIMEHeader{}
+	h.Set("Content-Type", mediaType+"; boundary="+boundary)
+	mw := multipart.NewWriter(create(h))
+	mw.SetBoundary(boundary)
+	return mw
+}
+
+func writeHeader(buf *bytes.Buffer, key, value string) {
+	buf.WriteString(key)
+	buf.WriteString(": ")
+	buf.WriteString(value)
+	buf.WriteString("\r\n")
+}
+
+func textPartHeader(contentType string) textproto.MIMEHeader {
+	h := textproto.MIMEHeader{}
+	h.Set("Content-Type", contentType)
+	h.Set("Content-Transfer-Encoding", "quoted-printable")
+	return h
+}
+
+func writeBase64(w io.Writer, data []byte) {
+	encoded := base64.StdEncoding.EncodeToString(data)
+	for len(encoded) > 76 {
+		io.WriteString(w, encoded[:76]+"\r\n")
+		encoded = encoded[76:]
+	}
+	io.WriteString(w, encoded+"\r\n")
+}
+
+func writeQuotedPrintable(w io.Writer, body string) {
+	qp := quotedprintable.NewWriter(w)
+	qp.Write([]byte(body))
+	qp.Close()
+}
+
+type Mailer interface {
+	Send(ctx context.Context, m *Mail) error
+	Kind() string
+}
+
+// NewMailerFromEnv builds the transport selected by MAIL_TRANSPORT. When it is
+// unset, SMTP is used if SMTP_HOST is present, otherwise email is disabled and
+// a nil Mailer is returned.
+func NewMailerFromEnv() (Mailer, error) {
+	transport := strings.ToLower(os.Getenv("MAIL_TRANSPORT"))
+	if transport == "" {
+		transport = MailTransportNone
+		if os.Getenv("SMTP_HOST") != "" {
+			transport = MailTransportSMTP
+		}
+	}
+
+	switch transport {
+	case MailTransportSMTP:
+		return NewSMTPMailer(
+			os.Getenv("SMTP_HOST"),
+			os.Getenv("SMTP_PORT"),
+			os.Getenv("SMTP_SECURITY"),
+			os.Getenv("SMTP_USERNAME"),
+			os.Getenv("SMTP_PASSWORD"),
+		)
+	case MailTransportFile, MailTransportMaildir:
+		dir := os.Getenv("MAIL_DIR")
+		if dir == "" {
+			dir = "./mail"
+		}
+		return NewFileMailer(dir, transport == MailTransportMaildir)
+	case MailTransportMemory:
+		return NewMemoryMailer(defaultMemoryMailboxSize), nil
+	case MailTransportNone:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unknown MAIL_TRANSPORT %q", transport)
+	}
+}
+
+type SMTPMailer struct {
+	host     string
+	port     string
+	security string
+	username string
+	password string
+}
+
+func NewSMTPMailer(host, port, security, username, password string) (*SMTPMailer, error) {
+	if host == "" {
+		return nil, fmt.Errorf("SMTP_HOST is required for the smtp transport")
+	}
+
+	security = strings.ToLower(security)
+	switch security {
+	case "":
+		security = SMTPSecuritySTARTTLS
+	case SMTPSecuritySTARTTLS, SMTPSecurityTLS, SMTPSecurityNone:
+	default:
+		return nil, fmt.Errorf("unknown SMTP_SECURITY %q", security)
+	}
+
+	if port == "" {
+		switch security {
+		case SMTPSecurityTLS:
+			port = "465"
+		case SMTPSecuritySTARTTLS:
+			port = "587"
+		default:
+			port = "25"
+		}
+	}
+
+	return &SMTPMailer{
+		host:     host,
+		port:     port,
+		security: security,
+		username: username,
+		password: password,
+	}, nil
+}
+
+func (s *SMTPMailer) Kind() string {
+	return fmt.Sprintf("smtp %s:%s (%s)", s.host, s.port, s.security)
+}
+
+func (s *SMTPMailer) Send(ctx context.Context, m *Mail) error {
+	sender, err := mail.ParseAddress(m.From)
+	if err != nil {
+		return fmt.Errorf("invalid sender address: %w", err)
+	}
+
+	addr := net.JoinHostPort(s.host, s.port)
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+	tlsConfig := &tls.Config{ServerName: s.host}
+
+	var conn net.Conn
+	if s.security == SMTPSecurityTLS {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: tlsConfig}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to connect to SMTP server: %w", err)
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, s.host)
+	if err != nil {
+		return fmt.Errorf("failed to create SMTP client: %w", err)
+	}
+	defer client.Close()
+
+	if s.security == SMTPSecuritySTARTTLS {
+		if err := client.StartTLS(tlsConfig); err != nil {
+			return fmt.Errorf("failed to start TLS: %w", err)
+		}
+	}
+
+	if s.username != "" {
+		auth := smtp.PlainAuth("", s.username, s.password, s.host)
+		if err := client.Auth(auth); err != nil {
+			return fmt.Errorf("failed to authenticate: %w", err)
+		}
+	}
+
+	if err := client.Mail(sender.Address); err != nil {
+		return fmt.Errorf("failed to set sender: %w", err)
+	}
+	if err := client.Rcpt(m.To); err != nil {
+		return fmt.Errorf("failed to set recipient: %w", err)
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("failed to open data writer: %w", err)
+	}
+	if _, err := w.Write(m.Bytes()); err != nil {
+		return fmt.Errorf("failed to write message: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("failed to close data writer: %w", err)
+	}
+
+	if err := client.Quit(); err != nil {
+		return fmt.Errorf("failed to quit: %w", err)
+	}
+	return nil
+}
+
+// FileMailer writes every message to disk instead of sending it, either as
+// flat .eml files or into a maildir (tmp/, new/, cur/) that mail clients can
+// open directly.
+type FileMailer struct {
+	dir     string
+	maildir bool
+	seq     atomic.Uint64
+}
+
+func NewFileMailer(dir string, maildir bool) (*FileMailer, error) {
+	subdirs := []string{""}
+	if maildir {
+		subdirs = []string{"tmp", "new", "cur"}
+	}
+	for _, sub := range subdirs {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create mail directory: %w", err)
+		}
+	}
+	return &FileMailer{dir: dir, maildir: maildir}, nil
+}
+
+func (f *FileMailer) Kind() string {
+	if f.maildir {
+		return "maildir " + f.dir
+	}
+	return "file " + f.dir
+}
+
+func (f *FileMailer) Send(ctx context.Context, m *Mail) error {
+	hostname, _ := os.Hostname()
+	name := fmt.Sprintf("%d.%d_%d.%s", m.Date.Unix(), os.Getpid(), f.seq.Add(1), hostname)
+
+	tmpDir, finalPath := f.dir, filepath.Join(f.dir, name+".eml")
+	if f.maildir {
+		tmpDir, finalPath = filepath.Join(f.dir, "tmp"), filepath.Join(f.dir, "new", name)
+	}
+
+	tmp, err := os.CreateTemp(tmpDir, ".mail-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(m.Bytes()); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), finalPath)
+}
+
+type CapturedMail struct {
+	ID          string    `json:"id"`
+	From        string    `json:"from"`
+	To          string    `json:"to"`
+	Subject     string    `json:"subject"`
+	HTMLBody    string    `json:"htmlBody"`
+	TextBody    string    `json:"textBody,omitempty"`
+	Attachments []string  `json:"attachments,omitempty"`
+	Raw         string    `json:"raw"`
+	SentAt      time.Time `json:"sentAt"`
+}
+
+// MemoryMailer keeps the most recent messages in memory so they can be
+// inspected from tests or through the dev mailbox endpoint.
+type MemoryMailer struct {
+	size int
+
+	mu       sync.Mutex
+	messages []CapturedMail
+}
+
+func NewMemoryMailer(size int) *MemoryMailer {
+	if size < 1 {
+		size = defaultMemoryMailboxSize
+	}
+	return &MemoryMailer{size: size}
+}
+
+func (m *MemoryMailer) Kind() string { return "memory" }
+
+func (m *MemoryMailer) Send(ctx context.Context, mail *Mail) error {
+	captured := CapturedMail{
+		ID:       mail.ID,
+		From:     mail.From,
+		To:       mail.To,
+		Subject:  mail.Subject,
+		HTMLBody: mail.HTMLBody,
+		TextBody: mail.TextBody,
+		Raw:      string(mail.Bytes()),
+		SentAt:   mail.Date,
+	}
+	for _, a := range mail.Attachments {
+		captured.Attachments = append(captured.Attachments, a.Filename)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.messages = append(m.messages, captured)
+	if len(m.messages) > m.size {
+		m.messages = m.messages[len(m.messages)-m.size:]
+	}
+	return nil
+}
+
+// Messages returns captured messages, newest first.
+func (m *MemoryMailer) Messages() []CapturedMail {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	out := make([]CapturedMail, len(m.messages))
+	for i, msg := range m.messages {
+		out[len(m.messages)-1-i] = msg
+	}
+	return out
+}
+
+func (m *MemoryMailer) Get(id string) (CapturedMail, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, msg := range m.messages {
+		if msg.ID == id {
+			return msg, true
+		}
+	}
+	return CapturedMail{}, false
+}
+
+func (m *MemoryMailer) Clear() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.messages = nil
+}
+
+func logMailer(m Mailer, err error) {
+	switch {
+	case err != nil:
+		log.Printf("⚠️ Email transport not available: %v", err)
+	case m == nil:
+		log.Println("⚠️ Email service not configured (set MAIL_TRANSPORT or SMTP_* environment variables)")
+	default:
+		log.Printf("✅ Email service initialized (%s)", m.Kind())
+	}
+}
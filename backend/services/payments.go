@@ -0,0 +1,85 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+)
+
+const (
+	PaymentProviderMock = "mock"
+)
+
+// RefundRequest asks the payment provider to pay back a booking.
+// IdempotencyKey must be stable for the booking, so a refund retried after a
+// crash is not paid out twice.
+type RefundRequest struct {
+	BookingID      string
+	PaymentID      string
+	Amount         float64
+	Reason         string
+	IdempotencyKey string
+}
+
+type RefundResult struct {
+	Reference string
+}
+
+// ChargeRequest takes an extra payment for a booking, e.g. when it is
+// exchanged for dearer seats. IdempotencyKey works as for refunds.
+type ChargeRequest struct {
+	BookingID      string
+	PaymentID      string
+	Amount         float64
+	Description    string
+	IdempotencyKey string
+}
+
+type ChargeResult struct {
+	Reference string
+}
+
+// PaymentGateway is the payment provider behind bookings.
+type PaymentGateway interface {
+	Charge(ctx context.Context, req ChargeRequest) (*ChargeResult, error)
+	Refund(ctx context.Context, req RefundRequest) (*RefundResult, error)
+	Kind() string
+}
+
+// NewPaymentGatewayFromEnv builds the provider selected by PAYMENT_PROVIDER.
+// Only the mock provider exists so far, matching the mock checkout.
+func NewPaymentGatewayFromEnv() (PaymentGateway, error) {
+	provider := strings.ToLower(os.Getenv("PAYMENT_PROVIDER"))
+	switch provider {
+	case "", PaymentProviderMock:
+		return MockPaymentGateway{}, nil
+	default:
+		return nil, fmt.Errorf("unknown PAYMENT_PROVIDER %q", provider)
+	}
+}
+
+// MockPaymentGateway accepts every charge and refund. References are derived
+// from the idempotency key, so a retry gets the same reference back, as it
+// would from a real provider.
+type MockPaymentGateway struct{}
+
+func (MockPaymentGateway) Charge(ctx context.Context, req ChargeRequest) (*ChargeResult, error) {
+	if req.Amount <= 0 {
+		return nil, fmt.Errorf("charge amount must be positive")
+	}
+	sum := sha256.Sum256([]byte(req.IdempotencyKey))
+	return &ChargeResult{Reference: "ch_mock_" + hex.EncodeToString(sum[:8])}, nil
+}
+
+func (MockPaymentGateway) Refund(ctx context.Context, req RefundRequest) (*RefundResult, error) {
+	if req.Amount < 0 {
+		return nil, fmt.Errorf("refund amount must not be negative")
+	}
+	sum := sha256.Sum256([]byte(req.IdempotencyKey))
+	return &RefundResult{Reference: "rf_mock_" + hex.EncodeToString(sum[:8])}, nil
+}
+
+func (MockPaymentGateway) Kind() string { return PaymentProviderMock }
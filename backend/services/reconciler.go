@@ -0,0 +1,512 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"cinema-booking-system/config"
+	"cinema-booking-system/models"
+
+	"github.com/redis/go-redis/v9"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	reconcilerLeaseName = "reconciler"
+	reconcileLookback   = 24 * time.Hour
+
+	ReconcileRepairNone = "none"
+	ReconcileRepairAuto = "auto"
+
+	ReconcileTriggerSchedule = "schedule"
+	ReconcileTriggerAdmin    = "admin"
+)
+
+var (
+	ErrReconcileRunning         = errors.New("a reconciliation run is already in progress")
+	ErrReportNotFound           = errors.New("reconciliation report not found")
+	ErrDiscrepancyNotFound      = errors.New("discrepancy not found in report")
+	ErrDiscrepancyNotRepairable = errors.New("discrepancy has no automatic repair and must be fixed by hand")
+	ErrDiscrepancyResolved      = errors.New("discrepancy no longer holds")
+)
+
+// Reconciler finds drift between Redis seat locks, session seat states and
+// bookings, and repairs it. Repairs marked AutoRepair are applied by
+// scheduled runs when RECONCILE_REPAIR is "auto"; the rest wait for an admin
+// to approve them from a report. Every repair re-checks its discrepancy
+// first and is audited as SEAT_STATE_REPAIRED.
+type Reconciler struct {
+	sessions *mongo.Collection
+	bookings *mongo.Collection
+	reports  *mongo.Collection
+	redis    *redis.Client
+
+	eventService *EventProducerService
+	interval     time.Duration
+	repairMode   string
+
+	mu      sync.Mutex
+	running bool
+}
+
+func NewReconciler(eventService *EventProducerService, interval time.Duration, repairMode string) *Reconciler {
+	return &Reconciler{
+		sessions:     config.MongoDB.Collection("sessions"),
+		bookings:     config.MongoDB.Collection("bookings"),
+		reports:      config.MongoDB.Collection("reconciliation_reports"),
+		redis:        config.RedisClient,
+		eventService: eventService,
+		interval:     interval,
+		repairMode:   repairMode,
+	}
+}
+
+func (r *Reconciler) Start(ctx context.Context) {
+	if r.interval <= 0 {
+		log.Println("⚠️ Reconciler disabled (RECONCILE_INTERVAL is 0)")
+		return
+	}
+
+	r.reports.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "startedAt", Value: -1}},
+	})
+
+	log.Printf("🩺 Reconciler started (every %s, repair: %s)", r.interval, r.repairMode)
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("🩺 Reconciler stopped")
+			return
+		case <-ticker.C:
+			report, err := r.Run(ctx, ReconcileTriggerSchedule, r.repairMode != ReconcileRepairAuto)
+			if err != nil {
+				if !errors.Is(err, ErrReconcileRunning) {
+					log.Printf("⚠️ Reconciliation run failed: %v", err)
+				}
+				continue
+			}
+			if n := len(report.Discrepancies); n > 0 {
+				log.Printf("🩺 Reconciliation found %d discrepancies, repaired %d", n, report.Repaired)
+			}
+		}
+	}
+}
+
+// Run scans for discrepancies and stores the report. Unless dryRun is set,
+// discrepancies with an AutoRepair fix are repaired straight away.
+func (r *Reconciler) Run(ctx context.Context, trigger string, dryRun bool) (*models.ReconciliationReport, error) {
+	r.mu.Lock()
+	if r.running {
+		r.mu.Unlock()
+		return nil, ErrReconcileRunning
+	}
+	r.running = true
+	r.mu.Unlock()
+	defer func() {
+		r.mu.Lock()
+		r.running = false
+		r.mu.Unlock()
+	}()
+
+	ok, err := AcquireLease(ctx, reconcilerLeaseName, 10*time.Minute)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrReconcileRunning
+	}
+	defer ReleaseLease(context.Background(), reconcilerLeaseName)
+
+	report := &models.ReconciliationReport{
+		ID:            primitive.NewObjectID(),
+		Trigger:       trigger,
+		DryRun:        dryRun,
+		StartedAt:     time.Now().UTC(),
+		Discrepancies: []models.Discrepancy{},
+	}
+
+	if err := r.scanSeats(ctx, report); err != nil {
+		report.Error = err.Error()
+	} else if err := r.scanLocks(ctx, report); err != nil {
+		report.Error = err.Error()
+	}
+
+	if !dryRun && report.Error == "" {
+		for i := range report.Discrepancies {
+			d := &report.Discrepancies[i]
+			if !d.AutoRepair {
+				continue
+			}
+			if err := r.repair(ctx, report.ID.Hex(), d, ""); err != nil {
+				d.RepairNote = err.Error()
+				continue
+			}
+			report.Repaired++
+		}
+	}
+
+	report.FinishedAt = time.Now().UTC()
+	if _, err := r.reports.InsertOne(ctx, report); err != nil {
+		return report, fmt.Errorf("failed to store reconciliation report: %w", err)
+	}
+	if report.Error != "" {
+		return report, errors.New(report.Error)
+	}
+	return report, nil
+}
+
+// scanSeats compares the seat states of recent and upcoming sessions with
+// the confirmed bookings for them.
+func (r *Reconciler) scanSeats(ctx context.Context, report *models.ReconciliationReport) error {
+	// Cancelled sessions keep their seats BOOKED while their bookings are
+	// cancelled and refunded, so they are left out.
+	cursor, err := r.sessions.Find(ctx, bson.M{
+		"startTime": bson.M{"$gte": time.Now().Add(-reconcileLookback)},
+		"status":    bson.M{"$ne": models.SessionCancelled},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to fetch sessions: %w", err)
+	}
+	var sessions []models.MovieSession
+	if err := cursor.All(ctx, &sessions); err != nil {
+		return fmt.Errorf("failed to decode sessions: %w", err)
+	}
+
+	for _, session := range sessions {
+		held, err := r.heldSeats(ctx, session.ID, "")
+		if err != nil {
+			return err
+		}
+		report.SessionsChecked++
+		sessionID := session.ID.Hex()
+
+		known := make(map[string]bool, len(session.Seats))
+		for _, seat := range session.Seats {
+			known[seat.ID] = true
+			holders := held[seat.ID]
+			switch {
+			case seat.Status == models.SeatBooked && len(holders) == 0:
+				report.Discrepancies = append(report.Discrepancies, newDiscrepancy(models.Discrepancy{
+					Kind:      models.DiscrepancyOrphanBookedSeat,
+					SessionID: sessionID,
+					SeatID:    seat.ID,
+					Detail:    "Seat is BOOKED but no confirmed booking holds it",
+					Repair:    "Mark seat AVAILABLE",
+				}))
+			case seat.Status != models.SeatBooked && len(holders) > 0:
+				report.Discrepancies = append(report.Discrepancies, newDiscrepancy(models.Discrepancy{
+					Kind:       models.DiscrepancyUnbookedSeat,
+					SessionID:  sessionID,
+					SeatID:     seat.ID,
+					BookingIDs: holders,
+					Detail:     fmt.Sprintf("Seat is %s but booking %s holds it", seat.Status, strings.Join(holders, ", ")),
+					Repair:     "Mark seat BOOKED",
+					// A seat blocked after it was sold needs a person to
+					// decide between the block and the booking.
+					AutoRepair: seat.Status != models.SeatBlocked,
+				}))
+			}
+			if len(holders) > 1 {
+				report.Discrepancies = append(report.Discrepancies, newDiscrepancy(models.Discrepancy{
+					Kind:       models.DiscrepancyDoubleBooking,
+					SessionID:  sessionID,
+					SeatID:     seat.ID,
+					BookingIDs: holders,
+					Detail:     fmt.Sprintf("Seat is held by %d confirmed bookings", len(holders)),
+				}))
+			}
+		}
+
+		for seatID, holders := range held {
+			if !known[seatID] {
+				report.Discrepancies = append(report.Discrepancies, newDiscrepancy(models.Discrepancy{
+					Kind:       models.DiscrepancyUnbookedSeat,
+					SessionID:  sessionID,
+					SeatID:     seatID,
+					BookingIDs: holders,
+					Detail:     "Booking holds a seat that does not exist in the session",
+				}))
+			}
+		}
+	}
+	return nil
+}
+
+// heldSeats maps each seat of a session to the confirmed bookings holding
+// it, optionally only for one seat.
+func (r *Reconciler) heldSeats(ctx context.Context, sessionID primitive.ObjectID, seatID string) (map[string][]string, error) {
+	filter := bson.M{"sessionId": sessionID, "status": models.BookingStatusConfirmed}
+	if seatID != "" {
+		filter["seats"] = seatID
+	}
+	cursor, err := r.bookings.Find(ctx, filter, options.Find().SetProjection(bson.M{"seats": 1}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch bookings: %w", err)
+	}
+	var bookings []models.Booking
+	if err := cursor.All(ctx, &bookings); err != nil {
+		return nil, fmt.Errorf("failed to decode bookings: %w", err)
+	}
+
+	held := make(map[string][]string)
+	for _, b := range bookings {
+		for _, s := range b.Seats {
+			held[s] = append(held[s], b.ID.Hex())
+		}
+	}
+	for _, ids := range held {
+		sort.Strings(ids)
+	}
+	return held, nil
+}
+
+// scanLocks finds seat locks whose session has ended or no longer exists.
+func (r *Reconciler) scanLocks(ctx context.Context, report *models.ReconciliationReport) error {
+	if r.redis == nil {
+		return nil
+	}
+
+	type lock struct{ key, sessionID, seatID string }
+	var locks []lock
+	iter := r.redis.Scan(ctx, 0, LockKeyPrefix+"*", 500).Iterator()
+	for iter.Next(ctx) {
+		key := iter.Val()
+		sessionID, seatID, ok := strings.Cut(strings.TrimPrefix(key, LockKeyPrefix), ":")
+		if !ok {
+			continue
+		}
+		locks = append(locks, lock{key: key, sessionID: sessionID, seatID: seatID})
+	}
+	if err := iter.Err(); err != nil {
+		return fmt.Errorf("failed to scan seat locks: %w", err)
+	}
+	report.LocksChecked = len(locks)
+	if len(locks) == 0 {
+		return nil
+	}
+
+	var ids []primitive.ObjectID
+	for _, l := range locks {
+		if oid, err := primitive.ObjectIDFromHex(l.sessionID); err == nil {
+			ids = append(ids, oid)
+		}
+	}
+	cursor, err := r.sessions.Find(ctx, bson.M{"_id": bson.M{"$in": ids}},
+		options.Find().SetProjection(bson.M{"startTime": 1, "endTime": 1}))
+	if err != nil {
+		return fmt.Errorf("failed to fetch sessions: %w", err)
+	}
+	var sessions []models.MovieSession
+	if err := cursor.All(ctx, &sessions); err != nil {
+		return fmt.Errorf("failed to decode sessions: %w", err)
+	}
+	ends := make(map[string]time.Time, len(sessions))
+	for _, s := range sessions {
+		ends[s.ID.Hex()] = sessionEnd(s)
+	}
+
+	now := time.Now()
+	for _, l := range locks {
+		end, ok := ends[l.sessionID]
+		detail := "Lock is for a session that no longer exists"
+		if ok {
+			if end.After(now) {
+				continue
+			}
+			detail = "Lock is for a session that ended at " + end.UTC().Format(time.RFC3339)
+		}
+		owner, _ := r.redis.Get(ctx, l.key).Result()
+		report.Discrepancies = append(report.Discrepancies, newDiscrepancy(models.Discrepancy{
+			Kind:       models.DiscrepancyOrphanLock,
+			SessionID:  l.sessionID,
+			SeatID:     l.seatID,
+			LockOwner:  owner,
+			Detail:     detail,
+			Repair:     "Delete lock",
+			AutoRepair: true,
+		}))
+	}
+	return nil
+}
+
+// repair applies the fix for one discrepancy after checking it still holds.
+func (r *Reconciler) repair(ctx context.Context, reportID string, d *models.Discrepancy, approvedBy string) error {
+	if d.Repair == "" {
+		return ErrDiscrepancyNotRepairable
+	}
+
+	var err error
+	switch d.Kind {
+	case models.DiscrepancyOrphanBookedSeat:
+		err = r.setSeatStatus(ctx, d, models.SeatAvailable, func(held []string) bool { return len(held) == 0 })
+	case models.DiscrepancyUnbookedSeat:
+		err = r.setSeatStatus(ctx, d, models.SeatBooked, func(held []string) bool { return len(held) > 0 })
+	case models.DiscrepancyOrphanLock:
+		var n int64
+		n, err = r.redis.Del(ctx, LockKeyPrefix+d.SessionID+":"+d.SeatID).Result()
+		if err == nil && n == 0 {
+			err = ErrDiscrepancyResolved
+		}
+	default:
+		err = ErrDiscrepancyNotRepairable
+	}
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	d.RepairedAt = &now
+	d.RepairedBy = approvedBy
+	if d.RepairedBy == "" {
+		d.RepairedBy = "system"
+	}
+	go r.eventService.LogSeatRepaired(context.Background(), reportID, *d, approvedBy)
+	return nil
+}
+
+// setSeatStatus flips a seat to status if the bookings holding it still
+// satisfy stillHolds and the seat is not already in that status.
+func (r *Reconciler) setSeatStatus(ctx context.Context, d *models.Discrepancy, status models.SeatStatus, stillHolds func(held []string) bool) error {
+	sessionID, err := primitive.ObjectIDFromHex(d.SessionID)
+	if err != nil {
+		return ErrDiscrepancyResolved
+	}
+	held, err := r.heldSeats(ctx, sessionID, d.SeatID)
+	if err != nil {
+		return err
+	}
+	if !stillHolds(held[d.SeatID]) {
+		return ErrDiscrepancyResolved
+	}
+
+	result, err := r.sessions.UpdateOne(ctx,
+		bson.M{"_id": sessionID, "seats": bson.M{"$elemMatch": bson.M{"id": d.SeatID, "status": bson.M{"$ne": status}}}},
+		bson.M{
+			"$set": bson.M{"seats.$.status": status, "updatedAt": time.Now().UTC()},
+			"$inc": bson.M{"version": 1},
+		},
+	)
+	if err != nil {
+		return err
+	}
+	if result.ModifiedCount == 0 {
+		return ErrDiscrepancyResolved
+	}
+	InvalidateSeatMap(ctx, d.SessionID)
+	return nil
+}
+
+// ApproveRepairs applies the repairs of the given discrepancies from a
+// stored report on behalf of an admin, and records the outcome in the report.
+// The returned map holds the error, if any, for each discrepancy.
+func (r *Reconciler) ApproveRepairs(ctx context.Context, reportID string, discrepancyIDs []string, approvedBy string) (map[string]string, error) {
+	oid, err := primitive.ObjectIDFromHex(reportID)
+	if err != nil {
+		return nil, ErrReportNotFound
+	}
+	var report models.ReconciliationReport
+	if err := r.reports.FindOne(ctx, bson.M{"_id": oid}).Decode(&report); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrReportNotFound
+		}
+		return nil, err
+	}
+
+	index := make(map[string]int, len(report.Discrepancies))
+	for i, d := range report.Discrepancies {
+		index[d.ID] = i
+	}
+
+	results := make(map[string]string, len(discrepancyIDs))
+	for _, id := range discrepancyIDs {
+		i, ok := index[id]
+		if !ok {
+			results[id] = ErrDiscrepancyNotFound.Error()
+			continue
+		}
+		d := &report.Discrepancies[i]
+		if d.RepairedAt != nil {
+			results[id] = "already repaired"
+			continue
+		}
+		if err := r.repair(ctx, reportID, d, approvedBy); err != nil {
+			results[id] = err.Error()
+			continue
+		}
+		results[id] = ""
+
+		_, err := r.reports.UpdateOne(ctx,
+			bson.M{"_id": oid},
+			bson.M{
+				"$set": bson.M{
+					"discrepancies.$[d].repairedAt": d.RepairedAt,
+					"discrepancies.$[d].repairedBy": d.RepairedBy,
+				},
+				"$inc": bson.M{"repaired": 1},
+			},
+			options.Update().SetArrayFilters(options.ArrayFilters{Filters: []interface{}{bson.M{"d.id": id}}}),
+		)
+		if err != nil {
+			log.Printf("⚠️ Failed to record repair of %s in report %s: %v", id, reportID, err)
+		}
+	}
+	return results, nil
+}
+
+func (r *Reconciler) GetReport(ctx context.Context, reportID string) (*models.ReconciliationReport, error) {
+	oid, err := primitive.ObjectIDFromHex(reportID)
+	if err != nil {
+		return nil, ErrReportNotFound
+	}
+	var report models.ReconciliationReport
+	if err := r.reports.FindOne(ctx, bson.M{"_id": oid}).Decode(&report); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrReportNotFound
+		}
+		return nil, err
+	}
+	return &report, nil
+}
+
+func (r *Reconciler) RecentReports(ctx context.Context, limit int64) ([]models.ReconciliationReport, error) {
+	cursor, err := r.reports.Find(ctx, bson.M{},
+		options.Find().SetSort(bson.D{{Key: "startedAt", Value: -1}}).SetLimit(limit))
+	if err != nil {
+		return nil, err
+	}
+	reports := []models.ReconciliationReport{}
+	if err := cursor.All(ctx, &reports); err != nil {
+		return nil, err
+	}
+	return reports, nil
+}
+
+func newDiscrepancy(d models.Discrepancy) models.Discrepancy {
+	sum := sha256.Sum256([]byte(string(d.Kind) + "\x00" + d.SessionID + "\x00" + d.SeatID))
+	d.ID = hex.EncodeToString(sum[:8])
+	return d
+}
+
+// sessionEnd is when a session is over, assuming the default run length when
+// it has no end time.
+func sessionEnd(s models.MovieSession) time.Time {
+	if s.EndTime.After(s.StartTime) {
+		return s.EndTime
+	}
+	return s.StartTime.Add(defaultSessionRunLength)
+}
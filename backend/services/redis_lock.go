@@ -85,6 +85,29 @@ func (s *RedisLockService) IsLocked(ctx context.Context, sessionID, seatID strin
 	return true, owner, nil
 }
 
+// LockOwners returns who holds each of seatIDs that is locked, in one round
+// trip.
+func (s *RedisLockService) LockOwners(ctx context.Context, sessionID string, seatIDs []string) (map[string]string, error) {
+	owners := make(map[string]string)
+	if len(seatIDs) == 0 {
+		return owners, nil
+	}
+	keys := make([]string, len(seatIDs))
+	for i, seatID := range seatIDs {
+		keys[i] = s.getLockKey(sessionID, seatID)
+	}
+	values, err := s.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to check lock status: %w", err)
+	}
+	for i, v := range values {
+		if owner, ok := v.(string); ok {
+			owners[seatIDs[i]] = owner
+		}
+	}
+	return owners, nil
+}
+
 func (s *RedisLockService) GetLockTTL(ctx context.Context, sessionID, seatID string) (time.Duration, error) {
 	key := s.getLockKey(sessionID, seatID)
 
@@ -120,40 +143,116 @@ func (s *RedisLockService) ExtendLock(ctx context.Context, sessionID, seatID, us
 }
 
 func (s *RedisLockService) LockMultipleSeats(ctx context.Context, sessionID string, seatIDs []string, userID string) ([]string, []string, error) {
-	var lockedSeats []string
-	var failedSeats []string
-
-	pipe := s.client.Pipeline()
+	return s.LockMultipleSeatsFor(ctx, sessionID, seatIDs, userID, LockDuration)
+}
 
-	for _, seatID := range seatIDs {
-		key := s.getLockKey(sessionID, seatID)
-		pipe.SetNX(ctx, key, userID, LockDuration)
+// lockScript locks every key in KEYS for ARGV[1] for ARGV[2] milliseconds,
+// or none of them if any is locked already. It returns the 1-based positions
+// of the keys that were locked already.
+var lockScript = redis.NewScript(`
+local taken = {}
+for i, key in ipairs(KEYS) do
+	if redis.call('EXISTS', key) == 1 then
+		table.insert(taken, i)
+	end
+end
+if #taken > 0 then
+	return taken
+end
+for _, key in ipairs(KEYS) do
+	redis.call('SET', key, ARGV[1], 'PX', ARGV[2])
+end
+return taken
+`)
+
+// LockMultipleSeatsFor is LockMultipleSeats with a lock duration other than
+// LockDuration, e.g. for seats held for a waitlisted customer. The seats are
+// locked in one script, all or none, so no one ever sees part of them
+// locked. If any is locked already it returns those seats as failed.
+func (s *RedisLockService) LockMultipleSeatsFor(ctx context.Context, sessionID string, seatIDs []string, userID string, ttl time.Duration) ([]string, []string, error) {
+	if len(seatIDs) == 0 {
+		return nil, nil, nil
+	}
+	keys := make([]string, len(seatIDs))
+	for i, seatID := range seatIDs {
+		keys[i] = s.getLockKey(sessionID, seatID)
 	}
 
-	results, err := pipe.Exec(ctx)
-	if err != nil && err != redis.Nil {
-		return nil, seatIDs, fmt.Errorf("failed to execute lock pipeline: %w", err)
+	taken, err := lockScript.Run(ctx, s.client, keys, userID, ttl.Milliseconds()).Int64Slice()
+	if err != nil {
+		return nil, seatIDs, fmt.Errorf("failed to lock seats: %w", err)
 	}
+	if len(taken) > 0 {
+		failedSeats := make([]string, len(taken))
+		for i, pos := range taken {
+			failedSeats[i] = seatIDs[pos-1]
+		}
+		return nil, failedSeats, nil
+	}
+	return seatIDs, nil, nil
+}
 
-	for i, result := range results {
-		cmd := result.(*redis.BoolCmd)
-		success, _ := cmd.Result()
+// transferScript moves the seat locks in KEYS to ARGV[2] for ARGV[3]
+// milliseconds, if each is held by ARGV[1], by ARGV[2] already, or by nobody.
+// With ARGV[4] == "all" it moves every lock or none. It returns 1 for each
+// key moved and 0 for the rest.
+var transferScript = redis.NewScript(`
+local moved = {}
+for i, key in ipairs(KEYS) do
+	local owner = redis.call('GET', key)
+	if not owner or owner == ARGV[1] or owner == ARGV[2] then
+		moved[i] = 1
+	elseif ARGV[4] == 'all' then
+		for j = 1, #KEYS do moved[j] = 0 end
+		return moved
+	else
+		moved[i] = 0
+	end
+end
+for i, key in ipairs(KEYS) do
+	if moved[i] == 1 then
+		redis.call('SET', key, ARGV[2], 'PX', ARGV[3])
+	end
+end
+return moved
+`)
+
+// TransferSeats hands the locks of seatIDs from one owner to another in a
+// single step, so no one else can lock a seat in between. Seats nobody has
+// locked are taken too; seats locked by anyone else are left alone. It
+// returns the seats now locked by to.
+func (s *RedisLockService) TransferSeats(ctx context.Context, sessionID string, seatIDs []string, from, to string, ttl time.Duration) ([]string, error) {
+	return s.transfer(ctx, sessionID, seatIDs, from, to, ttl, "some")
+}
 
-		if success {
-			lockedSeats = append(lockedSeats, seatIDs[i])
-		} else {
-			failedSeats = append(failedSeats, seatIDs[i])
-		}
+// TransferAllSeats is TransferSeats for all of seatIDs or none of them.
+func (s *RedisLockService) TransferAllSeats(ctx context.Context, sessionID string, seatIDs []string, from, to string, ttl time.Duration) (bool, error) {
+	moved, err := s.transfer(ctx, sessionID, seatIDs, from, to, ttl, "all")
+	if err != nil {
+		return false, err
 	}
+	return len(moved) == len(seatIDs), nil
+}
 
-	if len(failedSeats) > 0 && len(lockedSeats) > 0 {
-		for _, seatID := range lockedSeats {
-			s.UnlockSeat(ctx, sessionID, seatID, userID)
+func (s *RedisLockService) transfer(ctx context.Context, sessionID string, seatIDs []string, from, to string, ttl time.Duration, mode string) ([]string, error) {
+	if len(seatIDs) == 0 {
+		return nil, nil
+	}
+	keys := make([]string, len(seatIDs))
+	for i, seatID := range seatIDs {
+		keys[i] = s.getLockKey(sessionID, seatID)
+	}
+	flags, err := transferScript.Run(ctx, s.client, keys, from, to, ttl.Milliseconds(), mode).Int64Slice()
+	if err != nil {
+		return nil, fmt.Errorf("failed to transfer locks: %w", err)
+	}
+	var moved []string
+	for i, flag := range flags {
+		if flag == 1 {
+			moved = append(moved, seatIDs[i])
 		}
-		return nil, seatIDs, fmt.Errorf("could not lock all seats, some are already locked")
 	}
-
-	return lockedSeats, failedSeats, nil
+	return moved, nil
 }
 
 func (s *RedisLockService) UnlockMultipleSeats(ctx context.Context, sessionID string, seatIDs []string, userID string) error {
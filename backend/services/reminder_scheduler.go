@@ -0,0 +1,273 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"cinema-booking-system/config"
+	"cinema-booking-system/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const reminderClaimTimeout = 5 * time.Minute
+
+// ReminderScheduler emails customers a configurable time before their show.
+// Every reminder is claimed by inserting a booking_reminders document keyed by
+// booking and offset, so it is sent at most once no matter how many replicas
+// run the scheduler or how often they restart.
+type ReminderScheduler struct {
+	sessions  *mongo.Collection
+	bookings  *mongo.Collection
+	users     *mongo.Collection
+	reminders *mongo.Collection
+
+	outbox       *EmailOutboxService
+	eventService *EventProducerService
+	offsets      []time.Duration
+	interval     time.Duration
+}
+
+func NewReminderScheduler(outbox *EmailOutboxService, eventService *EventProducerService, offsets []time.Duration, interval time.Duration) *ReminderScheduler {
+	sorted := append([]time.Duration(nil), offsets...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	return &ReminderScheduler{
+		sessions:     config.MongoDB.Collection("sessions"),
+		bookings:     config.MongoDB.Collection("bookings"),
+		users:        config.MongoDB.Collection("users"),
+		reminders:    config.MongoDB.Collection("booking_reminders"),
+		outbox:       outbox,
+		eventService: eventService,
+		offsets:      sorted,
+		interval:     interval,
+	}
+}
+
+func (s *ReminderScheduler) Start(ctx context.Context) {
+	if len(s.offsets) == 0 || s.interval <= 0 {
+		log.Println("⚠️ Reminder scheduler disabled (no REMINDER_OFFSETS)")
+		return
+	}
+
+	s.reminders.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "bookingId", Value: 1}},
+	})
+
+	labels := make([]string, len(s.offsets))
+	for i, o := range s.offsets {
+		labels[i] = ReminderOffsetLabel(o)
+	}
+	log.Printf("⏰ Reminder scheduler started (offsets: %v, every %s)", labels, s.interval)
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		if n, err := s.RunOnce(ctx); err != nil {
+			log.Printf("⚠️ Reminder run failed: %v", err)
+		} else if n > 0 {
+			log.Printf("⏰ Queued %d reminder(s)", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Println("⏰ Reminder scheduler stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce queues every reminder that is due now and returns how many were
+// queued.
+func (s *ReminderScheduler) RunOnce(ctx context.Context) (int, error) {
+	now := time.Now().UTC()
+	horizon := now.Add(s.offsets[len(s.offsets)-1])
+
+	cursor, err := s.sessions.Find(ctx,
+		// A cancelled session's bookings stay CONFIRMED until the
+		// cancellation worker refunds them; they must not be reminded.
+		bson.M{
+			"startTime": bson.M{"$gt": now, "$lte": horizon},
+			"status":    bson.M{"$ne": models.SessionCancelled},
+		},
+		options.Find().SetProjection(bson.M{"seats": 0}),
+	)
+	if err != nil {
+		return 0, err
+	}
+	var sessions []models.MovieSession
+	if err := cursor.All(ctx, &sessions); err != nil {
+		return 0, err
+	}
+
+	queued := 0
+	for _, session := range sessions {
+		cursor, err := s.bookings.Find(ctx, bson.M{
+			"sessionId": session.ID,
+			"status":    models.BookingStatusConfirmed,
+		})
+		if err != nil {
+			return queued, err
+		}
+		var bookings []models.Booking
+		if err := cursor.All(ctx, &bookings); err != nil {
+			return queued, err
+		}
+
+		for _, booking := range bookings {
+			sent, err := s.remind(ctx, booking, session, now)
+			if err != nil {
+				log.Printf("⚠️ Reminder for booking %s failed: %v", booking.ID.Hex(), err)
+				continue
+			}
+			if sent {
+				queued++
+			}
+		}
+	}
+	return queued, nil
+}
+
+// dueOffset returns the smallest offset whose reminder time has passed. A
+// booking made after that time does not get that reminder at all.
+func (s *ReminderScheduler) dueOffset(booking models.Booking, session models.MovieSession, now time.Time) (time.Duration, bool) {
+	for _, o := range s.offsets {
+		remindAt := session.StartTime.Add(-o)
+		if !now.Before(remindAt) {
+			return o, booking.CreatedAt.Before(remindAt)
+		}
+	}
+	return 0, false
+}
+
+func (s *ReminderScheduler) remind(ctx context.Context, booking models.Booking, session models.MovieSession, now time.Time) (bool, error) {
+	offset, ok := s.dueOffset(booking, session, now)
+	if !ok {
+		return false, nil
+	}
+	label := ReminderOffsetLabel(offset)
+	key := booking.ID.Hex() + ":" + label
+
+	claimed, err := s.claim(ctx, key, booking.ID, label, now)
+	if err != nil || !claimed {
+		return false, err
+	}
+
+	prefs := s.preferences(ctx, booking)
+	if !prefs.EmailReminders {
+		return false, s.finish(ctx, key, models.ReminderSkipped, "disabled by user", primitive.NilObjectID)
+	}
+	if len(prefs.ReminderOffsets) > 0 && !containsString(prefs.ReminderOffsets, label) {
+		return false, s.finish(ctx, key, models.ReminderSkipped, "offset not selected by user", primitive.NilObjectID)
+	}
+
+	emailID, err := s.outbox.EnqueueBookingEmailOnce(ctx, "reminder:"+key, models.EmailKindBookingReminder, booking.UserEmail, BookingEmailData{
+		Locale:      booking.Locale,
+		UserName:    booking.UserEmail,
+		BookingID:   booking.ID.Hex(),
+		MovieTitle:  session.MovieTitle,
+		Theater:     session.Theater,
+		Seats:       booking.Seats,
+		TotalAmount: booking.TotalAmount,
+		ShowTime:    session.StartTime,
+		CalendarURL: BookingCalendarURL(booking.ID.Hex()),
+		TicketsURL:  BookingTicketsURL(booking.ID.Hex()),
+	})
+	if err != nil {
+		// Left PENDING, so the claim is retried once it times out.
+		return false, err
+	}
+
+	if err := s.finish(ctx, key, models.ReminderQueued, "", emailID); err != nil {
+		return false, err
+	}
+
+	go s.eventService.LogReminderSent(context.Background(), booking.ID.Hex(), session.ID.Hex(), booking.UserID, booking.Seats, label, emailID.Hex())
+	return true, nil
+}
+
+// claim takes ownership of a reminder. A PENDING claim older than
+// reminderClaimTimeout belongs to a replica that died mid-send and is taken
+// over.
+func (s *ReminderScheduler) claim(ctx context.Context, key string, bookingID primitive.ObjectID, label string, now time.Time) (bool, error) {
+	_, err := s.reminders.InsertOne(ctx, models.BookingReminder{
+		ID:        key,
+		BookingID: bookingID,
+		Offset:    label,
+		Status:    models.ReminderPending,
+		ClaimedBy: InstanceID,
+		ClaimedAt: now,
+		UpdatedAt: now,
+	})
+	if err == nil {
+		return true, nil
+	}
+	if !mongo.IsDuplicateKeyError(err) {
+		return false, err
+	}
+
+	result, err := s.reminders.UpdateOne(ctx,
+		bson.M{
+			"_id":       key,
+			"status":    models.ReminderPending,
+			"claimedAt": bson.M{"$lt": now.Add(-reminderClaimTimeout)},
+		},
+		bson.M{"$set": bson.M{"claimedBy": InstanceID, "claimedAt": now, "updatedAt": now}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount == 1, nil
+}
+
+func (s *ReminderScheduler) finish(ctx context.Context, key string, status models.ReminderStatus, reason string, emailID primitive.ObjectID) error {
+	set := bson.M{"status": status, "updatedAt": time.Now().UTC()}
+	if reason != "" {
+		set["reason"] = reason
+	}
+	if !emailID.IsZero() {
+		set["emailId"] = emailID
+	}
+	_, err := s.reminders.UpdateOne(ctx, bson.M{"_id": key, "claimedBy": InstanceID}, bson.M{"$set": set})
+	return err
+}
+
+func (s *ReminderScheduler) preferences(ctx context.Context, booking models.Booking) models.NotificationPreferences {
+	filter := bson.M{"email": booking.UserEmail}
+	if oid, err := primitive.ObjectIDFromHex(booking.UserID); err == nil {
+		filter = bson.M{"$or": []bson.M{{"_id": oid}, {"email": booking.UserEmail}}}
+	}
+
+	var user models.User
+	err := s.users.FindOne(ctx, filter, options.FindOne().SetProjection(bson.M{"notificationPreferences": 1})).Decode(&user)
+	if err != nil || user.NotificationPreferences == nil {
+		return models.DefaultNotificationPreferences()
+	}
+	return *user.NotificationPreferences
+}
+
+// ReminderOffsetLabel formats an offset the way REMINDER_OFFSETS and user
+// preferences spell it, e.g. "24h" or "90m".
+func ReminderOffsetLabel(d time.Duration) string {
+	if d%time.Hour == 0 {
+		return fmt.Sprintf("%dh", d/time.Hour)
+	}
+	return fmt.Sprintf("%dm", d/time.Minute)
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,188 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"time"
+
+	"cinema-booking-system/eventbus"
+	"cinema-booking-system/events"
+	"cinema-booking-system/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type ReplaySink interface {
+	Write(ctx context.Context, auditLog models.AuditLog) error
+	Close() error
+}
+
+type StoreReplaySink struct {
+	store AuditLogStore
+}
+
+func NewStoreReplaySink(store AuditLogStore) *StoreReplaySink {
+	return &StoreReplaySink{store: store}
+}
+
+func (s *StoreReplaySink) Write(ctx context.Context, auditLog models.AuditLog) error {
+	if auditLog.ID.IsZero() {
+		auditLog.ID = primitive.NewObjectID()
+	}
+	return s.store.SaveAuditLog(ctx, auditLog)
+}
+
+func (s *StoreReplaySink) Close() error { return nil }
+
+type JSONLReplaySink struct {
+	w      *bufio.Writer
+	closer io.Closer
+	enc    *json.Encoder
+}
+
+func NewJSONLReplaySink(w io.Writer) *JSONLReplaySink {
+	bw := bufio.NewWriter(w)
+	sink := &JSONLReplaySink{w: bw, enc: json.NewEncoder(bw)}
+	if c, ok := w.(io.Closer); ok && w != os.Stdout {
+		sink.closer = c
+	}
+	return sink
+}
+
+func (s *JSONLReplaySink) Write(ctx context.Context, auditLog models.AuditLog) error {
+	return s.enc.Encode(auditLog)
+}
+
+func (s *JSONLReplaySink) Close() error {
+	if err := s.w.Flush(); err != nil {
+		return err
+	}
+	if s.closer != nil {
+		return s.closer.Close()
+	}
+	return nil
+}
+
+type ReplayOptions struct {
+	Topic        string
+	From         eventbus.Position
+	Until        time.Time
+	DryRun       bool
+	RatePerSec   int
+	ProgressEach time.Duration
+}
+
+type ReplayStats struct {
+	Read    int
+	Written int
+	// WouldWrite counts the messages a dry run would have written.
+	WouldWrite int
+	Skipped    int
+	Invalid    int
+}
+
+type ReplayService struct {
+	source   eventbus.Replayer
+	sink     ReplaySink
+	registry *events.Registry
+}
+
+func NewReplayService(source eventbus.Replayer, sink ReplaySink) *ReplayService {
+	return &ReplayService{
+		source:   source,
+		sink:     sink,
+		registry: events.Default,
+	}
+}
+
+func (s *ReplayService) Run(ctx context.Context, opts ReplayOptions) (ReplayStats, error) {
+	var stats ReplayStats
+
+	var throttle <-chan time.Time
+	if opts.RatePerSec > 0 {
+		ticker := time.NewTicker(time.Second / time.Duration(opts.RatePerSec))
+		defer ticker.Stop()
+		throttle = ticker.C
+	}
+
+	progressEach := opts.ProgressEach
+	if progressEach <= 0 {
+		progressEach = 5 * time.Second
+	}
+	started := time.Now()
+	lastReport := started
+
+	report := func(final bool, msg eventbus.Message) {
+		elapsed := time.Since(started).Seconds()
+		rate := 0.0
+		if elapsed > 0 {
+			rate = float64(stats.Read) / elapsed
+		}
+		prefix := "⏩ Replay progress"
+		if final {
+			prefix = "✅ Replay finished"
+		}
+		written := fmt.Sprintf("written=%d", stats.Written)
+		if opts.DryRun {
+			written = fmt.Sprintf("would-write=%d (dry run)", stats.WouldWrite)
+		}
+		log.Printf("%s: read=%d %s skipped=%d invalid=%d (%.1f msg/s) at %s[%d]@%d",
+			prefix, stats.Read, written, stats.Skipped, stats.Invalid, rate, msg.Topic, msg.Partition, msg.Offset)
+	}
+
+	var last eventbus.Message
+	err := s.source.Replay(ctx, opts.Topic, opts.From, func(ctx context.Context, msg eventbus.Message) error {
+		last = msg
+		stats.Read++
+
+		if time.Since(lastReport) >= progressEach {
+			report(false, msg)
+			lastReport = time.Now()
+		}
+
+		auditLog, err := DecodeAuditLog(s.registry, msg.Value)
+		if err != nil {
+			stats.Invalid++
+			log.Printf("⚠️ Skipping invalid message %s[%d]@%d: %v", msg.Topic, msg.Partition, msg.Offset, err)
+			return nil
+		}
+
+		if !opts.Until.IsZero() && auditLog.Timestamp.After(opts.Until) {
+			stats.Skipped++
+			return nil
+		}
+
+		if opts.DryRun {
+			stats.WouldWrite++
+			return nil
+		}
+
+		if throttle != nil {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-throttle:
+			}
+		}
+
+		if err := s.sink.Write(ctx, auditLog); err != nil {
+			return fmt.Errorf("sink write failed at %s[%d]@%d: %w", msg.Topic, msg.Partition, msg.Offset, err)
+		}
+		stats.Written++
+		return nil
+	})
+
+	report(true, last)
+
+	if s.sink != nil {
+		if closeErr := s.sink.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	return stats, err
+}
@@ -0,0 +1,50 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"cinema-booking-system/eventbus"
+	"cinema-booking-system/models"
+)
+
+// recordingSink counts the audit logs written to it.
+type recordingSink struct {
+	writes int
+}
+
+func (s *recordingSink) Write(ctx context.Context, auditLog models.AuditLog) error {
+	s.writes++
+	return nil
+}
+
+func (s *recordingSink) Close() error { return nil }
+
+func TestReplayDryRunWritesNothing(t *testing.T) {
+	bus := eventbus.NewMemoryBus(2)
+	defer bus.Close()
+
+	ctx := context.Background()
+	producer := NewEventProducerServiceWithBus(bus, pipelineTopic)
+	if err := producer.LogSeatLocked(ctx, "session-1", "user-1", []string{"A1"}); err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+	if err := producer.LogSeatUnlocked(ctx, "session-1", "user-1", []string{"A1"}, "manual"); err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+	if err := bus.Publish(ctx, eventbus.Message{Topic: pipelineTopic, Key: []byte("bad"), Value: []byte("not json")}); err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+
+	sink := &recordingSink{}
+	stats, err := NewReplayService(bus, sink).Run(ctx, ReplayOptions{Topic: pipelineTopic, DryRun: true})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if stats.Read != 3 || stats.WouldWrite != 2 || stats.Written != 0 || stats.Invalid != 1 {
+		t.Errorf("stats = %+v, want 3 read, 2 would-write, 0 written, 1 invalid", stats)
+	}
+	if sink.writes != 0 {
+		t.Errorf("dry run wrote %d records", sink.writes)
+	}
+}
@@ -0,0 +1,170 @@
+package services
+
+import (
+	"context"
+	"strings"
+
+	"cinema-booking-system/config"
+	"cinema-booking-system/models"
+)
+
+// SingleSeatGapRuleApplies reports whether a session refuses selections that
+// leave a lone empty seat. The session's own setting wins over the theaters
+// listed in NO_SINGLE_SEAT_GAP_THEATERS.
+func SingleSeatGapRuleApplies(session *models.MovieSession) bool {
+	if session.NoSingleSeatGap != nil {
+		return *session.NoSingleSeatGap
+	}
+	if config.AppConfig == nil {
+		return false
+	}
+	for _, theater := range config.AppConfig.SingleSeatGapTheaters {
+		if theater == "*" || strings.EqualFold(theater, session.Theater) {
+			return true
+		}
+	}
+	return false
+}
+
+// SingleSeatGaps returns the seats that taking seatIDs would leave empty on
+// their own: a free seat beside the selection whose other neighbour is taken,
+// the end of the row or an aisle. Seats in locked count as taken whoever
+// holds them. Lone seats that already existed are not reported.
+func SingleSeatGaps(seats []models.Seat, locked map[string]string, seatIDs []string) []string {
+	selected := make(map[string]bool, len(seatIDs))
+	for _, seatID := range seatIDs {
+		selected[seatID] = true
+	}
+
+	type position struct {
+		row    string
+		number int
+	}
+	byPosition := make(map[position]models.Seat, len(seats))
+	for _, seat := range seats {
+		byPosition[position{seat.Row, seat.Number}] = seat
+	}
+	// A missing seat number is an aisle, so it is never empty.
+	empty := func(p position) bool {
+		seat, ok := byPosition[p]
+		return ok && !selected[seat.ID] && seatFree(seat, locked, "")
+	}
+
+	var gaps []string
+	seen := make(map[string]bool)
+	for _, seat := range seats {
+		if !selected[seat.ID] {
+			continue
+		}
+		for _, step := range []int{-1, 1} {
+			next := position{seat.Row, seat.Number + step}
+			if !empty(next) || empty(position{seat.Row, seat.Number + 2*step}) {
+				continue
+			}
+			if gapID := byPosition[next].ID; !seen[gapID] {
+				seen[gapID] = true
+				gaps = append(gaps, gapID)
+			}
+		}
+	}
+	return gaps
+}
+
+// CheckSingleSeatGap refuses a selection that would leave a lone empty seat
+// and suggests a block of the same size and category that would not. When no
+// such block is left in the session the selection is allowed, so the rule
+// never stops the last seats from selling.
+func CheckSingleSeatGap(session *models.MovieSession, seatIDs []string, locked map[string]string, userID string) error {
+	gaps := SingleSeatGaps(session.Seats, locked, seatIDs)
+	if len(gaps) == 0 {
+		return nil
+	}
+	suggestion := suggestGapFreeSeats(session, seatIDs, locked, userID)
+	if suggestion == nil {
+		return nil
+	}
+
+	rejected := &LockRejectedError{Code: LockRejectSingleSeatGap, Suggestion: suggestion}
+	for _, seatID := range gaps {
+		rejected.Seats = append(rejected.Seats, SeatRejection{SeatID: seatID, Code: LockRejectSingleSeatGap})
+	}
+	return rejected
+}
+
+// EnforceSingleSeatGap applies the single-seat gap rule to a selection when
+// the session has it, reading who holds the session's free seats first.
+func EnforceSingleSeatGap(ctx context.Context, locks *RedisLockService, session *models.MovieSession, seatIDs []string, userID string) error {
+	if !SingleSeatGapRuleApplies(session) {
+		return nil
+	}
+	owners, err := locks.LockOwners(ctx, session.ID.Hex(), availableSeatIDs(session))
+	if err != nil {
+		return err
+	}
+	return CheckSingleSeatGap(session, seatIDs, owners, userID)
+}
+
+// suggestGapFreeSeats picks the best block of adjacent seats, as many as were
+// selected and in the category of the first, that leaves no lone seat. Blocks
+// in the row the customer chose come first. The customer's own locks do not
+// stand in the way, since they give those seats up for the suggestion.
+func suggestGapFreeSeats(session *models.MovieSession, seatIDs []string, locked map[string]string, userID string) []string {
+	others := make(map[string]string, len(locked))
+	for seatID, owner := range locked {
+		if owner != userID {
+			others[seatID] = owner
+		}
+	}
+
+	prefs := SeatPreferences{PartySize: len(seatIDs)}
+	row := ""
+	for _, seat := range session.Seats {
+		if seat.ID == seatIDs[0] {
+			row = seat.Row
+			prefs.Category = seat.Category
+			if prefs.Category == "" {
+				prefs.Category = models.SeatCategoryStandard
+			}
+			break
+		}
+	}
+
+	var best []string
+	for _, block := range RankSeatBlocks(session.Seats, others, prefs) {
+		if len(SingleSeatGaps(session.Seats, others, block.SeatIDs)) > 0 {
+			continue
+		}
+		if block.Row == row {
+			return block.SeatIDs
+		}
+		if best == nil {
+			best = block.SeatIDs
+		}
+	}
+	return best
+}
+
+// withoutSingleSeatGaps drops the blocks that would leave a lone seat, unless
+// every block would.
+func withoutSingleSeatGaps(seats []models.Seat, locked map[string]string, blocks []SeatBlock) []SeatBlock {
+	var kept []SeatBlock
+	for _, block := range blocks {
+		if len(SingleSeatGaps(seats, locked, block.SeatIDs)) == 0 {
+			kept = append(kept, block)
+		}
+	}
+	if len(kept) == 0 {
+		return blocks
+	}
+	return kept
+}
+
+func availableSeatIDs(session *models.MovieSession) []string {
+	var seatIDs []string
+	for _, seat := range session.Seats {
+		if seat.Status == models.SeatAvailable || seat.Status == "" {
+			seatIDs = append(seatIDs, seat.ID)
+		}
+	}
+	return seatIDs
+}
@@ -0,0 +1,175 @@
+package services
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"cinema-booking-system/config"
+	"cinema-booking-system/models"
+)
+
+func TestSingleSeatGaps(t *testing.T) {
+	tests := []struct {
+		name    string
+		seats   []models.Seat
+		locked  map[string]string
+		seatIDs []string
+		want    []string
+	}{
+		{
+			name:    "seat at the end of the row",
+			seats:   hall("A", 6),
+			seatIDs: []string{"A2", "A3"},
+			want:    []string{"A1"},
+		},
+		{
+			name:    "taking the end seat leaves no gap",
+			seats:   hall("A", 6),
+			seatIDs: []string{"A1", "A2"},
+		},
+		{
+			name:    "seat beside an aisle",
+			seats:   removed(hall("A", 6), "A4"),
+			seatIDs: []string{"A1", "A2"},
+			want:    []string{"A3"},
+		},
+		{
+			name:    "an aisle is not a gap",
+			seats:   removed(hall("A", 6), "A4"),
+			seatIDs: []string{"A5"},
+			want:    []string{"A6"},
+		},
+		{
+			name:    "seat beside a booked one",
+			seats:   withSeats(hall("A", 6), booked, "A5"),
+			seatIDs: []string{"A2", "A3"},
+			want:    []string{"A1", "A4"},
+		},
+		{
+			name:    "seat beside one locked by someone else",
+			seats:   hall("A", 6),
+			locked:  map[string]string{"A4": "someone-else"},
+			seatIDs: []string{"A2"},
+			want:    []string{"A1", "A3"},
+		},
+		{
+			name:    "a lone seat that was already there is not reported",
+			seats:   withSeats(hall("A", 6), booked, "A2"),
+			seatIDs: []string{"A3", "A4"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := SingleSeatGaps(tt.seats, tt.locked, tt.seatIDs)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("gaps = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckSingleSeatGap(t *testing.T) {
+	tests := []struct {
+		name           string
+		seats          []models.Seat
+		locked         map[string]string
+		seatIDs        []string
+		wantGaps       []string
+		wantSuggestion []string
+	}{
+		{
+			name:    "no gap",
+			seats:   hall("A", 6),
+			seatIDs: []string{"A1", "A2"},
+		},
+		{
+			name:           "edge seat left alone",
+			seats:          hall("A", 6),
+			seatIDs:        []string{"A2", "A3"},
+			wantGaps:       []string{"A1"},
+			wantSuggestion: []string{"A3", "A4"},
+		},
+		{
+			name:           "seat beside an aisle left alone",
+			seats:          removed(hall("A", 8), "A4"),
+			seatIDs:        []string{"A6", "A7"},
+			wantGaps:       []string{"A5", "A8"},
+			wantSuggestion: []string{"A5", "A6"},
+		},
+		{
+			name:           "the customer's own locks do not block the suggestion",
+			seats:          hall("A", 6),
+			locked:         map[string]string{"A3": "user-1", "A4": "user-1", "A6": "someone-else"},
+			seatIDs:        []string{"A4"},
+			wantGaps:       []string{"A5"},
+			wantSuggestion: []string{"A3"},
+		},
+		{
+			name:    "allowed when no gap-free block is left",
+			seats:   hall("A", 3),
+			seatIDs: []string{"A1", "A2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			session := &models.MovieSession{Seats: tt.seats}
+			err := CheckSingleSeatGap(session, tt.seatIDs, tt.locked, "user-1")
+
+			if tt.wantGaps == nil {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			var rejected *LockRejectedError
+			if !errors.As(err, &rejected) {
+				t.Fatalf("error = %v, want a LockRejectedError", err)
+			}
+			if rejected.Code != LockRejectSingleSeatGap {
+				t.Errorf("code = %s, want %s", rejected.Code, LockRejectSingleSeatGap)
+			}
+			var gaps []string
+			for _, seat := range rejected.Seats {
+				gaps = append(gaps, seat.SeatID)
+			}
+			if !reflect.DeepEqual(gaps, tt.wantGaps) {
+				t.Errorf("gaps = %v, want %v", gaps, tt.wantGaps)
+			}
+			if !reflect.DeepEqual(rejected.Suggestion, tt.wantSuggestion) {
+				t.Errorf("suggestion = %v, want %v", rejected.Suggestion, tt.wantSuggestion)
+			}
+		})
+	}
+}
+
+func TestSingleSeatGapRuleApplies(t *testing.T) {
+	previous := config.AppConfig
+	defer func() { config.AppConfig = previous }()
+
+	on, off := true, false
+	tests := []struct {
+		name     string
+		theaters []string
+		session  models.MovieSession
+		want     bool
+	}{
+		{name: "no theaters listed", session: models.MovieSession{Theater: "Hall 1"}},
+		{name: "theater listed", theaters: []string{"hall 1"}, session: models.MovieSession{Theater: "Hall 1"}, want: true},
+		{name: "other theater listed", theaters: []string{"Hall 2"}, session: models.MovieSession{Theater: "Hall 1"}},
+		{name: "every theater", theaters: []string{"*"}, session: models.MovieSession{Theater: "Hall 1"}, want: true},
+		{name: "session turns it on", session: models.MovieSession{Theater: "Hall 1", NoSingleSeatGap: &on}, want: true},
+		{name: "session turns it off", theaters: []string{"*"}, session: models.MovieSession{Theater: "Hall 1", NoSingleSeatGap: &off}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config.AppConfig = &config.Config{SingleSeatGapTheaters: tt.theaters}
+			if got := SingleSeatGapRuleApplies(&tt.session); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
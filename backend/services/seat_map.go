@@ -0,0 +1,221 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"cinema-booking-system/config"
+	"cinema-booking-system/models"
+
+	"github.com/redis/go-redis/v9"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	SeatMapKeyPrefix = "seat_map:"
+
+	// SeatMapTTL bounds how stale a cached seat map can be when an
+	// invalidation is missed. Booking re-checks seats in MongoDB, so a stale
+	// map can only let a doomed lock through, never a double booking.
+	SeatMapTTL = 30 * time.Second
+)
+
+// Reasons a seat lock is rejected, returned to clients as "code".
+const (
+	LockRejectInvalidSession   = "INVALID_SESSION"
+	LockRejectSessionNotFound  = "SESSION_NOT_FOUND"
+	LockRejectSalesNotOpen     = "SALES_NOT_OPEN"
+	LockRejectSalesClosed      = "SALES_CLOSED"
+	LockRejectSessionStarted   = "SESSION_STARTED"
+	LockRejectSessionCancelled = "SESSION_CANCELLED"
+	LockRejectNoSeats          = "NO_SEATS"
+	LockRejectDuplicateSeat    = "DUPLICATE_SEAT"
+	LockRejectSeatNotFound     = "SEAT_NOT_FOUND"
+	LockRejectSeatBooked       = "SEAT_BOOKED"
+	LockRejectSeatBlocked      = "SEAT_BLOCKED"
+	LockRejectSeatLocked       = "SEAT_LOCKED"
+	LockRejectNoBlock          = "NO_CONTIGUOUS_BLOCK"
+	LockRejectSingleSeatGap    = "SINGLE_SEAT_GAP"
+)
+
+var ErrSessionNotFound = errors.New("session not found")
+
+// SeatRejection is one seat that cannot be locked and why.
+type SeatRejection struct {
+	SeatID string `json:"seatId"`
+	Code   string `json:"code"`
+}
+
+// LockRejectedError explains why a lock request was refused. Code is the
+// first problem found; Seats lists every seat that caused one. Suggestion,
+// when set, is a selection of the same size that would be accepted.
+type LockRejectedError struct {
+	Code          string
+	SessionStatus models.SessionStatus
+	Seats         []SeatRejection
+	Suggestion    []string
+}
+
+func (e *LockRejectedError) Error() string {
+	switch e.Code {
+	case LockRejectInvalidSession:
+		return "Invalid session ID"
+	case LockRejectSessionNotFound:
+		return "Session not found"
+	case LockRejectNoSeats:
+		return "No seats selected"
+	case LockRejectDuplicateSeat:
+		return "Seat " + e.Seats[0].SeatID + " was selected more than once"
+	case LockRejectSeatNotFound:
+		return "Seat " + e.Seats[0].SeatID + " does not exist in this session"
+	case LockRejectSeatBooked:
+		return "Seat " + e.Seats[0].SeatID + " is already booked"
+	case LockRejectSeatBlocked:
+		return "Seat " + e.Seats[0].SeatID + " is not available for sale"
+	case LockRejectSeatLocked:
+		return "Seat " + e.Seats[0].SeatID + " is being booked by someone else"
+	case LockRejectNoBlock:
+		return "No block of adjacent seats is free for your party"
+	case LockRejectSingleSeatGap:
+		return "Seat " + e.Seats[0].SeatID + " would be left empty on its own"
+	}
+	if e.SessionStatus != "" {
+		return (&SessionNotOnSaleError{Status: e.SessionStatus}).Error()
+	}
+	return e.Code
+}
+
+// SeatMapCache keeps the parts of a session the lock path needs - its times,
+// status, seat rules and seat layout, statuses and prices - in Redis, so
+// locking does not read the whole session from MongoDB on every click.
+type SeatMapCache struct {
+	sessions *mongo.Collection
+	client   *redis.Client
+}
+
+func NewSeatMapCache() *SeatMapCache {
+	return &SeatMapCache{
+		sessions: config.MongoDB.Collection("sessions"),
+		client:   config.RedisClient,
+	}
+}
+
+// Get returns the seat map of a session, from the cache when possible. It
+// returns ErrSessionNotFound for unknown or malformed session IDs.
+func (c *SeatMapCache) Get(ctx context.Context, sessionID string) (*models.MovieSession, error) {
+	objectID, err := primitive.ObjectIDFromHex(sessionID)
+	if err != nil {
+		return nil, ErrSessionNotFound
+	}
+
+	if c.client != nil {
+		raw, err := c.client.Get(ctx, SeatMapKeyPrefix+sessionID).Bytes()
+		if err == nil {
+			var cached models.MovieSession
+			if err := json.Unmarshal(raw, &cached); err == nil {
+				return &cached, nil
+			}
+		} else if err != redis.Nil {
+			log.Printf("⚠️ Seat map cache unavailable for session %s: %v", sessionID, err)
+		}
+	}
+
+	var session models.MovieSession
+	err = c.sessions.FindOne(ctx, bson.M{"_id": objectID},
+		options.FindOne().SetProjection(bson.M{
+			"seats.id": 1, "seats.row": 1, "seats.number": 1, "seats.status": 1,
+			"seats.price": 1, "seats.category": 1,
+			"status": 1, "startTime": 1, "endTime": 1, "salesOpenAt": 1,
+			"theater": 1, "noSingleSeatGap": 1,
+		}),
+	).Decode(&session)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrSessionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load seat map: %w", err)
+	}
+
+	if c.client != nil {
+		if data, err := json.Marshal(session); err == nil {
+			c.client.Set(ctx, SeatMapKeyPrefix+sessionID, data, SeatMapTTL)
+		}
+	}
+	return &session, nil
+}
+
+// InvalidateSeatMap drops the cached seat map of a session after its seats
+// or status changed.
+func InvalidateSeatMap(ctx context.Context, sessionID string) {
+	if config.RedisClient == nil {
+		return
+	}
+	if err := config.RedisClient.Del(ctx, SeatMapKeyPrefix+sessionID).Err(); err != nil {
+		log.Printf("⚠️ Failed to invalidate seat map of session %s: %v", sessionID, err)
+	}
+}
+
+// ValidateSeatLock checks a lock request against a session's seat map. Seat
+// locks held in Redis are checked separately by the lock itself.
+func ValidateSeatLock(session *models.MovieSession, seatIDs []string, now time.Time, cutoff time.Duration) error {
+	if err := CheckSessionOnSale(*session, now, cutoff); err != nil {
+		status := err.(*SessionNotOnSaleError).Status
+		return &LockRejectedError{Code: sessionRejectCode(status), SessionStatus: status}
+	}
+	if len(seatIDs) == 0 {
+		return &LockRejectedError{Code: LockRejectNoSeats}
+	}
+
+	statuses := make(map[string]models.SeatStatus, len(session.Seats))
+	for _, seat := range session.Seats {
+		statuses[seat.ID] = seat.Status
+	}
+
+	rejected := &LockRejectedError{}
+	seen := make(map[string]bool, len(seatIDs))
+	for _, seatID := range seatIDs {
+		code := ""
+		status, ok := statuses[seatID]
+		switch {
+		case seen[seatID]:
+			code = LockRejectDuplicateSeat
+		case !ok:
+			code = LockRejectSeatNotFound
+		case status == models.SeatBooked:
+			code = LockRejectSeatBooked
+		case status == models.SeatBlocked:
+			code = LockRejectSeatBlocked
+		}
+		seen[seatID] = true
+		if code == "" {
+			continue
+		}
+		if rejected.Code == "" {
+			rejected.Code = code
+		}
+		rejected.Seats = append(rejected.Seats, SeatRejection{SeatID: seatID, Code: code})
+	}
+	if rejected.Code != "" {
+		return rejected
+	}
+	return nil
+}
+
+func sessionRejectCode(status models.SessionStatus) string {
+	switch status {
+	case models.SessionScheduled:
+		return LockRejectSalesNotOpen
+	case models.SessionSalesClosed:
+		return LockRejectSalesClosed
+	case models.SessionCancelled:
+		return LockRejectSessionCancelled
+	}
+	return LockRejectSessionStarted
+}
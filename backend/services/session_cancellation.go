@@ -0,0 +1,454 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"cinema-booking-system/config"
+	"cinema-booking-system/models"
+	"cinema-booking-system/websocket"
+
+	"github.com/redis/go-redis/v9"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	sessionCancellationLeaseName    = "session-cancellations"
+	sessionCancellationPollInterval = 10 * time.Second
+)
+
+var (
+	ErrSessionFinished             = errors.New("session has already finished")
+	ErrSessionCancellationNotFound = errors.New("session cancellation not found")
+)
+
+// SessionCancellationService voids whole sessions, for example after a
+// projector failure. Cancel marks the session CANCELLED straight away and
+// records a job; a background worker then refunds, emails and cancels every
+// confirmed booking. Every step is idempotent and keyed by booking, so a job
+// interrupted by a restart is simply picked up again.
+type SessionCancellationService struct {
+	sessions *mongo.Collection
+	bookings *mongo.Collection
+	jobs     *mongo.Collection
+	redis    *redis.Client
+
+	payments     PaymentGateway
+	outbox       *EmailOutboxService
+	eventService *EventProducerService
+	wsHub        *websocket.Hub
+
+	wake chan struct{}
+}
+
+func NewSessionCancellationService(payments PaymentGateway, outbox *EmailOutboxService, eventService *EventProducerService, wsHub *websocket.Hub) *SessionCancellationService {
+	return &SessionCancellationService{
+		sessions:     config.MongoDB.Collection("sessions"),
+		bookings:     config.MongoDB.Collection("bookings"),
+		jobs:         config.MongoDB.Collection("session_cancellations"),
+		redis:        config.RedisClient,
+		payments:     payments,
+		outbox:       outbox,
+		eventService: eventService,
+		wsHub:        wsHub,
+		wake:         make(chan struct{}, 1),
+	}
+}
+
+func (s *SessionCancellationService) Start(ctx context.Context) {
+	_, err := s.jobs.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "sessionId", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "status", Value: 1}}},
+	})
+	if err != nil {
+		log.Printf("⚠️ Failed to create session cancellation indexes: %v", err)
+	}
+
+	log.Printf("🚫 Session cancellation worker started (payments: %s)", s.payments.Kind())
+
+	ticker := time.NewTicker(sessionCancellationPollInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.RunPending(ctx); err != nil {
+			log.Printf("⚠️ Session cancellation run failed: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Println("🚫 Session cancellation worker stopped")
+			return
+		case <-ticker.C:
+		case <-s.wake:
+		}
+	}
+}
+
+// Cancel voids a session on behalf of requestedBy and queues the refunds.
+// Cancelling a session that already has a job returns that job, and retries
+// it if some of its bookings failed.
+func (s *SessionCancellationService) Cancel(ctx context.Context, sessionID, reason, requestedBy string) (*models.SessionCancellation, error) {
+	oid, err := primitive.ObjectIDFromHex(sessionID)
+	if err != nil {
+		return nil, ErrSessionNotFound
+	}
+
+	var session models.MovieSession
+	if err := s.sessions.FindOne(ctx, bson.M{"_id": oid}, sessionWithoutSeats()).Decode(&session); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrSessionNotFound
+		}
+		return nil, err
+	}
+
+	var existing models.SessionCancellation
+	err = s.jobs.FindOne(ctx, bson.M{"sessionId": oid}).Decode(&existing)
+	if err == nil {
+		return s.retry(ctx, &existing)
+	}
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, err
+	}
+
+	if SessionStatusAt(session, time.Now().UTC(), config.AppConfig.SalesCutoff) == models.SessionFinished {
+		return nil, ErrSessionFinished
+	}
+
+	now := time.Now().UTC()
+	job := models.SessionCancellation{
+		ID:          primitive.NewObjectID(),
+		SessionID:   oid,
+		MovieTitle:  session.MovieTitle,
+		Reason:      reason,
+		RequestedBy: requestedBy,
+		Status:      models.SessionCancellationPending,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if _, err := s.jobs.InsertOne(ctx, job); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			if err := s.jobs.FindOne(ctx, bson.M{"sessionId": oid}).Decode(&existing); err != nil {
+				return nil, err
+			}
+			return &existing, nil
+		}
+		return nil, fmt.Errorf("failed to create session cancellation: %w", err)
+	}
+
+	if err := s.markSessionCancelled(ctx, &job); err != nil {
+		// The worker marks the session again before touching any booking.
+		log.Printf("⚠️ Failed to mark session %s cancelled: %v", sessionID, err)
+	}
+	if n, err := s.bookings.CountDocuments(ctx, bson.M{"sessionId": oid, "status": models.BookingStatusConfirmed}); err == nil {
+		job.TotalBookings = int(n)
+		s.jobs.UpdateOne(ctx, bson.M{"_id": job.ID}, bson.M{"$set": bson.M{"totalBookings": job.TotalBookings}})
+	}
+
+	s.notify()
+	return &job, nil
+}
+
+func (s *SessionCancellationService) retry(ctx context.Context, job *models.SessionCancellation) (*models.SessionCancellation, error) {
+	if job.Status != models.SessionCancellationCompletedWithErrors {
+		return job, nil
+	}
+	_, err := s.jobs.UpdateOne(ctx,
+		bson.M{"_id": job.ID, "status": models.SessionCancellationCompletedWithErrors},
+		bson.M{
+			"$set":   bson.M{"status": models.SessionCancellationPending, "updatedAt": time.Now().UTC()},
+			"$unset": bson.M{"finishedAt": ""},
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	job.Status = models.SessionCancellationPending
+	job.FinishedAt = nil
+	s.notify()
+	return job, nil
+}
+
+func (s *SessionCancellationService) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// RunPending works through every job that has bookings left to process.
+func (s *SessionCancellationService) RunPending(ctx context.Context) error {
+	ok, err := AcquireLease(ctx, sessionCancellationLeaseName, 10*time.Minute)
+	if err != nil || !ok {
+		return err
+	}
+	defer ReleaseLease(context.Background(), sessionCancellationLeaseName)
+
+	cursor, err := s.jobs.Find(ctx,
+		bson.M{"status": bson.M{"$in": []models.SessionCancellationStatus{models.SessionCancellationPending, models.SessionCancellationRunning}}},
+		options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}}),
+	)
+	if err != nil {
+		return err
+	}
+	var jobs []models.SessionCancellation
+	if err := cursor.All(ctx, &jobs); err != nil {
+		return err
+	}
+
+	for i := range jobs {
+		if err := s.process(ctx, &jobs[i]); err != nil {
+			log.Printf("⚠️ Session cancellation %s interrupted: %v", jobs[i].ID.Hex(), err)
+		}
+	}
+	return nil
+}
+
+func (s *SessionCancellationService) process(ctx context.Context, job *models.SessionCancellation) error {
+	now := time.Now().UTC()
+	set := bson.M{"status": models.SessionCancellationRunning, "updatedAt": now}
+	if job.StartedAt == nil {
+		set["startedAt"] = now
+	}
+	_, err := s.jobs.UpdateOne(ctx, bson.M{"_id": job.ID}, bson.M{
+		"$set":   set,
+		"$unset": bson.M{"failures": ""},
+		"$inc":   bson.M{"attempts": 1},
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := s.markSessionCancelled(ctx, job); err != nil {
+		return err
+	}
+
+	var session models.MovieSession
+	if err := s.sessions.FindOne(ctx, bson.M{"_id": job.SessionID}, sessionWithoutSeats()).Decode(&session); err != nil {
+		return fmt.Errorf("failed to load session: %w", err)
+	}
+
+	remaining, err := s.bookings.CountDocuments(ctx, bson.M{"sessionId": job.SessionID, "status": models.BookingStatusConfirmed})
+	if err != nil {
+		return err
+	}
+	s.jobs.UpdateOne(ctx, bson.M{"_id": job.ID}, bson.M{"$set": bson.M{"totalBookings": job.Processed + int(remaining)}})
+
+	cursor, err := s.bookings.Find(ctx, bson.M{"sessionId": job.SessionID, "status": models.BookingStatusConfirmed})
+	if err != nil {
+		return err
+	}
+	var bookings []models.Booking
+	if err := cursor.All(ctx, &bookings); err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, booking := range bookings {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		update := bson.M{"$set": bson.M{"updatedAt": time.Now().UTC()}}
+		cancelled, err := s.cancelBooking(ctx, job, session, booking)
+		switch {
+		case err != nil:
+			failed++
+			log.Printf("❌ Failed to cancel booking %s of session %s: %v", booking.ID.Hex(), job.SessionID.Hex(), err)
+			update["$push"] = bson.M{"failures": models.BookingFailure{BookingID: booking.ID.Hex(), Error: err.Error()}}
+		case cancelled != nil:
+			inc := bson.M{"processed": 1}
+			if cancelled.Refund != nil {
+				inc["refundedAmount"] = cancelled.Refund.Amount
+			}
+			update["$inc"] = inc
+		}
+		if _, err := s.jobs.UpdateOne(ctx, bson.M{"_id": job.ID}, update); err != nil {
+			return err
+		}
+	}
+
+	status := models.SessionCancellationCompleted
+	if failed > 0 {
+		status = models.SessionCancellationCompletedWithErrors
+	}
+	finished := time.Now().UTC()
+	_, err = s.jobs.UpdateOne(ctx, bson.M{"_id": job.ID}, bson.M{"$set": bson.M{
+		"status":     status,
+		"finishedAt": finished,
+		"updatedAt":  finished,
+	}})
+	if err != nil {
+		return err
+	}
+	log.Printf("🚫 Session %s cancelled: %d booking(s) processed, %d failed", job.SessionID.Hex(), len(bookings)-failed, failed)
+	return nil
+}
+
+// markSessionCancelled takes the session off sale. Only the call that
+// actually flips the status releases its seat locks, tells watching clients
+// and audits the cancellation.
+func (s *SessionCancellationService) markSessionCancelled(ctx context.Context, job *models.SessionCancellation) error {
+	var before models.MovieSession
+	now := time.Now().UTC()
+	err := s.sessions.FindOneAndUpdate(ctx,
+		bson.M{"_id": job.SessionID, "status": bson.M{"$ne": models.SessionCancelled}},
+		bson.M{
+			"$set": bson.M{"status": models.SessionCancelled, "statusChangedAt": now, "updatedAt": now},
+			"$inc": bson.M{"version": 1},
+		},
+		options.FindOneAndUpdate().SetProjection(bson.M{"status": 1}),
+	).Decode(&before)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to mark session cancelled: %w", err)
+	}
+
+	sessionID := job.SessionID.Hex()
+	InvalidateSeatMap(ctx, sessionID)
+	s.releaseLocks(ctx, sessionID)
+	s.wsHub.BroadcastSessionCancelled(sessionID, job.Reason)
+	go s.eventService.LogSessionCancelled(context.Background(), *job, before.Status)
+	return nil
+}
+
+func (s *SessionCancellationService) releaseLocks(ctx context.Context, sessionID string) {
+	if s.redis == nil {
+		return
+	}
+	iter := s.redis.Scan(ctx, 0, LockKeyPrefix+sessionID+":*", 500).Iterator()
+	var keys []string
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+	}
+	if err := iter.Err(); err != nil {
+		log.Printf("⚠️ Failed to list seat locks of session %s: %v", sessionID, err)
+		return
+	}
+	if len(keys) > 0 {
+		if err := s.redis.Del(ctx, keys...).Err(); err != nil {
+			log.Printf("⚠️ Failed to release seat locks of session %s: %v", sessionID, err)
+		}
+	}
+}
+
+// cancelBooking refunds, emails and cancels one booking, in that order. A
+// stored refund is never requested again, the email is deduplicated and the
+// cancellation only matches a CONFIRMED booking, so repeating it after a
+// crash at any point finishes the work without doing any of it twice. It
+// returns nil when the booking was already cancelled by someone else.
+func (s *SessionCancellationService) cancelBooking(ctx context.Context, job *models.SessionCancellation, session models.MovieSession, booking models.Booking) (*models.Booking, error) {
+	bookingID := booking.ID.Hex()
+	reason := "Session cancelled: " + job.Reason
+
+	if booking.Refund == nil {
+		result, err := s.payments.Refund(ctx, RefundRequest{
+			BookingID:      bookingID,
+			PaymentID:      booking.PaymentID,
+			Amount:         booking.TotalAmount,
+			Reason:         reason,
+			IdempotencyKey: "session-cancellation:" + bookingID,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("refund failed: %w", err)
+		}
+		refund := &models.Refund{
+			Amount:    booking.TotalAmount,
+			Reference: result.Reference,
+			Provider:  s.payments.Kind(),
+			IssuedAt:  time.Now().UTC(),
+		}
+		_, err = s.bookings.UpdateOne(ctx,
+			bson.M{"_id": booking.ID, "refund": bson.M{"$exists": false}},
+			bson.M{"$set": bson.M{"refund": refund}},
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to record refund %s: %w", result.Reference, err)
+		}
+		booking.Refund = refund
+	}
+
+	now := time.Now().UTC()
+	cancelled := booking
+	cancelled.Status = models.BookingStatusCancelled
+	cancelled.CancelledAt = &now
+	cancelled.CancelReason = reason
+
+	invite := models.EmailAttachment{
+		Filename:    "booking-" + bookingID + ".ics",
+		ContentType: CalendarContentType,
+		Data: BuildBookingCalendar(session.MovieTitle, []BookingCalendarEntry{
+			{Booking: cancelled, Session: session},
+		}),
+	}
+	_, err := s.outbox.EnqueueBookingEmailOnce(ctx, "session-cancellation:"+bookingID, models.EmailKindRefund, booking.UserEmail, BookingEmailData{
+		Locale:          booking.Locale,
+		UserName:        booking.UserEmail,
+		BookingID:       bookingID,
+		MovieTitle:      session.MovieTitle,
+		Theater:         session.Theater,
+		Seats:           booking.Seats,
+		TotalAmount:     booking.TotalAmount,
+		ShowTime:        session.StartTime,
+		Reason:          reason,
+		RefundAmount:    booking.Refund.Amount,
+		RefundReference: booking.Refund.Reference,
+	}, invite)
+	if err != nil {
+		return nil, fmt.Errorf("failed to queue refund email: %w", err)
+	}
+
+	result, err := s.bookings.UpdateOne(ctx,
+		bson.M{"_id": booking.ID, "status": models.BookingStatusConfirmed},
+		bson.M{"$set": bson.M{
+			"status":       models.BookingStatusCancelled,
+			"cancelledAt":  now,
+			"cancelReason": reason,
+		}},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to cancel booking: %w", err)
+	}
+	if result.ModifiedCount == 0 {
+		return nil, nil
+	}
+
+	go s.eventService.LogBookingRefunded(context.Background(), cancelled, reason)
+	return &cancelled, nil
+}
+
+func (s *SessionCancellationService) Get(ctx context.Context, id string) (*models.SessionCancellation, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrSessionCancellationNotFound
+	}
+	var job models.SessionCancellation
+	if err := s.jobs.FindOne(ctx, bson.M{"_id": oid}).Decode(&job); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrSessionCancellationNotFound
+		}
+		return nil, err
+	}
+	return &job, nil
+}
+
+func (s *SessionCancellationService) Recent(ctx context.Context, limit int64) ([]models.SessionCancellation, error) {
+	cursor, err := s.jobs.Find(ctx, bson.M{},
+		options.Find().SetSort(bson.D{{Key: "createdAt", Value: -1}}).SetLimit(limit),
+	)
+	if err != nil {
+		return nil, err
+	}
+	jobs := []models.SessionCancellation{}
+	if err := cursor.All(ctx, &jobs); err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
@@ -0,0 +1,197 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"cinema-booking-system/config"
+	"cinema-booking-system/models"
+	"cinema-booking-system/websocket"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const sessionLifecycleLeaseName = "session-lifecycle"
+
+// SessionNotOnSaleError rejects a lock or booking for a session that is not
+// ON_SALE.
+type SessionNotOnSaleError struct {
+	SessionID string
+	Status    models.SessionStatus
+}
+
+func (e *SessionNotOnSaleError) Error() string {
+	switch e.Status {
+	case models.SessionScheduled:
+		return "Tickets for this session are not on sale yet"
+	case models.SessionSalesClosed:
+		return "Ticket sales for this session have closed"
+	case models.SessionInProgress:
+		return "This session has already started"
+	case models.SessionFinished:
+		return "This session has finished"
+	case models.SessionCancelled:
+		return "This session has been cancelled"
+	}
+	return fmt.Sprintf("session %s is %s", e.SessionID, e.Status)
+}
+
+// Code is the rejection code clients get, shared with seat locks.
+func (e *SessionNotOnSaleError) Code() string {
+	return sessionRejectCode(e.Status)
+}
+
+// SalesCloseAt is when locking and booking stop for a session.
+func SalesCloseAt(s models.MovieSession, cutoff time.Duration) time.Time {
+	return s.StartTime.Add(-cutoff)
+}
+
+// SessionStatusAt works out a session's status at now. A cancelled session
+// stays cancelled; every other status follows from its times, so it is right
+// even before the lifecycle job has caught up.
+func SessionStatusAt(s models.MovieSession, now time.Time, cutoff time.Duration) models.SessionStatus {
+	switch {
+	case s.Status == models.SessionCancelled:
+		return models.SessionCancelled
+	case !now.Before(sessionEnd(s)):
+		return models.SessionFinished
+	case !now.Before(s.StartTime):
+		return models.SessionInProgress
+	case !now.Before(SalesCloseAt(s, cutoff)):
+		return models.SessionSalesClosed
+	case s.SalesOpenAt != nil && now.Before(*s.SalesOpenAt):
+		return models.SessionScheduled
+	}
+	return models.SessionOnSale
+}
+
+// ApplySessionStatus fills in a session's current status and sales close
+// time for API responses.
+func ApplySessionStatus(s *models.MovieSession, now time.Time, cutoff time.Duration) {
+	s.Status = SessionStatusAt(*s, now, cutoff)
+	closeAt := SalesCloseAt(*s, cutoff)
+	s.SalesCloseAt = &closeAt
+}
+
+// CheckSessionOnSale returns a SessionNotOnSaleError unless seats of s can be
+// locked and booked at now.
+func CheckSessionOnSale(s models.MovieSession, now time.Time, cutoff time.Duration) error {
+	if status := SessionStatusAt(s, now, cutoff); status != models.SessionOnSale {
+		return &SessionNotOnSaleError{SessionID: s.ID.Hex(), Status: status}
+	}
+	return nil
+}
+
+// SessionLifecycle moves stored sessions through their statuses as time
+// passes, and tells clients watching a session over WebSocket.
+type SessionLifecycle struct {
+	sessions     *mongo.Collection
+	eventService *EventProducerService
+	wsHub        *websocket.Hub
+	interval     time.Duration
+	cutoff       time.Duration
+}
+
+func NewSessionLifecycle(eventService *EventProducerService, wsHub *websocket.Hub, interval, cutoff time.Duration) *SessionLifecycle {
+	return &SessionLifecycle{
+		sessions:     config.MongoDB.Collection("sessions"),
+		eventService: eventService,
+		wsHub:        wsHub,
+		interval:     interval,
+		cutoff:       cutoff,
+	}
+}
+
+func (l *SessionLifecycle) Start(ctx context.Context) {
+	if l.interval <= 0 {
+		log.Println("⚠️ Session lifecycle job disabled (SESSION_LIFECYCLE_INTERVAL is 0)")
+		return
+	}
+
+	l.sessions.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "status", Value: 1}, {Key: "startTime", Value: 1}},
+	})
+
+	log.Printf("🎞️ Session lifecycle job started (every %s, sales close %s before start)", l.interval, l.cutoff)
+
+	ticker := time.NewTicker(l.interval)
+	defer ticker.Stop()
+
+	for {
+		if n, err := l.RunOnce(ctx); err != nil {
+			log.Printf("⚠️ Session lifecycle run failed: %v", err)
+		} else if n > 0 {
+			log.Printf("🎞️ Moved %d session(s) to a new status", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Println("🎞️ Session lifecycle job stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce stores the current status of every session that is not finished or
+// cancelled yet and returns how many changed.
+func (l *SessionLifecycle) RunOnce(ctx context.Context) (int, error) {
+	ok, err := AcquireLease(ctx, sessionLifecycleLeaseName, 2*l.interval)
+	if err != nil || !ok {
+		return 0, err
+	}
+
+	cursor, err := l.sessions.Find(ctx,
+		bson.M{"status": bson.M{"$nin": []models.SessionStatus{models.SessionFinished, models.SessionCancelled}}},
+		options.Find().SetProjection(bson.M{"seats": 0}),
+	)
+	if err != nil {
+		return 0, err
+	}
+	var sessions []models.MovieSession
+	if err := cursor.All(ctx, &sessions); err != nil {
+		return 0, err
+	}
+
+	now := time.Now().UTC()
+	changed := 0
+	for _, s := range sessions {
+		next := SessionStatusAt(s, now, l.cutoff)
+		if next == s.Status {
+			continue
+		}
+
+		// Matching on the old status keeps a concurrent cancellation from
+		// being overwritten.
+		filter := bson.M{"_id": s.ID, "status": s.Status}
+		if s.Status == "" {
+			filter["status"] = bson.M{"$exists": false}
+		}
+		result, err := l.sessions.UpdateOne(ctx, filter, bson.M{"$set": bson.M{
+			"status":          next,
+			"statusChangedAt": now,
+		}})
+		if err != nil {
+			return changed, err
+		}
+		if result.ModifiedCount == 0 {
+			continue
+		}
+		changed++
+
+		sessionID := s.ID.Hex()
+		InvalidateSeatMap(ctx, sessionID)
+		closeAt := SalesCloseAt(s, l.cutoff)
+		l.wsHub.BroadcastSessionStatus(sessionID, models.SessionStatusUpdate{
+			Status:         next,
+			PreviousStatus: s.Status,
+			SalesCloseAt:   &closeAt,
+		})
+		go l.eventService.LogSessionStatusChanged(context.Background(), sessionID, s.Status, next)
+	}
+	return changed, nil
+}
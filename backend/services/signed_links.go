@@ -0,0 +1,50 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+
+	"cinema-booking-system/config"
+)
+
+const (
+	TokenPurposeBookingCalendar = "booking-calendar"
+	TokenPurposeUserCalendar    = "user-calendar"
+	TokenPurposeBookingTickets  = "booking-tickets"
+	TokenPurposeTicket          = "ticket"
+)
+
+// SignToken returns an HMAC of subject scoped to purpose, so a token issued
+// for one kind of link cannot be reused for another.
+func SignToken(purpose, subject string) string {
+	m := hmac.New(sha256.New, []byte(config.AppConfig.TokenSecret))
+	m.Write([]byte(purpose + "\x00" + subject))
+	return hex.EncodeToString(m.Sum(nil)[:16])
+}
+
+func VerifyToken(purpose, subject, token string) bool {
+	return hmac.Equal([]byte(SignToken(purpose, subject)), []byte(token))
+}
+
+func BookingCalendarURL(bookingID string) string {
+	return config.AppConfig.PublicBaseURL + "/api/bookings/" + bookingID + "/calendar.ics?token=" +
+		SignToken(TokenPurposeBookingCalendar, bookingID)
+}
+
+func UserCalendarFeedURL(userID string) string {
+	return config.AppConfig.PublicBaseURL + "/api/users/" + userID + "/calendar.ics?token=" +
+		SignToken(TokenPurposeUserCalendar, userID)
+}
+
+func BookingTicketsURL(bookingID string) string {
+	return config.AppConfig.PublicBaseURL + "/api/bookings/" + bookingID + "/tickets?token=" +
+		SignToken(TokenPurposeBookingTickets, bookingID)
+}
+
+// BookingTicketPDFURL shares the tickets token, since both links hand out the
+// same ticket codes.
+func BookingTicketPDFURL(bookingID string) string {
+	return config.AppConfig.PublicBaseURL + "/api/bookings/" + bookingID + "/ticket.pdf?token=" +
+		SignToken(TokenPurposeBookingTickets, bookingID)
+}
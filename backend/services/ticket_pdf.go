@@ -0,0 +1,161 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"cinema-booking-system/models"
+
+	"github.com/go-pdf/fpdf"
+)
+
+const (
+	TicketPDFContentType = "application/pdf"
+
+	ticketPDFQRSize    = 55.0 // mm
+	ticketPDFPerRow    = 3
+	ticketPDFMargin    = 15.0
+	ticketPDFPageWidth = 210.0
+)
+
+// RenderTicketPDF renders a printable A4 ticket for a booking: show details,
+// a price breakdown and one QR code per seat, each scannable at check-in
+// like the e-ticket. The PDF uses the built-in Helvetica font, so it is
+// always in English and text outside Latin-1 is dropped.
+func RenderTicketPDF(booking models.Booking, session models.MovieSession) ([]byte, error) {
+	en := ResolveLocale(DefaultLocale)
+	contentWidth := ticketPDFPageWidth - 2*ticketPDFMargin
+
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(ticketPDFMargin, ticketPDFMargin, ticketPDFMargin)
+	pdf.SetAutoPageBreak(true, ticketPDFMargin)
+	pdf.SetTitle("Ticket "+booking.ID.Hex(), true)
+	pdf.SetCreator("Cinema Booking System", true)
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+	pdf.AddPage()
+
+	pdf.SetFillColor(220, 38, 38)
+	pdf.SetTextColor(255, 255, 255)
+	pdf.SetFont("Helvetica", "B", 16)
+	pdf.CellFormat(contentWidth, 12, "  Cinema Booking System - Ticket", "", 1, "L", true, 0, "")
+	pdf.Ln(6)
+
+	pdf.SetTextColor(17, 24, 39)
+	pdf.SetFont("Helvetica", "B", 20)
+	pdf.MultiCell(contentWidth, 9, tr(session.MovieTitle), "", "L", false)
+	pdf.Ln(3)
+
+	details := [][2]string{
+		{"Theater", session.Theater},
+		{"Date", en.FormatDate(session.StartTime)},
+		{"Showtime", en.FormatTime(session.StartTime)},
+		{"Seats", strings.Join(booking.Seats, ", ")},
+		{"Booking ID", booking.ID.Hex()},
+	}
+	if booking.Status == models.BookingStatusCancelled {
+		details = append(details, [2]string{"Status", "CANCELLED - not valid for entry"})
+	}
+	for _, d := range details {
+		pdf.SetFont("Helvetica", "", 11)
+		pdf.SetTextColor(107, 114, 128)
+		pdf.CellFormat(35, 7, d[0], "", 0, "L", false, 0, "")
+		pdf.SetFont("Helvetica", "B", 11)
+		pdf.SetTextColor(17, 24, 39)
+		pdf.CellFormat(contentWidth-35, 7, tr(d[1]), "", 1, "L", false, 0, "")
+	}
+	pdf.Ln(5)
+
+	pdf.SetFont("Helvetica", "B", 12)
+	pdf.CellFormat(contentWidth, 8, "Price breakdown", "B", 1, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "", 11)
+	for _, line := range ticketPriceLines(booking, session) {
+		pdf.CellFormat(contentWidth-40, 7, "Seat "+line.SeatID, "", 0, "L", false, 0, "")
+		pdf.CellFormat(40, 7, pdfMoney(en, line.Price), "", 1, "R", false, 0, "")
+	}
+	pdf.SetFont("Helvetica", "B", 11)
+	pdf.CellFormat(contentWidth-40, 8, "Total", "T", 0, "L", false, 0, "")
+	pdf.CellFormat(40, 8, pdfMoney(en, booking.TotalAmount), "T", 1, "R", false, 0, "")
+	pdf.Ln(8)
+
+	pdf.SetFont("Helvetica", "", 10)
+	pdf.SetTextColor(107, 114, 128)
+	pdf.MultiCell(contentWidth, 5, "Show one code per seat at the entrance. Each code admits one person once.", "", "L", false)
+	pdf.Ln(3)
+
+	gap := (contentWidth - ticketPDFPerRow*ticketPDFQRSize) / (ticketPDFPerRow - 1)
+	for i, t := range BookingTickets(booking) {
+		png, err := TicketQRCode(t.Token, TicketQRSize)
+		if err != nil {
+			return nil, fmt.Errorf("failed to render ticket QR code: %w", err)
+		}
+		name := "ticket-" + t.SeatID
+		pdf.RegisterImageOptionsReader(name, fpdf.ImageOptions{ImageType: "PNG"}, bytes.NewReader(png))
+
+		col := i % ticketPDFPerRow
+		if col == 0 && i > 0 {
+			pdf.Ln(ticketPDFQRSize + 10)
+		}
+		y := pdf.GetY()
+		if y+ticketPDFQRSize+8 > 297-ticketPDFMargin {
+			pdf.AddPage()
+			y = pdf.GetY()
+		}
+		x := ticketPDFMargin + float64(col)*(ticketPDFQRSize+gap)
+		pdf.ImageOptions(name, x, y, ticketPDFQRSize, ticketPDFQRSize, false, fpdf.ImageOptions{ImageType: "PNG"}, 0, "")
+
+		pdf.SetXY(x, y+ticketPDFQRSize)
+		pdf.SetFont("Helvetica", "B", 12)
+		pdf.SetTextColor(17, 24, 39)
+		pdf.CellFormat(ticketPDFQRSize, 6, "Seat "+t.SeatID, "", 0, "C", false, 0, "")
+		pdf.SetXY(x, y)
+	}
+
+	if err := pdf.Error(); err != nil {
+		return nil, fmt.Errorf("failed to render ticket PDF: %w", err)
+	}
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, fmt.Errorf("failed to render ticket PDF: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+type ticketPriceLine struct {
+	SeatID string
+	Price  float64
+}
+
+// ticketPriceLines prices each seat from the session's seat map. Seats the
+// map does not know share whatever part of the total is left.
+func ticketPriceLines(booking models.Booking, session models.MovieSession) []ticketPriceLine {
+	prices := make(map[string]float64, len(session.Seats))
+	for _, s := range session.Seats {
+		prices[s.ID] = s.Price
+	}
+
+	lines := make([]ticketPriceLine, len(booking.Seats))
+	remaining, unknown := booking.TotalAmount, 0
+	for i, seatID := range booking.Seats {
+		price, ok := prices[seatID]
+		if !ok || price <= 0 {
+			unknown++
+			lines[i] = ticketPriceLine{SeatID: seatID, Price: -1}
+			continue
+		}
+		remaining -= price
+		lines[i] = ticketPriceLine{SeatID: seatID, Price: price}
+	}
+	for i := range lines {
+		if lines[i].Price < 0 {
+			lines[i].Price = remaining / float64(unknown)
+		}
+	}
+	return lines
+}
+
+// pdfMoney spells out the currency because the baht sign is not in the
+// built-in PDF fonts.
+func pdfMoney(l *Locale, amount float64) string {
+	return strings.Replace(l.FormatMoney(amount), l.CurrencyPrefix, "", 1) + " THB"
+}
@@ -0,0 +1,286 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"cinema-booking-system/config"
+	"cinema-booking-system/events"
+	"cinema-booking-system/models"
+
+	"github.com/skip2/go-qrcode"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	TicketQRSize             = 256
+	checkInGraceAfter        = 30 * time.Minute
+	ticketTokenVersionPrefix = "T1."
+)
+
+const (
+	CheckInInvalidTicket    = "INVALID_TICKET"
+	CheckInInvalidSignature = "INVALID_SIGNATURE"
+	CheckInWrongSession     = "WRONG_SESSION"
+	CheckInBookingNotFound  = "BOOKING_NOT_FOUND"
+	CheckInBookingCancelled = "BOOKING_CANCELLED"
+	CheckInSeatNotInBooking = "SEAT_NOT_IN_BOOKING"
+	CheckInSessionEnded     = "SESSION_ENDED"
+	CheckInSessionNotFound  = "SESSION_NOT_FOUND"
+	CheckInAlreadyAdmitted  = "ALREADY_ADMITTED"
+)
+
+// TicketClaims is what a ticket token carries. One token admits one seat.
+type TicketClaims struct {
+	BookingID string `json:"b"`
+	SessionID string `json:"s"`
+	SeatID    string `json:"t"`
+	IssuedAt  int64  `json:"iat"`
+}
+
+type Ticket struct {
+	SeatID     string     `json:"seatId"`
+	Token      string     `json:"token"`
+	AdmittedAt *time.Time `json:"admittedAt,omitempty"`
+}
+
+// CheckInError explains why a ticket was not admitted. Reason is one of the
+// CheckIn* codes.
+type CheckInError struct {
+	Reason     string     `json:"reason"`
+	Message    string     `json:"message"`
+	SeatID     string     `json:"seatId,omitempty"`
+	AdmittedAt *time.Time `json:"admittedAt,omitempty"`
+	AdmittedBy string     `json:"admittedBy,omitempty"`
+}
+
+func (e *CheckInError) Error() string {
+	return e.Reason + ": " + e.Message
+}
+
+type CheckInResult struct {
+	BookingID  string    `json:"bookingId"`
+	SessionID  string    `json:"sessionId"`
+	SeatID     string    `json:"seatId"`
+	MovieTitle string    `json:"movieTitle"`
+	Theater    string    `json:"theater"`
+	StartTime  time.Time `json:"startTime"`
+	AdmittedAt time.Time `json:"admittedAt"`
+}
+
+// IssueTicketToken encodes claims as "T1.<payload>.<signature>", with the
+// payload base64url-encoded JSON and the signature an HMAC over it.
+func IssueTicketToken(claims TicketClaims) string {
+	data, _ := json.Marshal(claims)
+	payload := base64.RawURLEncoding.EncodeToString(data)
+	return ticketTokenVersionPrefix + payload + "." + SignToken(TokenPurposeTicket, payload)
+}
+
+func ParseTicketToken(token string) (TicketClaims, error) {
+	var claims TicketClaims
+
+	rest, ok := strings.CutPrefix(strings.TrimSpace(token), ticketTokenVersionPrefix)
+	if !ok {
+		return claims, &CheckInError{Reason: CheckInInvalidTicket, Message: "Not a ticket issued by this system"}
+	}
+	payload, signature, ok := strings.Cut(rest, ".")
+	if !ok {
+		return claims, &CheckInError{Reason: CheckInInvalidTicket, Message: "Ticket code is incomplete"}
+	}
+	if !VerifyToken(TokenPurposeTicket, payload, signature) {
+		return claims, &CheckInError{Reason: CheckInInvalidSignature, Message: "Ticket signature does not match; the ticket may be forged or altered"}
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(payload)
+	if err == nil {
+		err = json.Unmarshal(data, &claims)
+	}
+	if err != nil || claims.BookingID == "" || claims.SeatID == "" {
+		return claims, &CheckInError{Reason: CheckInInvalidTicket, Message: "Ticket payload is unreadable"}
+	}
+	return claims, nil
+}
+
+func TicketQRCode(token string, size int) ([]byte, error) {
+	return qrcode.Encode(token, qrcode.Medium, size)
+}
+
+// BookingTickets returns one ticket per seat. Tokens are derived from the
+// booking, so the same ticket is returned every time it is downloaded.
+func BookingTickets(booking models.Booking) []Ticket {
+	admitted := make(map[string]time.Time)
+	for _, a := range booking.Admissions {
+		admitted[a.SeatID] = a.AdmittedAt
+	}
+
+	tickets := make([]Ticket, 0, len(booking.Seats))
+	for _, seatID := range booking.Seats {
+		t := Ticket{
+			SeatID: seatID,
+			Token: IssueTicketToken(TicketClaims{
+				BookingID: booking.ID.Hex(),
+				SessionID: booking.SessionID.Hex(),
+				SeatID:    seatID,
+				IssuedAt:  booking.CreatedAt.Unix(),
+			}),
+		}
+		if at, ok := admitted[seatID]; ok {
+			at := at
+			t.AdmittedAt = &at
+		}
+		tickets = append(tickets, t)
+	}
+	return tickets
+}
+
+// TicketAttachments renders the QR code of every ticket as an inline image
+// that email templates reference as cid:<ContentID>.
+func TicketAttachments(tickets []Ticket) ([]models.EmailAttachment, []TicketImage, error) {
+	var attachments []models.EmailAttachment
+	var images []TicketImage
+	for _, t := range tickets {
+		png, err := TicketQRCode(t.Token, TicketQRSize)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to render ticket QR code: %w", err)
+		}
+		cid := "ticket-" + t.SeatID + "@cinema-booking-system"
+		attachments = append(attachments, models.EmailAttachment{
+			Filename:    "ticket-" + t.SeatID + ".png",
+			ContentType: "image/png",
+			ContentID:   cid,
+			Data:        png,
+		})
+		images = append(images, TicketImage{SeatID: t.SeatID, ContentID: cid})
+	}
+	return attachments, images, nil
+}
+
+type TicketImage struct {
+	SeatID    string
+	ContentID string
+}
+
+type TicketService struct {
+	bookings     *mongo.Collection
+	sessions     *mongo.Collection
+	eventService *EventProducerService
+}
+
+func NewTicketService(eventService *EventProducerService) *TicketService {
+	return &TicketService{
+		bookings:     config.MongoDB.Collection("bookings"),
+		sessions:     config.MongoDB.Collection("sessions"),
+		eventService: eventService,
+	}
+}
+
+// CheckIn admits the seat a ticket token stands for. sessionID, when set, is
+// the show being scanned for, so a ticket for another show is refused. Each
+// seat can be admitted once; the conditional update makes concurrent scans
+// of the same ticket admit it only once.
+func (s *TicketService) CheckIn(ctx context.Context, token, sessionID, staff string) (*CheckInResult, error) {
+	claims, err := ParseTicketToken(token)
+	if err != nil {
+		return nil, err
+	}
+	if sessionID != "" && claims.SessionID != sessionID {
+		return nil, &CheckInError{Reason: CheckInWrongSession, SeatID: claims.SeatID, Message: "Ticket is for a different show"}
+	}
+
+	bookingID, err := primitive.ObjectIDFromHex(claims.BookingID)
+	if err != nil {
+		return nil, &CheckInError{Reason: CheckInInvalidTicket, Message: "Ticket refers to an invalid booking"}
+	}
+
+	var booking models.Booking
+	if err := s.bookings.FindOne(ctx, bson.M{"_id": bookingID}).Decode(&booking); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, &CheckInError{Reason: CheckInBookingNotFound, SeatID: claims.SeatID, Message: "Booking does not exist"}
+		}
+		return nil, err
+	}
+	if booking.SessionID.Hex() != claims.SessionID {
+		return nil, &CheckInError{Reason: CheckInInvalidTicket, SeatID: claims.SeatID, Message: "Ticket does not match its booking"}
+	}
+	if booking.Status == models.BookingStatusCancelled {
+		return nil, &CheckInError{Reason: CheckInBookingCancelled, SeatID: claims.SeatID, Message: "Booking was cancelled"}
+	}
+	if !containsString(booking.Seats, claims.SeatID) {
+		return nil, &CheckInError{Reason: CheckInSeatNotInBooking, SeatID: claims.SeatID, Message: "Seat is no longer part of this booking"}
+	}
+	if err := admissionConflict(booking, claims.SeatID); err != nil {
+		return nil, err
+	}
+
+	var session models.MovieSession
+	if err := s.sessions.FindOne(ctx, bson.M{"_id": booking.SessionID}).Decode(&session); err != nil {
+		return nil, &CheckInError{Reason: CheckInSessionNotFound, SeatID: claims.SeatID, Message: "Show no longer exists"}
+	}
+	if time.Now().After(sessionEnd(session).Add(checkInGraceAfter)) {
+		return nil, &CheckInError{Reason: CheckInSessionEnded, SeatID: claims.SeatID, Message: "Show has already ended"}
+	}
+
+	now := time.Now().UTC()
+	result, err := s.bookings.UpdateOne(ctx,
+		bson.M{
+			"_id":               bookingID,
+			"status":            models.BookingStatusConfirmed,
+			"seats":             claims.SeatID,
+			"admissions.seatId": bson.M{"$ne": claims.SeatID},
+		},
+		bson.M{"$push": bson.M{"admissions": models.SeatAdmission{
+			SeatID:     claims.SeatID,
+			AdmittedAt: now,
+			AdmittedBy: staff,
+		}}},
+	)
+	if err != nil {
+		return nil, err
+	}
+	if result.ModifiedCount == 0 {
+		// Lost a race with another scan or a cancellation; report which.
+		if err := s.bookings.FindOne(ctx, bson.M{"_id": bookingID}).Decode(&booking); err != nil {
+			return nil, err
+		}
+		if err := admissionConflict(booking, claims.SeatID); err != nil {
+			return nil, err
+		}
+		return nil, &CheckInError{Reason: CheckInBookingCancelled, SeatID: claims.SeatID, Message: "Booking changed while checking in"}
+	}
+
+	eventCtx := events.WithCorrelationID(context.Background(), events.CorrelationID(ctx))
+	go s.eventService.LogTicketAdmitted(eventCtx, booking.ID.Hex(), claims.SessionID, booking.UserID, claims.SeatID, staff)
+
+	return &CheckInResult{
+		BookingID:  booking.ID.Hex(),
+		SessionID:  claims.SessionID,
+		SeatID:     claims.SeatID,
+		MovieTitle: session.MovieTitle,
+		Theater:    session.Theater,
+		StartTime:  session.StartTime,
+		AdmittedAt: now,
+	}, nil
+}
+
+func admissionConflict(booking models.Booking, seatID string) error {
+	for _, a := range booking.Admissions {
+		if a.SeatID == seatID {
+			at := a.AdmittedAt
+			return &CheckInError{
+				Reason:     CheckInAlreadyAdmitted,
+				SeatID:     seatID,
+				Message:    "Ticket was already used at " + at.Format(time.RFC3339),
+				AdmittedAt: &at,
+				AdmittedBy: a.AdmittedBy,
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,735 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"cinema-booking-system/config"
+	"cinema-booking-system/models"
+	"cinema-booking-system/websocket"
+
+	"github.com/redis/go-redis/v9"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	waitlistLeaseName     = "waitlist"
+	waitlistPollInterval  = time.Minute
+	waitlistQueueInterval = 2 * time.Second
+
+	// waitlistHolder owns the locks of released seats until the worker has
+	// offered them, so no one else can take them in the meantime.
+	waitlistHolder = "waitlist:hold"
+	// waitlistPendingHold is how long released seats are held for the
+	// worker, and how long the worker counts as alive after a run.
+	waitlistPendingHold = 30 * time.Second
+
+	waitlistQueueKey    = "waitlist:queue"
+	waitlistReleasedKey = "waitlist:released:"
+	waitlistSessionsKey = "waitlist:sessions"
+	waitlistWorkerKey   = "waitlist:worker"
+)
+
+var (
+	ErrWaitlistEntryNotFound = errors.New("waitlist entry not found")
+	ErrInvalidPartySize      = fmt.Errorf("party size must be between 1 and %d", MaxPartySize)
+)
+
+// SeatsAvailableError refuses a waitlist entry for a session that still has
+// seats anyone can book.
+type SeatsAvailableError struct {
+	Available int
+}
+
+func (e *SeatsAvailableError) Error() string {
+	return fmt.Sprintf("%d seat(s) are still available for this session", e.Available)
+}
+
+var activeWaitlistStatuses = []models.WaitlistStatus{models.WaitlistWaiting, models.WaitlistOffered}
+
+// WaitlistService queues customers for sold-out sessions. Seats freed in a
+// session with customers waiting stay locked, handed to the waitlist, and
+// are queued in Redis. A single leased worker drains the queue: the earliest
+// entry the seats suit is offered them, their locks move to that customer
+// for the hold period, and the customer is notified by email and WebSocket.
+// A customer who does not book in time loses the offer and the seats move on
+// down the list.
+type WaitlistService struct {
+	entries  *mongo.Collection
+	sessions *mongo.Collection
+	locks    *RedisLockService
+	redis    *redis.Client
+
+	outbox       *EmailOutboxService
+	eventService *EventProducerService
+	wsHub        *websocket.Hub
+	hold         time.Duration
+}
+
+func NewWaitlistService(outbox *EmailOutboxService, eventService *EventProducerService, wsHub *websocket.Hub, hold time.Duration) *WaitlistService {
+	return &WaitlistService{
+		entries:      config.MongoDB.Collection("waitlist"),
+		sessions:     config.MongoDB.Collection("sessions"),
+		locks:        NewRedisLockService(),
+		redis:        config.RedisClient,
+		outbox:       outbox,
+		eventService: eventService,
+		wsHub:        wsHub,
+		hold:         hold,
+	}
+}
+
+// Start drains the queue of released seats every few seconds, and runs a
+// periodic sweep that expires missed offers and offers any free seats, in
+// case a release was lost.
+func (w *WaitlistService) Start(ctx context.Context) {
+	_, err := w.entries.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "sessionId", Value: 1}, {Key: "status", Value: 1}, {Key: "createdAt", Value: 1}}},
+		{Keys: bson.D{{Key: "sessionId", Value: 1}, {Key: "userId", Value: 1}}},
+	})
+	if err != nil {
+		log.Printf("⚠️ Failed to create waitlist indexes: %v", err)
+	}
+
+	log.Printf("⏳ Waitlist worker started (seats held for %s)", w.hold)
+
+	queue := time.NewTicker(waitlistQueueInterval)
+	defer queue.Stop()
+	sweep := time.NewTicker(waitlistPollInterval)
+	defer sweep.Stop()
+
+	for {
+		var n int
+		var err error
+		select {
+		case <-ctx.Done():
+			log.Println("⏳ Waitlist worker stopped")
+			return
+		case <-queue.C:
+			n, err = w.Drain(ctx)
+		case <-sweep.C:
+			n, err = w.RunOnce(ctx)
+		}
+
+		if err != nil {
+			log.Printf("⚠️ Waitlist run failed: %v", err)
+		} else if n > 0 {
+			log.Printf("⏳ Offered seats to %d waitlisted customer(s)", n)
+		}
+	}
+}
+
+// Drain offers the seats queued by SeatsReleased and returns how many offers
+// were made. Only the lease holder drains.
+func (w *WaitlistService) Drain(ctx context.Context) (int, error) {
+	ok, err := AcquireLease(ctx, waitlistLeaseName, 2*waitlistPollInterval)
+	if err != nil || !ok {
+		return 0, err
+	}
+	if err := w.redis.Set(ctx, waitlistWorkerKey, InstanceID, waitlistPendingHold).Err(); err != nil {
+		return 0, err
+	}
+
+	offered := 0
+	for {
+		sessionID, err := w.redis.SPop(ctx, waitlistQueueKey).Result()
+		if errors.Is(err, redis.Nil) {
+			return offered, nil
+		}
+		if err != nil {
+			return offered, err
+		}
+
+		var released *redis.StringSliceCmd
+		_, err = w.redis.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			released = pipe.SMembers(ctx, waitlistReleasedKey+sessionID)
+			pipe.Del(ctx, waitlistReleasedKey+sessionID)
+			return nil
+		})
+		if err != nil {
+			return offered, err
+		}
+		offered += len(w.serve(ctx, sessionID, released.Val()))
+	}
+}
+
+// RunOnce offers free seats in every session with customers waiting and
+// returns how many offers were made.
+func (w *WaitlistService) RunOnce(ctx context.Context) (int, error) {
+	ok, err := AcquireLease(ctx, waitlistLeaseName, 2*waitlistPollInterval)
+	if err != nil || !ok {
+		return 0, err
+	}
+
+	ids, err := w.entries.Distinct(ctx, "sessionId", bson.M{"status": bson.M{"$in": activeWaitlistStatuses}})
+	if err != nil {
+		return 0, err
+	}
+	var sessionIDs []string
+	for _, id := range ids {
+		if oid, ok := id.(primitive.ObjectID); ok {
+			sessionIDs = append(sessionIDs, oid.Hex())
+		}
+	}
+	if err := w.syncWaitingSessions(ctx, sessionIDs); err != nil {
+		log.Printf("⚠️ Failed to update the sessions with a waitlist: %v", err)
+	}
+
+	offered := 0
+	for _, sessionID := range sessionIDs {
+		offered += len(w.serve(ctx, sessionID, nil))
+	}
+	return offered, nil
+}
+
+// syncWaitingSessions makes the Redis set SeatsReleased checks match the
+// sessions that have customers on their waitlist. Sessions added by Join in
+// the meantime are kept.
+func (w *WaitlistService) syncWaitingSessions(ctx context.Context, sessionIDs []string) error {
+	known, err := w.redis.SMembers(ctx, waitlistSessionsKey).Result()
+	if err != nil {
+		return err
+	}
+	var stale []interface{}
+	for _, id := range known {
+		if !containsString(sessionIDs, id) {
+			stale = append(stale, id)
+		}
+	}
+	if len(stale) == 0 {
+		return nil
+	}
+	return w.redis.SRem(ctx, waitlistSessionsKey, stale...).Err()
+}
+
+// Join adds a customer to the waitlist of a sold-out session. A customer who
+// is already waiting gets their existing entry back, with created false.
+func (w *WaitlistService) Join(ctx context.Context, sessionID, userID string, req models.JoinWaitlistRequest) (*models.WaitlistEntry, bool, error) {
+	if req.PartySize < 1 || req.PartySize > MaxPartySize {
+		return nil, false, ErrInvalidPartySize
+	}
+
+	session, err := w.loadSession(ctx, sessionID)
+	if err != nil {
+		return nil, false, err
+	}
+	if err := CheckSessionOnSale(*session, time.Now().UTC(), config.AppConfig.SalesCutoff); err != nil {
+		return nil, false, err
+	}
+	if req.PartySize > len(session.Seats) {
+		return nil, false, ErrInvalidPartySize
+	}
+	free, err := w.freeSeats(ctx, session)
+	if err != nil {
+		return nil, false, err
+	}
+	if len(free) > 0 {
+		return nil, false, &SeatsAvailableError{Available: len(free)}
+	}
+
+	if entry, err := w.Get(ctx, sessionID, userID); err == nil {
+		return entry, false, nil
+	} else if !errors.Is(err, ErrWaitlistEntryNotFound) {
+		return nil, false, err
+	}
+
+	now := time.Now().UTC()
+	entry := models.WaitlistEntry{
+		SessionID: session.ID,
+		UserID:    userID,
+		UserEmail: req.UserEmail,
+		Locale:    req.Locale,
+		PartySize: req.PartySize,
+		Status:    models.WaitlistWaiting,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	result, err := w.entries.InsertOne(ctx, entry)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to join waitlist: %w", err)
+	}
+	entry.ID = result.InsertedID.(primitive.ObjectID)
+	if err := w.redis.SAdd(ctx, waitlistSessionsKey, sessionID).Err(); err != nil {
+		log.Printf("⚠️ Failed to mark session %s as having a waitlist: %v", sessionID, err)
+	}
+	if entry.Position, err = w.position(ctx, entry); err != nil {
+		return nil, false, err
+	}
+
+	go w.eventService.LogWaitlistJoined(context.Background(), entry)
+
+	return &entry, true, nil
+}
+
+// Get returns a customer's waiting or offered entry for a session.
+func (w *WaitlistService) Get(ctx context.Context, sessionID, userID string) (*models.WaitlistEntry, error) {
+	oid, err := primitive.ObjectIDFromHex(sessionID)
+	if err != nil {
+		return nil, ErrWaitlistEntryNotFound
+	}
+
+	var entry models.WaitlistEntry
+	err = w.entries.FindOne(ctx, bson.M{
+		"sessionId": oid,
+		"userId":    userID,
+		"status":    bson.M{"$in": activeWaitlistStatuses},
+	}).Decode(&entry)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrWaitlistEntryNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if entry.Status == models.WaitlistWaiting {
+		if entry.Position, err = w.position(ctx, entry); err != nil {
+			return nil, err
+		}
+	}
+	return &entry, nil
+}
+
+// Leave takes a customer off the waitlist. Seats held for them go to the next
+// customer without being unlocked in between.
+func (w *WaitlistService) Leave(ctx context.Context, sessionID, userID string) (*models.WaitlistEntry, error) {
+	oid, err := primitive.ObjectIDFromHex(sessionID)
+	if err != nil {
+		return nil, ErrWaitlistEntryNotFound
+	}
+
+	var entry models.WaitlistEntry
+	err = w.entries.FindOneAndUpdate(ctx,
+		bson.M{"sessionId": oid, "userId": userID, "status": bson.M{"$in": activeWaitlistStatuses}},
+		bson.M{"$set": bson.M{"status": models.WaitlistLeft, "updatedAt": time.Now().UTC()}},
+	).Decode(&entry)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrWaitlistEntryNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if entry.Status == models.WaitlistOffered && len(entry.OfferedSeats) > 0 {
+		w.SeatsReleased(ctx, sessionID, entry.OfferedSeats, userID)
+	}
+	entry.Status = models.WaitlistLeft
+	return &entry, nil
+}
+
+// MarkBooked closes the offer of a customer who booked a session.
+func (w *WaitlistService) MarkBooked(ctx context.Context, sessionID primitive.ObjectID, userID string) {
+	_, err := w.entries.UpdateOne(ctx,
+		bson.M{"sessionId": sessionID, "userId": userID, "status": models.WaitlistOffered},
+		bson.M{"$set": bson.M{"status": models.WaitlistBooked, "updatedAt": time.Now().UTC()}},
+	)
+	if err != nil {
+		log.Printf("⚠️ Failed to close waitlist offer of %s for session %s: %v", userID, sessionID.Hex(), err)
+	}
+}
+
+// SeatsReleased frees seatIDs of a session: seats owner had locked, or seats
+// nobody has locked when owner is "", e.g. after a cancellation or a lock
+// expiry. It only talks to Redis, so it is safe to call from a request or
+// the lock expiry monitor. If customers are waiting and the worker is
+// running, the seats stay locked, handed to the waitlist in one step, and
+// are queued for the worker to offer. Otherwise they are unlocked and
+// announced as AVAILABLE.
+func (w *WaitlistService) SeatsReleased(ctx context.Context, sessionID string, seatIDs []string, owner string) {
+	var held []string
+	if w.hasWaitingCustomers(ctx, sessionID) {
+		moved, err := w.locks.TransferSeats(ctx, sessionID, seatIDs, owner, waitlistHolder, waitlistPendingHold)
+		if err != nil {
+			log.Printf("⚠️ Failed to hold released seats of session %s for the waitlist: %v", sessionID, err)
+		} else if len(moved) > 0 {
+			members := make([]interface{}, len(moved))
+			for i, seatID := range moved {
+				members[i] = seatID
+			}
+			_, err = w.redis.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+				pipe.SAdd(ctx, waitlistReleasedKey+sessionID, members...)
+				pipe.SAdd(ctx, waitlistQueueKey, sessionID)
+				return nil
+			})
+			if err != nil {
+				// The sweep offers them, or the hold runs out.
+				log.Printf("⚠️ Failed to queue released seats of session %s: %v", sessionID, err)
+			}
+			held = moved
+		}
+	}
+
+	var free []string
+	for _, seatID := range seatIDs {
+		if !containsString(held, seatID) {
+			free = append(free, seatID)
+		}
+	}
+	if len(free) == 0 {
+		return
+	}
+	if owner != "" {
+		w.locks.UnlockMultipleSeats(ctx, sessionID, free, owner)
+	}
+	updates := make([]models.SeatUpdate, 0, len(free))
+	for _, seatID := range free {
+		updates = append(updates, models.SeatUpdate{SeatID: seatID, Status: models.SeatAvailable})
+	}
+	w.wsHub.BroadcastMultipleSeatUpdates(sessionID, updates)
+}
+
+// hasWaitingCustomers reports whether released seats of a session should be
+// held for its waitlist: someone is on it and a worker has run recently to
+// offer them.
+func (w *WaitlistService) hasWaitingCustomers(ctx context.Context, sessionID string) bool {
+	var worker *redis.IntCmd
+	var waiting *redis.BoolCmd
+	_, err := w.redis.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		worker = pipe.Exists(ctx, waitlistWorkerKey)
+		waiting = pipe.SIsMember(ctx, waitlistSessionsKey, sessionID)
+		return nil
+	})
+	if err != nil {
+		log.Printf("⚠️ Failed to check the waitlist of session %s: %v", sessionID, err)
+		return false
+	}
+	return worker.Val() > 0 && waiting.Val()
+}
+
+// serve offers the free seats of a session to its waitlist, then lets go of
+// the released seats nobody was offered. Clients are told about the seats
+// offered, as LOCKED, and the released seats left over, as AVAILABLE. It
+// returns the offers made.
+func (w *WaitlistService) serve(ctx context.Context, sessionID string, released []string) []models.WaitlistOffer {
+	offers, err := w.offer(ctx, sessionID)
+	if err != nil {
+		log.Printf("⚠️ Failed to offer seats of session %s to the waitlist: %v", sessionID, err)
+	}
+
+	held := make(map[string]bool)
+	var updates []models.SeatUpdate
+	for _, offer := range offers {
+		for _, seatID := range offer.SeatIDs {
+			held[seatID] = true
+			updates = append(updates, models.SeatUpdate{
+				SeatID:   seatID,
+				Status:   models.SeatLocked,
+				LockedBy: offer.UserID,
+			})
+		}
+	}
+	var leftover []string
+	for _, seatID := range released {
+		if !held[seatID] {
+			leftover = append(leftover, seatID)
+			updates = append(updates, models.SeatUpdate{SeatID: seatID, Status: models.SeatAvailable})
+		}
+	}
+	w.locks.UnlockMultipleSeats(ctx, sessionID, leftover, waitlistHolder)
+	if len(updates) > 0 {
+		w.wsHub.BroadcastMultipleSeatUpdates(sessionID, updates)
+	}
+	return offers
+}
+
+// offer expires lapsed offers of a session and offers its free seats to the
+// waiting customers in the order they joined. Customers whose party does not
+// fit in the free seats are skipped, not removed.
+func (w *WaitlistService) offer(ctx context.Context, sessionID string) ([]models.WaitlistOffer, error) {
+	oid, err := primitive.ObjectIDFromHex(sessionID)
+	if err != nil {
+		return nil, nil
+	}
+
+	now := time.Now().UTC()
+	_, err = w.entries.UpdateMany(ctx,
+		bson.M{"sessionId": oid, "status": models.WaitlistOffered, "offerExpiresAt": bson.M{"$lte": now}},
+		bson.M{"$set": bson.M{"status": models.WaitlistExpired, "updatedAt": now}},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to expire offers: %w", err)
+	}
+
+	cursor, err := w.entries.Find(ctx,
+		bson.M{"sessionId": oid, "status": models.WaitlistWaiting},
+		options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}, {Key: "_id", Value: 1}}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	var waiting []models.WaitlistEntry
+	if err := cursor.All(ctx, &waiting); err != nil {
+		return nil, err
+	}
+	if len(waiting) == 0 {
+		return nil, nil
+	}
+
+	session, err := w.loadSession(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if CheckSessionOnSale(*session, now, config.AppConfig.SalesCutoff) != nil {
+		return nil, nil
+	}
+	free, err := w.freeSeats(ctx, session)
+	if err != nil {
+		return nil, err
+	}
+
+	var offers []models.WaitlistOffer
+	for _, entry := range waiting {
+		if len(free) == 0 {
+			break
+		}
+		seatIDs := pickWaitlistSeats(session, free, entry.PartySize)
+		if seatIDs == nil {
+			continue
+		}
+
+		expiresAt := time.Now().UTC().Add(w.hold)
+		ok, err := w.locks.TransferAllSeats(ctx, sessionID, seatIDs, waitlistHolder, entry.UserID, w.hold)
+		if err != nil || !ok {
+			// Someone locked them first; they are not free any more.
+			free = withoutSeats(free, seatIDs)
+			continue
+		}
+
+		result, err := w.entries.UpdateOne(ctx,
+			bson.M{"_id": entry.ID, "status": models.WaitlistWaiting},
+			bson.M{"$set": bson.M{
+				"status":         models.WaitlistOffered,
+				"offeredSeats":   seatIDs,
+				"offeredAt":      now,
+				"offerExpiresAt": expiresAt,
+				"updatedAt":      now,
+			}},
+		)
+		if err != nil || result.MatchedCount == 0 {
+			// The customer left, or another instance served them. Hold the
+			// seats for the next customer again.
+			w.locks.TransferAllSeats(ctx, sessionID, seatIDs, entry.UserID, waitlistHolder, waitlistPendingHold)
+			continue
+		}
+		free = withoutSeats(free, seatIDs)
+
+		offer := models.WaitlistOffer{
+			EntryID:   entry.ID.Hex(),
+			UserID:    entry.UserID,
+			SeatIDs:   seatIDs,
+			ExpiresAt: expiresAt,
+		}
+		offers = append(offers, offer)
+		w.notify(ctx, session, entry, offer)
+	}
+	return offers, nil
+}
+
+func (w *WaitlistService) notify(ctx context.Context, session *models.MovieSession, entry models.WaitlistEntry, offer models.WaitlistOffer) {
+	sessionID := session.ID.Hex()
+	log.Printf("⏳ Holding seats %v of session %s for waitlisted user %s until %s", offer.SeatIDs, sessionID, entry.UserID, offer.ExpiresAt.Format(time.RFC3339))
+
+	w.wsHub.BroadcastWaitlistOffer(sessionID, offer)
+	go w.eventService.LogWaitlistOffered(context.Background(), sessionID, offer)
+
+	if entry.UserEmail == "" || w.outbox == nil {
+		return
+	}
+	_, err := w.outbox.EnqueueBookingEmailOnce(ctx, "waitlist-offer:"+offer.EntryID, models.EmailKindWaitlistOffer, entry.UserEmail, BookingEmailData{
+		Locale:        entry.Locale,
+		UserName:      entry.UserEmail,
+		MovieTitle:    session.MovieTitle,
+		Theater:       session.Theater,
+		Seats:         offer.SeatIDs,
+		TotalAmount:   SeatsTotal(*session, offer.SeatIDs),
+		ShowTime:      session.StartTime,
+		HoldExpiresAt: offer.ExpiresAt,
+	})
+	if err != nil {
+		log.Printf("❌ Failed to queue waitlist offer email for %s: %v", entry.UserEmail, err)
+	}
+}
+
+// Depth summarises every session with customers on its waitlist, longest
+// queue first.
+func (w *WaitlistService) Depth(ctx context.Context) ([]models.WaitlistDepth, error) {
+	cursor, err := w.entries.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"status": bson.M{"$in": activeWaitlistStatuses}}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":         "$sessionId",
+			"waiting":     bson.M{"$sum": bson.M{"$cond": bson.A{bson.M{"$eq": bson.A{"$status", models.WaitlistWaiting}}, 1, 0}}},
+			"seatsWanted": bson.M{"$sum": bson.M{"$cond": bson.A{bson.M{"$eq": bson.A{"$status", models.WaitlistWaiting}}, "$partySize", 0}}},
+			"offered":     bson.M{"$sum": bson.M{"$cond": bson.A{bson.M{"$eq": bson.A{"$status", models.WaitlistOffered}}, 1, 0}}},
+			"oldestAt":    bson.M{"$min": "$createdAt"},
+		}}},
+		{{Key: "$lookup", Value: bson.M{
+			"from":         "sessions",
+			"localField":   "_id",
+			"foreignField": "_id",
+			"as":           "session",
+		}}},
+		{{Key: "$set", Value: bson.M{
+			"movieTitle": bson.M{"$first": "$session.movieTitle"},
+			"startTime":  bson.M{"$first": "$session.startTime"},
+		}}},
+		{{Key: "$project", Value: bson.M{"session": 0}}},
+		{{Key: "$sort", Value: bson.D{{Key: "waiting", Value: -1}, {Key: "startTime", Value: 1}}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	depths := []models.WaitlistDepth{}
+	if err := cursor.All(ctx, &depths); err != nil {
+		return nil, err
+	}
+	return depths, nil
+}
+
+// position is an entry's place among the waiting entries of its session.
+func (w *WaitlistService) position(ctx context.Context, entry models.WaitlistEntry) (int, error) {
+	ahead, err := w.entries.CountDocuments(ctx, bson.M{
+		"sessionId": entry.SessionID,
+		"status":    models.WaitlistWaiting,
+		"$or": []bson.M{
+			{"createdAt": bson.M{"$lt": entry.CreatedAt}},
+			{"createdAt": entry.CreatedAt, "_id": bson.M{"$lt": entry.ID}},
+		},
+	})
+	if err != nil {
+		return 0, err
+	}
+	return int(ahead) + 1, nil
+}
+
+func (w *WaitlistService) loadSession(ctx context.Context, sessionID string) (*models.MovieSession, error) {
+	oid, err := primitive.ObjectIDFromHex(sessionID)
+	if err != nil {
+		return nil, ErrSessionNotFound
+	}
+
+	var session models.MovieSession
+	err = w.sessions.FindOne(ctx, bson.M{"_id": oid},
+		options.FindOne().SetProjection(bson.M{
+			"seats.id": 1, "seats.row": 1, "seats.number": 1, "seats.status": 1, "seats.price": 1,
+			"movieTitle": 1, "theater": 1, "noSingleSeatGap": 1, "status": 1, "startTime": 1, "endTime": 1, "salesOpenAt": 1,
+		}),
+	).Decode(&session)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrSessionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load session: %w", err)
+	}
+	return &session, nil
+}
+
+// freeSeats are the seats of a session that are AVAILABLE and not locked, or
+// locked only by the waitlist itself.
+func (w *WaitlistService) freeSeats(ctx context.Context, session *models.MovieSession) ([]models.Seat, error) {
+	var candidates []models.Seat
+	var ids []string
+	for _, seat := range session.Seats {
+		if seat.Status == models.SeatAvailable || seat.Status == "" {
+			candidates = append(candidates, seat)
+			ids = append(ids, seat.ID)
+		}
+	}
+	owners, err := w.locks.LockOwners(ctx, session.ID.Hex(), ids)
+	if err != nil {
+		return nil, err
+	}
+
+	var free []models.Seat
+	for _, seat := range candidates {
+		if owner, locked := owners[seat.ID]; !locked || owner == waitlistHolder {
+			free = append(free, seat)
+		}
+	}
+	return free, nil
+}
+
+// PickWaitlistSeats chooses n free seats side by side in one row, front row
+// and lowest numbers first. It returns nil if no row has n adjacent free
+// seats, since a party is not offered seats scattered across the hall.
+func PickWaitlistSeats(free []models.Seat, n int) []string {
+	blocks := waitlistBlocks(free, n)
+	if len(blocks) == 0 {
+		return nil
+	}
+	return blocks[0].SeatIDs
+}
+
+// pickWaitlistSeats is PickWaitlistSeats for a session. With the single-seat
+// gap rule it skips blocks that would leave a lone seat, unless every block
+// would, like the rule does for customers choosing their own seats.
+func pickWaitlistSeats(session *models.MovieSession, free []models.Seat, n int) []string {
+	blocks := waitlistBlocks(free, n)
+	if len(blocks) == 0 {
+		return nil
+	}
+	if SingleSeatGapRuleApplies(session) {
+		// Seats that are available but not free are locked by someone.
+		taken := make(map[string]string)
+		for _, seatID := range availableSeatIDs(session) {
+			taken[seatID] = "locked"
+		}
+		for _, seat := range free {
+			delete(taken, seat.ID)
+		}
+		blocks = withoutSingleSeatGaps(session.Seats, taken, blocks)
+	}
+	return blocks[0].SeatIDs
+}
+
+// waitlistBlocks lists every run of n adjacent free seats in one row, front
+// row and lowest numbers first.
+func waitlistBlocks(free []models.Seat, n int) []SeatBlock {
+	if n <= 0 {
+		return nil
+	}
+	byRow := make(map[string][]models.Seat)
+	var rows []string
+	for _, seat := range free {
+		if _, ok := byRow[seat.Row]; !ok {
+			rows = append(rows, seat.Row)
+		}
+		byRow[seat.Row] = append(byRow[seat.Row], seat)
+	}
+	sort.Strings(rows)
+
+	var blocks []SeatBlock
+	for _, row := range rows {
+		seats := byRow[row]
+		sort.Slice(seats, func(i, j int) bool { return seats[i].Number < seats[j].Number })
+		start := 0
+		for i := range seats {
+			if i > 0 && seats[i].Number != seats[i-1].Number+1 {
+				start = i
+			}
+			if i-start+1 < n {
+				continue
+			}
+			ids := make([]string, 0, n)
+			for _, seat := range seats[i-n+1 : i+1] {
+				ids = append(ids, seat.ID)
+			}
+			blocks = append(blocks, SeatBlock{Row: row, SeatIDs: ids})
+		}
+	}
+	return blocks
+}
+
+func withoutSeats(seats []models.Seat, seatIDs []string) []models.Seat {
+	var out []models.Seat
+	for _, seat := range seats {
+		if !containsString(seatIDs, seat.ID) {
+			out = append(out, seat)
+		}
+	}
+	return out
+}
@@ -0,0 +1,84 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+
+	"cinema-booking-system/models"
+)
+
+func TestPickWaitlistSeats(t *testing.T) {
+	on, off := true, false
+	// freeOf is the seats of a seat map that are AVAILABLE and not in locked.
+	freeOf := func(seats []models.Seat, locked ...string) []models.Seat {
+		var free []models.Seat
+		for _, seat := range seats {
+			if seat.Status == models.SeatAvailable && !containsString(locked, seat.ID) {
+				free = append(free, seat)
+			}
+		}
+		return free
+	}
+
+	tests := []struct {
+		name   string
+		seats  []models.Seat
+		locked []string
+		rule   *bool
+		n      int
+		want   []string
+	}{
+		{
+			name:  "front row, lowest numbers",
+			seats: hall("AB", 4),
+			rule:  &off,
+			n:     2,
+			want:  []string{"A1", "A2"},
+		},
+		{
+			name:  "without the rule a lone seat may be left",
+			seats: withSeats(hall("A", 6), booked, "A4"),
+			rule:  &off,
+			n:     2,
+			want:  []string{"A1", "A2"},
+		},
+		{
+			name:  "with the rule blocks leaving a lone seat are skipped",
+			seats: withSeats(hall("A", 6), booked, "A4"),
+			rule:  &on,
+			n:     2,
+			want:  []string{"A5", "A6"},
+		},
+		{
+			name:   "seats locked by others count as taken",
+			seats:  hall("A", 6),
+			locked: []string{"A4"},
+			rule:   &on,
+			n:      2,
+			want:   []string{"A5", "A6"},
+		},
+		{
+			name:  "with no gap-free block the first block is offered",
+			seats: hall("A", 3),
+			rule:  &on,
+			n:     2,
+			want:  []string{"A1", "A2"},
+		},
+		{
+			name:  "party too large for any row",
+			seats: withSeats(hall("AB", 4), booked, "A2", "B3"),
+			rule:  &on,
+			n:     3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			session := &models.MovieSession{Seats: tt.seats, NoSingleSeatGap: tt.rule}
+			got := pickWaitlistSeats(session, freeOf(tt.seats, tt.locked...), tt.n)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("picked %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,9 @@
+package templates
+
+import "embed"
+
+// Email holds the built-in email templates, one directory per locale. Set
+// EMAIL_TEMPLATE_DIR to load them from disk instead.
+//
+//go:embed email
+var Email embed.FS
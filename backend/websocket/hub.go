@@ -135,6 +135,74 @@ func (h *Hub) BroadcastMultipleSeatUpdates(sessionID string, seatUpdates []model
 	log.Printf("📡 Broadcast %d seat updates for session=%s", len(seatUpdates), sessionID)
 }
 
+func (h *Hub) BroadcastSessionStatus(sessionID string, update models.SessionStatusUpdate) {
+	msg := models.WSMessage{
+		Type:      "SESSION_STATUS",
+		SessionID: sessionID,
+		Data:      update,
+	}
+
+	data, err := encodeJSON(msg)
+	if err != nil {
+		log.Printf("Error encoding session status: %v", err)
+		return
+	}
+
+	h.broadcast <- &BroadcastMessage{
+		SessionID: sessionID,
+		Message:   data,
+	}
+
+	log.Printf("📡 Broadcast session status: session=%s, status=%s", sessionID, update.Status)
+}
+
+func (h *Hub) BroadcastSessionCancelled(sessionID, reason string) {
+	msg := models.WSMessage{
+		Type:      "SESSION_CANCELLED",
+		SessionID: sessionID,
+		Data: models.SessionStatusUpdate{
+			Status: models.SessionCancelled,
+			Reason: reason,
+		},
+	}
+
+	data, err := encodeJSON(msg)
+	if err != nil {
+		log.Printf("Error encoding session cancellation: %v", err)
+		return
+	}
+
+	h.broadcast <- &BroadcastMessage{
+		SessionID: sessionID,
+		Message:   data,
+	}
+
+	log.Printf("📡 Broadcast session cancelled: session=%s", sessionID)
+}
+
+// BroadcastWaitlistOffer tells clients watching a session that seats are
+// held for a waitlisted customer. Only that customer's client acts on it.
+func (h *Hub) BroadcastWaitlistOffer(sessionID string, offer models.WaitlistOffer) {
+	msg := models.WSMessage{
+		Type:      "WAITLIST_OFFER",
+		SessionID: sessionID,
+		Data:      offer,
+	}
+
+	data, err := encodeJSON(msg)
+	if err != nil {
+		log.Printf("Error encoding waitlist offer: %v", err)
+		return
+	}
+
+	h.broadcast <- &BroadcastMessage{
+		SessionID: sessionID,
+		Message:   data,
+	}
+
+	log.Printf("📡 Broadcast waitlist offer: session=%s, user=%s, seats=%v", sessionID, offer.UserID, offer.SeatIDs)
+}
+
 func (h *Hub) GetClientCount(sessionID string) int {
 	h.mu.RLock()
 	defer h.mu.RUnlock()
@@ -78,6 +78,20 @@ export function useWebSocket(sessionId) {
         seatStore.updateMultipleSeats(message.data)
         break
 
+      case 'SESSION_STATUS':
+        seatStore.setSessionStatus(message.data)
+        break
+
+      case 'SESSION_CANCELLED':
+        seatStore.setSessionStatus(message.data)
+        break
+
+      case 'WAITLIST_OFFER':
+        if (message.data.userId === seatStore.userId) {
+          seatStore.setWaitlistOffer(message.data)
+        }
+        break
+
       case 'PONG':
         break
 
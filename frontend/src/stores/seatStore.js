@@ -8,6 +8,8 @@ export const useSeatStore = defineStore('seats', () => {
   const userId = ref(`user_${Date.now()}`)
   const isConnected = ref(false)
   const isLoading = ref(false)
+  // Seats the waitlist is holding for this user, from a WAITLIST_OFFER message
+  const waitlistOffer = ref(null)
 
   const availableSeats = computed(() => 
     seats.value.filter(seat => seat.status === 'AVAILABLE')
@@ -32,6 +34,15 @@ export const useSeatStore = defineStore('seats', () => {
     }, 0)
   )
 
+  // Sessions from older backends have no status; treat them as on sale.
+  const isOnSale = computed(() =>
+    !session.value?.status || session.value.status === 'ON_SALE'
+  )
+
+  const isSoldOut = computed(() =>
+    seats.value.length > 0 && availableSeats.value.length === 0
+  )
+
   const seatsByRow = computed(() => {
     const grouped = {}
     seats.value.forEach(seat => {
@@ -50,6 +61,19 @@ export const useSeatStore = defineStore('seats', () => {
     session.value = sessionData
     seats.value = sessionData.seats || []
     selectedSeats.value = []
+    waitlistOffer.value = null
+  }
+
+  function setSessionStatus(update) {
+    if (!session.value) return
+    session.value = {
+      ...session.value,
+      status: update.status,
+      salesCloseAt: update.salesCloseAt || session.value.salesCloseAt
+    }
+    if (update.status !== 'ON_SALE') {
+      selectedSeats.value = []
+    }
   }
 
   function setUserId(id) {
@@ -90,6 +114,10 @@ export const useSeatStore = defineStore('seats', () => {
     }
   }
 
+  function setWaitlistOffer(offer) {
+    waitlistOffer.value = offer
+  }
+
   function clearSelection() {
     selectedSeats.value = []
   }
@@ -121,16 +149,21 @@ export const useSeatStore = defineStore('seats', () => {
     userId,
     isConnected,
     isLoading,
+    waitlistOffer,
     availableSeats,
     lockedSeats,
     bookedSeats,
     myLockedSeats,
     totalSelectedPrice,
     seatsByRow,
+    isOnSale,
+    isSoldOut,
     setSession,
+    setSessionStatus,
     setUserId,
     updateSeat,
     updateMultipleSeats,
+    setWaitlistOffer,
     toggleSeatSelection,
     clearSelection,
     setConnectionStatus,